@@ -0,0 +1,484 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+	"github.com/spf13/pflag"
+	"go.opencensus.io/trace"
+	yaml "gopkg.in/yaml.v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/virtual-kubelet/virtual-kubelet/log"
+)
+
+const (
+	// configAPIVersion and configKind identify the version of the schema of the configuration file.
+	configAPIVersion = "virtual-kubelet.io/v1alpha1"
+	configKind       = "VirtualKubeletConfiguration"
+)
+
+// kubeletConfigFile represents the contents of the configuration file passed with --config.
+//
+// Settings are resolved in the following order of precedence: command line flags, environment variables, the
+// configuration file, and then the defaults of the flags.
+// The log level, taints, labels and number of pod synchronization workers are reloaded when SIGHUP is received.
+type kubeletConfigFile struct {
+	APIVersion string `yaml:"apiVersion"`
+	Kind       string `yaml:"kind"`
+
+	// NodeName is the name of the node, which is overridden by --nodename and DEFAULT_NODE_NAME.
+	NodeName string `yaml:"nodeName"`
+	// LogLevel is overridden by --log-level.
+	LogLevel string `yaml:"logLevel"`
+	// Taints replace the default taint of the node when set, even when empty.
+	// They are overridden by --taint, --disable-taint and VKUBELET_TAINT_KEY, VKUBELET_TAINT_VALUE and VKUBELET_TAINT_EFFECT.
+	Taints []taintConfig `yaml:"taints"`
+	// Labels are set on the node, in addition to its default labels.
+	Labels map[string]string `yaml:"labels"`
+
+	// KubeletPort is the port of the kubelet API, which is overridden by KUBELET_PORT.
+	KubeletPort int32 `yaml:"kubeletPort"`
+	// MetricsAddr is the address of the metrics server, which is overridden by --metrics-addr.
+	MetricsAddr string `yaml:"metricsAddr"`
+	// TLS configures the serving certificate of the kubelet API and the authentication of its clients.
+	TLS tlsConfig `yaml:"tls"`
+
+	// PodSyncWorkers is overridden by --pod-sync-workers.
+	PodSyncWorkers int `yaml:"podSyncWorkers"`
+	// FullResyncPeriod is overridden by --full-resync-period.
+	FullResyncPeriod time.Duration `yaml:"fullResyncPeriod"`
+
+	// Tracing is overridden field by field by the --trace-* flags.
+	Tracing tracingConfig `yaml:"tracing"`
+
+	// ProviderMiddleware configures the caching, rate limiting and tracing of the calls to the provider.
+	ProviderMiddleware providerMiddlewareConfig `yaml:"providerMiddleware"`
+
+	// Nodes are the virtual nodes served by the process, which replace the node configured by nodeName, taints, labels
+	// and the --nodename, --provider and --provider-config flags when set.
+	// Their taints and labels are reloaded when SIGHUP is received.
+	Nodes []nodeConfig `yaml:"nodes"`
+}
+
+// taintConfig is a taint of the node in the configuration file.
+type taintConfig struct {
+	Key    string             `yaml:"key"`
+	Value  string             `yaml:"value"`
+	Effect corev1.TaintEffect `yaml:"effect"`
+}
+
+// tlsConfig is the TLS configuration of the kubelet API in the configuration file.
+type tlsConfig struct {
+	// CertFile and KeyFile are overridden by APISERVER_CERT_LOCATION and APISERVER_KEY_LOCATION.
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+	// ClientCAFile is overridden by --client-ca-file.
+	ClientCAFile string `yaml:"clientCAFile"`
+}
+
+// tracingConfig is the tracing configuration in the configuration file.
+type tracingConfig struct {
+	Exporters   []string          `yaml:"exporters"`
+	ServiceName string            `yaml:"serviceName"`
+	Tags        map[string]string `yaml:"tags"`
+	SampleRate  string            `yaml:"sampleRate"`
+}
+
+// changedFlags holds the names of the flags set on the command line.
+var changedFlags = make(map[string]bool)
+
+// recordChangedFlags records the flags of the specified set which were set on the command line.
+func recordChangedFlags(flags *pflag.FlagSet) {
+	flags.Visit(func(f *pflag.Flag) {
+		changedFlags[f.Name] = true
+	})
+}
+
+// reloadableConfig holds the settings which are reloaded from the configuration file on SIGHUP.
+type reloadableConfig struct {
+	LogLevel logrus.Level
+	Taints   []corev1.Taint
+	Labels   map[string]string
+	// Nodes holds the taints and labels of each of the nodes of the configuration file, by name, which replace Taints
+	// and Labels when set.
+	Nodes          map[string]nodeMetadata
+	PodSyncWorkers int
+}
+
+// nodeMetadata holds the taints and labels of a node.
+type nodeMetadata struct {
+	Taints []corev1.Taint
+	Labels map[string]string
+}
+
+// metadata returns the taints and labels of the specified node, and whether it is configured.
+func (rc reloadableConfig) metadata(name string) (nodeMetadata, bool) {
+	if rc.Nodes == nil {
+		return nodeMetadata{Taints: rc.Taints, Labels: rc.Labels}, true
+	}
+	md, ok := rc.Nodes[name]
+	return md, ok
+}
+
+// loadConfigFile reads and validates the specified configuration file.
+func loadConfigFile(path string) (*kubeletConfigFile, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading configuration file")
+	}
+
+	var cfg kubeletConfigFile
+	// Unknown fields are rejected, so that misspelled settings aren't silently ignored.
+	if err := yaml.UnmarshalStrict(b, &cfg); err != nil {
+		return nil, errors.Wrapf(err, "error parsing configuration file %s", path)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid configuration file %s", path)
+	}
+	return &cfg, nil
+}
+
+// validate checks the configuration file against its schema, returning all the problems found.
+func (cfg *kubeletConfigFile) validate() error {
+	var errs []string
+	if cfg.APIVersion != configAPIVersion {
+		errs = append(errs, fmt.Sprintf("apiVersion must be %s", configAPIVersion))
+	}
+	if cfg.Kind != configKind {
+		errs = append(errs, fmt.Sprintf("kind must be %s", configKind))
+	}
+	if cfg.LogLevel != "" {
+		if _, err := log.ParseLevel(cfg.LogLevel); err != nil {
+			errs = append(errs, fmt.Sprintf("logLevel: %v", err))
+		}
+	}
+
+	errs = append(errs, validateTaints(cfg.Taints)...)
+	errs = append(errs, validateLabels(cfg.Labels)...)
+
+	if cfg.KubeletPort < 0 || cfg.KubeletPort > 65535 {
+		errs = append(errs, "kubeletPort must be between 1 and 65535")
+	}
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		errs = append(errs, "tls.certFile and tls.keyFile must be set together")
+	}
+	if cfg.PodSyncWorkers < 0 {
+		errs = append(errs, "podSyncWorkers must not be negative")
+	}
+	if cfg.FullResyncPeriod < 0 {
+		errs = append(errs, "fullResyncPeriod must not be negative")
+	}
+
+	for i, e := range cfg.Tracing.Exporters {
+		if _, ok := tracingExporters[e]; !ok && e != "zpages" {
+			errs = append(errs, fmt.Sprintf("tracing.exporters[%d]: exporter %q not found, available exporters: %s", i, e, AvailableTraceExporters()))
+		}
+	}
+	for k := range cfg.Tracing.Tags {
+		if reservedTagNames[k] {
+			errs = append(errs, fmt.Sprintf("tracing.tags[%s]: must not use a reserved tag key", k))
+		}
+	}
+	if _, err := parseTraceSampler(cfg.Tracing.SampleRate); err != nil {
+		errs = append(errs, fmt.Sprintf("tracing.sampleRate: %v", err))
+	}
+	for _, msg := range cfg.ProviderMiddleware.validate() {
+		errs = append(errs, "providerMiddleware."+msg)
+	}
+
+	if len(cfg.Nodes) > 0 && (cfg.NodeName != "" || cfg.Taints != nil || cfg.Labels != nil) {
+		errs = append(errs, "nodeName, taints and labels must be set on each of the nodes when nodes are set")
+	}
+	for i, n := range cfg.Nodes {
+		for _, msg := range n.validate() {
+			errs = append(errs, fmt.Sprintf("nodes[%d].%s", i, msg))
+		}
+		for _, m := range cfg.Nodes[:i] {
+			if m.Name == n.Name {
+				errs = append(errs, fmt.Sprintf("nodes[%d]: duplicate node %s", i, n.Name))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// validateTaints checks the taints of a node, returning all the problems found.
+func validateTaints(taints []taintConfig) []string {
+	var errs []string
+	for i, t := range taints {
+		for _, msg := range validation.IsQualifiedName(t.Key) {
+			errs = append(errs, fmt.Sprintf("taints[%d].key: %s", i, msg))
+		}
+		if t.Value != "" {
+			for _, msg := range validation.IsValidLabelValue(t.Value) {
+				errs = append(errs, fmt.Sprintf("taints[%d].value: %s", i, msg))
+			}
+		}
+		switch t.Effect {
+		case corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			errs = append(errs, fmt.Sprintf("taints[%d].effect: %q is not supported", i, t.Effect))
+		}
+		for _, u := range taints[:i] {
+			if u.Key == t.Key && u.Effect == t.Effect {
+				errs = append(errs, fmt.Sprintf("taints[%d]: duplicate taint %s:%s", i, t.Key, t.Effect))
+			}
+		}
+	}
+	return errs
+}
+
+// validateLabels checks the labels of a node, returning all the problems found.
+func validateLabels(labels map[string]string) []string {
+	var errs []string
+	for k, v := range labels {
+		for _, msg := range validation.IsQualifiedName(k) {
+			errs = append(errs, fmt.Sprintf("labels[%s]: %s", k, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(v) {
+			errs = append(errs, fmt.Sprintf("labels[%s]: %s", k, msg))
+		}
+	}
+	return errs
+}
+
+// flagChanged returns whether the specified flag was set on the command line.
+func flagChanged(name string) bool {
+	return changedFlags[name]
+}
+
+// envSet returns whether any of the specified environment variables is set.
+func envSet(keys ...string) bool {
+	for _, k := range keys {
+		if _, ok := os.LookupEnv(k); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// applyConfigFile sets the settings which aren't reloaded from the configuration file, unless they are overridden by
+// command line flags or environment variables.
+func applyConfigFile(cfg *kubeletConfigFile) {
+	if cfg.NodeName != "" && !flagChanged("nodename") && !envSet("DEFAULT_NODE_NAME") {
+		nodeName = cfg.NodeName
+	}
+	if cfg.KubeletPort != 0 && !envSet("KUBELET_PORT") {
+		kubeletPort = cfg.KubeletPort
+	}
+	if cfg.MetricsAddr != "" && !flagChanged("metrics-addr") {
+		metricsAddr = cfg.MetricsAddr
+	}
+	if !envSet("APISERVER_CERT_LOCATION", "APISERVER_KEY_LOCATION") {
+		certPath, keyPath = cfg.TLS.CertFile, cfg.TLS.KeyFile
+	}
+	if cfg.TLS.ClientCAFile != "" && !flagChanged("client-ca-file") {
+		apiAuth.ClientCAFile = cfg.TLS.ClientCAFile
+	}
+	if cfg.FullResyncPeriod != 0 && !flagChanged("full-resync-period") {
+		kubeSharedInformerFactoryResync = cfg.FullResyncPeriod
+	}
+
+	if cfg.Tracing.Exporters != nil && !flagChanged("trace-exporter") {
+		userTraceExporters = cfg.Tracing.Exporters
+	}
+	if cfg.Tracing.ServiceName != "" && !flagChanged("trace-service-name") {
+		userTraceConfig.ServiceName = cfg.Tracing.ServiceName
+	}
+	if !flagChanged("trace-tag") {
+		for k, v := range cfg.Tracing.Tags {
+			userTraceConfig.Tags[k] = v
+		}
+	}
+	if cfg.Tracing.SampleRate != "" && !flagChanged("trace-sample-rate") {
+		traceSampler = cfg.Tracing.SampleRate
+	}
+
+	if cfg.ProviderMiddleware.Trace != nil && !flagChanged("provider-trace") {
+		*providerMiddleware.Trace = *cfg.ProviderMiddleware.Trace
+	}
+	if cfg.ProviderMiddleware.CacheTTL != 0 && !flagChanged("provider-cache-ttl") {
+		providerMiddleware.CacheTTL = cfg.ProviderMiddleware.CacheTTL
+	}
+	if cfg.ProviderMiddleware.RateLimit.QPS != 0 && !flagChanged("provider-rate-limit-qps") {
+		providerMiddleware.RateLimit.QPS = cfg.ProviderMiddleware.RateLimit.QPS
+	}
+	if cfg.ProviderMiddleware.RateLimit.Burst != 0 && !flagChanged("provider-rate-limit-burst") {
+		providerMiddleware.RateLimit.Burst = cfg.ProviderMiddleware.RateLimit.Burst
+	}
+}
+
+// resolveReloadableConfig resolves the settings which are reloaded on SIGHUP from the command line flags, the
+// environment and the specified configuration file, which may be nil.
+func resolveReloadableConfig(cfg *kubeletConfigFile) (reloadableConfig, error) {
+	if cfg == nil {
+		cfg = &kubeletConfigFile{}
+	}
+	var rc reloadableConfig
+
+	level := logLevel
+	if cfg.LogLevel != "" && !flagChanged("log-level") {
+		level = cfg.LogLevel
+	}
+	var err error
+	if rc.LogLevel, err = log.ParseLevel(level); err != nil {
+		return rc, errors.Errorf("log level %q is not supported", level)
+	}
+
+	switch {
+	case disableTaint:
+	case cfg.Taints != nil && !flagChanged("taint") && !envSet("VKUBELET_TAINT_KEY", "VKUBELET_TAINT_VALUE", "VKUBELET_TAINT_EFFECT"):
+		rc.Taints = toTaints(cfg.Taints)
+	default:
+		taint, err := getTaint(taintKey, provider)
+		if err != nil {
+			return rc, errors.Wrap(err, "error setting up desired kubernetes node taint")
+		}
+		rc.Taints = []corev1.Taint{*taint}
+	}
+	rc.Labels = cfg.Labels
+
+	// The taints of the nodes of the configuration file aren't overridden by the taint flags and environment
+	// variables, which only configure their default taint.
+	if len(cfg.Nodes) > 0 {
+		rc.Nodes = make(map[string]nodeMetadata, len(cfg.Nodes))
+	}
+	for _, n := range cfg.Nodes {
+		md := nodeMetadata{Labels: n.Labels}
+		switch {
+		case n.Taints != nil:
+			md.Taints = toTaints(n.Taints)
+		case !disableTaint:
+			key := taintKey
+			if key == "" {
+				key = DefaultTaintKey
+			}
+			taint, err := getTaint(key, n.Provider)
+			if err != nil {
+				return rc, errors.Wrapf(err, "error setting up desired kubernetes node taint of node %s", n.Name)
+			}
+			md.Taints = []corev1.Taint{*taint}
+		}
+		rc.Nodes[n.Name] = md
+	}
+
+	rc.PodSyncWorkers = podSyncWorkers
+	if cfg.PodSyncWorkers != 0 && !flagChanged("pod-sync-workers") {
+		rc.PodSyncWorkers = cfg.PodSyncWorkers
+	}
+	if rc.PodSyncWorkers <= 0 {
+		return rc, errors.New("the number of pod synchronization workers must be positive")
+	}
+
+	return rc, nil
+}
+
+// toTaints converts the taints of the configuration file.
+func toTaints(taints []taintConfig) []corev1.Taint {
+	converted := make([]corev1.Taint, 0, len(taints))
+	for _, t := range taints {
+		converted = append(converted, corev1.Taint{Key: t.Key, Value: t.Value, Effect: t.Effect})
+	}
+	return converted
+}
+
+// reloadConfigFile reloads the configuration file, applying the changes to the log level, taints, labels and number of
+// pod synchronization workers of the specified nodes. Changes to other settings are only applied on restart.
+func reloadConfigFile(ctx context.Context, nodes []*virtualNode) error {
+	if kubeletConfig == "" {
+		return errors.New("no configuration file to reload, use --config")
+	}
+	cfg, err := loadConfigFile(kubeletConfig)
+	if err != nil {
+		return err
+	}
+	rc, err := resolveReloadableConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	logrus.SetLevel(rc.LogLevel)
+	for _, n := range nodes {
+		// Nodes removed from the configuration file are only stopped on restart.
+		md, ok := rc.metadata(n.name)
+		if !ok {
+			continue
+		}
+		if err := n.vk.UpdateNodeMetadata(ctx, md.Taints, md.Labels); err != nil {
+			return errors.Wrapf(err, "error updating node %s", n.name)
+		}
+		n.vk.SetPodSyncWorkers(rc.PodSyncWorkers)
+	}
+
+	if changed := restartRequired(loadedConfigFile, cfg); len(changed) > 0 {
+		log.G(ctx).WithField("settings", strings.Join(changed, ", ")).Warn("Configuration changes require a restart to be applied")
+	}
+	loadedConfigFile = cfg
+	log.G(ctx).WithField("config", kubeletConfig).Info("Reloaded configuration file")
+	return nil
+}
+
+// restartRequired returns the settings which differ between the specified configuration files and which aren't reloaded.
+func restartRequired(old, cfg *kubeletConfigFile) []string {
+	var changed []string
+	for name, values := range map[string][2]interface{}{
+		"nodeName":           {old.NodeName, cfg.NodeName},
+		"kubeletPort":        {old.KubeletPort, cfg.KubeletPort},
+		"metricsAddr":        {old.MetricsAddr, cfg.MetricsAddr},
+		"tls":                {old.TLS, cfg.TLS},
+		"fullResyncPeriod":   {old.FullResyncPeriod, cfg.FullResyncPeriod},
+		"tracing":            {old.Tracing, cfg.Tracing},
+		"providerMiddleware": {old.ProviderMiddleware, cfg.ProviderMiddleware},
+		"nodes":              {nodeProviders(old.Nodes), nodeProviders(cfg.Nodes)},
+	} {
+		if !reflect.DeepEqual(values[0], values[1]) {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// nodeProviders returns the nodes without the taints and labels, which are reloaded.
+func nodeProviders(nodes []nodeConfig) []nodeConfig {
+	var stripped []nodeConfig
+	for _, n := range nodes {
+		stripped = append(stripped, nodeConfig{Name: n.Name, Provider: n.Provider, ProviderConfig: n.ProviderConfig})
+	}
+	return stripped
+}
+
+// parseTraceSampler parses the trace sample rate, returning nil when the default sampler must be used.
+func parseTraceSampler(rate string) (trace.Sampler, error) {
+	switch strings.ToLower(rate) {
+	case "":
+		return nil, nil
+	case "always":
+		return trace.AlwaysSample(), nil
+	case "never":
+		return trace.NeverSample(), nil
+	}
+	r, err := strconv.Atoi(rate)
+	if err != nil {
+		return nil, errors.Errorf("unsupported trace sample rate %q, supported values: always, never, or number 0-100", rate)
+	}
+	if r < 0 || r > 100 {
+		return nil, errors.Errorf("trace sample rate %d must not be less than zero or greater than 100", r)
+	}
+	return trace.ProbabilitySampler(float64(r) / 100), nil
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const testConfig = `
+apiVersion: virtual-kubelet.io/v1alpha1
+kind: VirtualKubeletConfiguration
+nodeName: vk-0
+logLevel: debug
+taints:
+- key: example.com/dedicated
+  value: batch
+  effect: NoExecute
+labels:
+  example.com/tier: batch
+kubeletPort: 10260
+tls:
+  certFile: tls.crt
+  keyFile: tls.key
+podSyncWorkers: 4
+fullResyncPeriod: 2m
+tracing:
+  sampleRate: always
+`
+
+// writeConfigFile writes the specified configuration file, returning its path.
+func writeConfigFile(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "virtual-kubelet-config")
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteString(content)
+	require.NoError(t, err)
+	return f.Name()
+}
+
+func TestLoadConfigFile(t *testing.T) {
+	path := writeConfigFile(t, testConfig)
+	defer os.Remove(path)
+
+	cfg, err := loadConfigFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "vk-0", cfg.NodeName)
+	assert.Equal(t, []taintConfig{{Key: "example.com/dedicated", Value: "batch", Effect: corev1.TaintEffectNoExecute}}, cfg.Taints)
+	assert.Equal(t, int32(10260), cfg.KubeletPort)
+	assert.Equal(t, 2*time.Minute, cfg.FullResyncPeriod)
+	assert.Equal(t, tlsConfig{CertFile: "tls.crt", KeyFile: "tls.key"}, cfg.TLS)
+}
+
+func TestLoadConfigFileInvalid(t *testing.T) {
+	for name, tc := range map[string]struct {
+		content string
+		errs    []string
+	}{
+		"unknown field": {
+			content: strings.Replace(testConfig, "nodeName:", "node:", 1),
+			errs:    []string{"field node not found"},
+		},
+		"version": {
+			content: strings.Replace(testConfig, "v1alpha1", "v1", 1),
+			errs:    []string{"apiVersion must be virtual-kubelet.io/v1alpha1"},
+		},
+		"values": {
+			content: strings.NewReplacer("NoExecute", "Never", "batch\nkubeletPort", "-batch-\nkubeletPort", "podSyncWorkers: 4", "podSyncWorkers: -1").Replace(testConfig),
+			errs:    []string{`taints[0].effect: "Never" is not supported`, "labels[example.com/tier]:", "podSyncWorkers must not be negative"},
+		},
+		"provider middleware": {
+			content: testConfig + "providerMiddleware:\n  cacheTTL: -1s\n  rateLimit:\n    qps: -1\n",
+			errs:    []string{"providerMiddleware.cacheTTL must not be negative", "providerMiddleware.rateLimit.qps must not be negative"},
+		},
+		"nodes": {
+			content: testConfig + "nodes:\n- name: vk_0\n  provider: mock\n- name: vk-1\n- name: vk-1\n  provider: mock\n",
+			errs:    []string{"nodeName, taints and labels must be set on each of the nodes", "nodes[0].name:", "nodes[1].provider is required", "nodes[2]: duplicate node vk-1"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := writeConfigFile(t, tc.content)
+			defer os.Remove(path)
+
+			_, err := loadConfigFile(path)
+			require.Error(t, err)
+			for _, msg := range tc.errs {
+				assert.Contains(t, err.Error(), msg)
+			}
+		})
+	}
+}
+
+// TestResolveReloadableConfig verifies that the settings of the configuration file are overridden by flags and the
+// environment.
+func TestResolveReloadableConfig(t *testing.T) {
+	path := writeConfigFile(t, testConfig)
+	defer os.Remove(path)
+	cfg, err := loadConfigFile(path)
+	require.NoError(t, err)
+
+	defer func(level string, workers int, p string) {
+		logLevel, podSyncWorkers, provider = level, workers, p
+		changedFlags = make(map[string]bool)
+	}(logLevel, podSyncWorkers, provider)
+	logLevel, podSyncWorkers, provider = "info", 10, "mock"
+
+	rc, err := resolveReloadableConfig(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, logrus.DebugLevel, rc.LogLevel)
+	assert.Equal(t, []corev1.Taint{{Key: "example.com/dedicated", Value: "batch", Effect: corev1.TaintEffectNoExecute}}, rc.Taints)
+	assert.Equal(t, map[string]string{"example.com/tier": "batch"}, rc.Labels)
+	assert.Equal(t, 4, rc.PodSyncWorkers)
+
+	changedFlags = map[string]bool{"log-level": true, "pod-sync-workers": true}
+	os.Setenv("VKUBELET_TAINT_EFFECT", "PreferNoSchedule")
+	defer os.Unsetenv("VKUBELET_TAINT_EFFECT")
+	rc, err = resolveReloadableConfig(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, logrus.InfoLevel, rc.LogLevel)
+	assert.Equal(t, []corev1.Taint{{Key: DefaultTaintKey, Value: "mock", Effect: corev1.TaintEffectPreferNoSchedule}}, rc.Taints)
+	assert.Equal(t, 10, rc.PodSyncWorkers)
+
+	// At least one worker is needed for pods to be synchronized.
+	podSyncWorkers = 0
+	_, err = resolveReloadableConfig(cfg)
+	assert.EqualError(t, err, "the number of pod synchronization workers must be positive")
+}
+
+// TestApplyConfigFileProviderTrace verifies that the configuration file can disable the tracing of the provider,
+// which is enabled by default.
+func TestApplyConfigFileProviderTrace(t *testing.T) {
+	path := writeConfigFile(t, "apiVersion: "+configAPIVersion+"\nkind: "+configKind+"\nproviderMiddleware:\n  trace: false\n")
+	defer os.Remove(path)
+	cfg, err := loadConfigFile(path)
+	require.NoError(t, err)
+
+	defer func(trace bool) {
+		*providerMiddleware.Trace = trace
+		changedFlags = make(map[string]bool)
+	}(*providerMiddleware.Trace)
+	*providerMiddleware.Trace = true
+
+	applyConfigFile(cfg)
+	assert.False(t, *providerMiddleware.Trace)
+
+	*providerMiddleware.Trace = true
+	changedFlags = map[string]bool{"provider-trace": true}
+	applyConfigFile(cfg)
+	assert.True(t, *providerMiddleware.Trace)
+}
+
+// TestResolveReloadableConfigNodes verifies that the nodes of the configuration file have their own taints and labels.
+func TestResolveReloadableConfigNodes(t *testing.T) {
+	path := writeConfigFile(t, `
+apiVersion: virtual-kubelet.io/v1alpha1
+kind: VirtualKubeletConfiguration
+nodes:
+- name: vk-0
+  provider: azure
+  taints: []
+  labels:
+    example.com/region: westeurope
+- name: vk-1
+  provider: aws
+`)
+	defer os.Remove(path)
+	cfg, err := loadConfigFile(path)
+	require.NoError(t, err)
+
+	rc, err := resolveReloadableConfig(cfg)
+	require.NoError(t, err)
+	md, ok := rc.metadata("vk-0")
+	require.True(t, ok)
+	assert.Equal(t, nodeMetadata{Taints: []corev1.Taint{}, Labels: map[string]string{"example.com/region": "westeurope"}}, md)
+	md, ok = rc.metadata("vk-1")
+	require.True(t, ok)
+	assert.Equal(t, []corev1.Taint{{Key: DefaultTaintKey, Value: "aws", Effect: DefaultTaintEffect}}, md.Taints)
+	_, ok = rc.metadata("vk-2")
+	assert.False(t, ok)
+}
+
+func TestRestartRequired(t *testing.T) {
+	old := &kubeletConfigFile{NodeName: "vk-0", LogLevel: "info", PodSyncWorkers: 4}
+	cfg := &kubeletConfigFile{NodeName: "vk-1", LogLevel: "debug", PodSyncWorkers: 8, Tracing: tracingConfig{SampleRate: "never"}}
+	assert.Equal(t, []string{"nodeName", "tracing"}, restartRequired(old, cfg))
+
+	// The taints and labels of the nodes are reloaded, but not their providers.
+	old = &kubeletConfigFile{Nodes: []nodeConfig{{Name: "vk-0", Provider: "mock"}}}
+	cfg = &kubeletConfigFile{Nodes: []nodeConfig{{Name: "vk-0", Provider: "mock", Labels: map[string]string{"tier": "batch"}}}}
+	assert.Empty(t, restartRequired(old, cfg))
+	cfg.Nodes = append(cfg.Nodes, nodeConfig{Name: "vk-1", Provider: "mock"})
+	assert.Equal(t, []string{"nodes"}, restartRequired(old, cfg))
+}
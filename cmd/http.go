@@ -3,17 +3,18 @@ package cmd
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
-	"os"
-	"strconv"
+	"time"
 
-	"github.com/cpuguy83/strongerrors"
 	"github.com/pkg/errors"
 	"github.com/virtual-kubelet/virtual-kubelet/log"
 	"github.com/virtual-kubelet/virtual-kubelet/vkubelet"
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
 )
 
 // AcceptedCiphers is the list of accepted TLS ciphers, with known weak ciphers elided
@@ -30,32 +31,73 @@ var AcceptedCiphers = []uint16{
 	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
 }
 
-func loadTLSConfig(certPath, keyPath string) (*tls.Config, error) {
-	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
-	if err != nil {
-		return nil, errors.Wrap(err, "error loading tls certs")
-	}
+const (
+	// authorizationModeAlwaysAllow authorizes all the authenticated requests to the kubelet API.
+	authorizationModeAlwaysAllow = "AlwaysAllow"
+	// authorizationModeWebhook authorizes requests to the kubelet API using SubjectAccessReviews.
+	authorizationModeWebhook = "Webhook"
+)
+
+// apiAuthConfig holds the flags configuring the authentication and authorization of requests to the kubelet API.
+// The defaults are the same as the Kubelet's.
+type apiAuthConfig struct {
+	ClientCAFile         string
+	Anonymous            bool
+	TokenWebhook         bool
+	TokenWebhookCacheTTL time.Duration
+	AuthorizationMode    string
+	AuthorizedCacheTTL   time.Duration
+	UnauthorizedCacheTTL time.Duration
+}
 
-	return &tls.Config{
-		Certificates:             []tls.Certificate{cert},
+func loadTLSConfig(cfg *apiServerConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
 		MinVersion:               tls.VersionTLS12,
 		PreferServerCipherSuites: true,
 		CipherSuites:             AcceptedCiphers,
-	}, nil
+	}
+
+	if cfg.CertificateManager != nil {
+		// The certificate is looked up on every handshake, so that rotated certificates are served without a restart.
+		tlsCfg.GetCertificate = cfg.CertificateManager.GetCertificate
+	} else {
+		cert, err := tls.LoadX509KeyPair(cfg.CertPath, cfg.KeyPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "error loading tls certs")
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if cfg.ClientCAPath != "" {
+		pem, err := ioutil.ReadFile(cfg.ClientCAPath)
+		if err != nil {
+			return nil, errors.Wrap(err, "error reading client ca file")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificates found in client ca file %s", cfg.ClientCAPath)
+		}
+		// Client certificates are optional, as requests may also be authenticated with bearer tokens.
+		// They must be signed by the client CA when presented, though.
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return tlsCfg, nil
 }
 
-func setupHTTPServer(ctx context.Context, cfg *apiServerConfig) (io.Closer, io.Closer, error) {
+func setupHTTPServer(ctx context.Context, cfg *apiServerConfig, nodes []*virtualNode) (io.Closer, io.Closer, error) {
 	var (
 		podS     *http.Server
 		metricsS *http.Server
 	)
-	if cfg.CertPath == "" || cfg.KeyPath == "" {
+	if cfg.CertificateManager == nil && (cfg.CertPath == "" || cfg.KeyPath == "") {
 		log.G(ctx).
 			WithField("certPath", cfg.CertPath).
 			WithField("keyPath", cfg.KeyPath).
 			Error("TLS certificates not provided, not setting up pod http server")
 	} else {
-		tlsCfg, err := loadTLSConfig(cfg.CertPath, cfg.KeyPath)
+		tlsCfg, err := loadTLSConfig(cfg)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -64,11 +106,8 @@ func setupHTTPServer(ctx context.Context, cfg *apiServerConfig) (io.Closer, io.C
 			return nil, nil, errors.Wrap(err, "error setting up listener for pod http server")
 		}
 
-		mux := http.NewServeMux()
-		vkubelet.AttachPodRoutes(p, mux)
-
 		podS = &http.Server{
-			Handler:   mux,
+			Handler:   podHandler(cfg, nodes),
 			TLSConfig: tlsCfg,
 		}
 		go serveHTTP(ctx, podS, l, "pods")
@@ -85,10 +124,8 @@ func setupHTTPServer(ctx context.Context, cfg *apiServerConfig) (io.Closer, io.C
 			return nil, nil, errors.Wrap(err, "could not setup listenr for pod metrics http server")
 		}
 
-		mux := http.NewServeMux()
-		vkubelet.AttachMetricsRoutes(p, mux)
 		metricsS = &http.Server{
-			Handler: mux,
+			Handler: metricsHandler(cfg, nodes),
 		}
 		go serveHTTP(ctx, metricsS, l, "pod metrics")
 	}
@@ -96,6 +133,48 @@ func setupHTTPServer(ctx context.Context, cfg *apiServerConfig) (io.Closer, io.C
 	return podS, metricsS, nil
 }
 
+// podHandler creates the handler of the kubelet API of the specified nodes.
+// When there are several nodes, requests are routed to the node of the pod they are for, or to the node under
+// /nodes/{node}/, and authorized against that node. The other requests, such as /healthz, are authorized against all
+// the nodes.
+func podHandler(cfg *apiServerConfig, nodes []*virtualNode) http.Handler {
+	handler := func(n *virtualNode) http.Handler {
+		mux := http.NewServeMux()
+		vkubelet.AttachPodRoutes(n.p, mux,
+			api.WithStreamIdleTimeout(cfg.StreamIdleTimeout),
+			api.WithStreamCreationTimeout(cfg.StreamCreationTimeout),
+		)
+		vkubelet.AttachHealthzRoutes(n.p, mux, n.healthChecks(cfg.HealthChecks)...)
+
+		auth := cfg.Auth
+		auth.NodeName = n.name
+		return vkubelet.AuthHandler(mux, auth)
+	}
+
+	fallback := http.NewServeMux()
+	fallback.Handle("/healthz", nodesHealthzHandler(nodes, cfg.HealthChecks))
+	return routeNodes(nodes, handler, vkubelet.AuthHandler(fallback, cfg.Auth))
+}
+
+// metricsHandler creates the handler of the metrics server of the specified nodes.
+// When there are several nodes, the metrics of each node are served under /nodes/{node}/.
+func metricsHandler(cfg *apiServerConfig, nodes []*virtualNode) http.Handler {
+	mux := http.NewServeMux()
+	if len(nodes) == 1 {
+		vkubelet.AttachMetricsRoutes(nodes[0].p, mux)
+		vkubelet.AttachHealthzRoutes(nodes[0].p, mux, nodes[0].healthChecks(cfg.HealthChecks)...)
+	} else {
+		mux.Handle("/", vkubelet.InstrumentHandler(routeNodes(nodes, func(n *virtualNode) http.Handler {
+			return vkubelet.MetricsSummaryHandler(n.p)
+		}, http.HandlerFunc(vkubelet.NotFound))))
+		mux.Handle("/healthz", nodesHealthzHandler(nodes, cfg.HealthChecks))
+	}
+	if cfg.MetricsHandler != nil {
+		mux.Handle("/metrics", cfg.MetricsHandler)
+	}
+	return mux
+}
+
 func serveHTTP(ctx context.Context, s *http.Server, l net.Listener, name string) {
 	if err := s.Serve(l); err != nil {
 		select {
@@ -108,24 +187,28 @@ func serveHTTP(ctx context.Context, s *http.Server, l net.Listener, name string)
 }
 
 type apiServerConfig struct {
-	CertPath    string
-	KeyPath     string
-	Addr        string
-	MetricsAddr string
+	CertPath           string
+	KeyPath            string
+	ClientCAPath       string
+	CertificateManager *vkubelet.CertificateManager
+	Auth               vkubelet.AuthConfig
+	Addr               string
+	MetricsAddr        string
+	// MetricsHandler serves the metrics of the virtual-kubelet on /metrics of the metrics address when set.
+	MetricsHandler        http.Handler
+	StreamIdleTimeout     time.Duration
+	StreamCreationTimeout time.Duration
+	// HealthChecks are the checks of the components shared by the nodes, such as the secret informer.
+	HealthChecks []api.HealthCheck
 }
 
-func getAPIConfig(metricsAddr string) (*apiServerConfig, error) {
-	config := apiServerConfig{
-		CertPath: os.Getenv("APISERVER_CERT_LOCATION"),
-		KeyPath:  os.Getenv("APISERVER_KEY_LOCATION"),
-	}
-
-	port, err := strconv.Atoi(os.Getenv("KUBELET_PORT"))
-	if err != nil {
-		return nil, strongerrors.InvalidArgument(errors.Wrap(err, "error parsing KUBELET_PORT variable"))
+func getAPIConfig(port int32, certPath, keyPath, metricsAddr string, streamIdleTimeout, streamCreationTimeout time.Duration) *apiServerConfig {
+	return &apiServerConfig{
+		CertPath:              certPath,
+		KeyPath:               keyPath,
+		Addr:                  fmt.Sprintf(":%d", port),
+		MetricsAddr:           metricsAddr,
+		StreamIdleTimeout:     streamIdleTimeout,
+		StreamCreationTimeout: streamCreationTimeout,
 	}
-	config.Addr = fmt.Sprintf(":%d", port)
-	config.MetricsAddr = metricsAddr
-
-	return &config, nil
 }
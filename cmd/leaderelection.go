@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/virtual-kubelet/virtual-kubelet/log"
+)
+
+// Default leader election values.
+// These are the same values used by other Kubernetes components, such as the kube-controller-manager.
+const (
+	DefaultLeaderElectionLeaseDuration = 15 * time.Second
+	DefaultLeaderElectionRenewDeadline = 10 * time.Second
+	DefaultLeaderElectionRetryPeriod   = 2 * time.Second
+)
+
+type leaderElectionConfig struct {
+	Namespace     string
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+}
+
+// runWithLeaderElection blocks until this instance is elected as the leader for the specified node, and then calls run.
+// All instances serving the same node name compete for the same lock, so only one of them runs at a time.
+//
+// If this instance stops being the leader, the context passed to run is cancelled and, once run has returned, an error
+// is returned so the process can exit, leaving a standby instance to take over.
+func runWithLeaderElection(ctx context.Context, client kubernetes.Interface, cfg leaderElectionConfig, nodeName string, run func(context.Context) error) error {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return errors.Wrap(err, "error getting hostname")
+	}
+	// Add a unique suffix so that instances running on the same host are distinguishable.
+	identity := hostname + "_" + uuid.New().String()
+
+	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster.StartLogging(log.L.Infof)
+	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events(cfg.Namespace)})
+	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: fmt.Sprintf("%s/leader-election", nodeName)})
+
+	lock, err := resourcelock.New(
+		resourcelock.ConfigMapsResourceLock,
+		cfg.Namespace,
+		fmt.Sprintf("virtual-kubelet-%s", nodeName),
+		client.CoreV1(),
+		resourcelock.ResourceLockConfig{
+			Identity:      identity,
+			EventRecorder: recorder,
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "error creating leader election lock")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	logger := log.G(ctx).WithField("identity", identity).WithField("lock", lock.Describe())
+
+	var (
+		mu sync.Mutex
+		// stopped is set once the leader election is over, after which run must not be called anymore.
+		stopped bool
+		// runErr receives the error returned by run, once it has been called.
+		runErr chan error
+	)
+	le, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: cfg.LeaseDuration,
+		RenewDeadline: cfg.RenewDeadline,
+		RetryPeriod:   cfg.RetryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				mu.Lock()
+				if stopped {
+					mu.Unlock()
+					return
+				}
+				errCh := make(chan error, 1)
+				runErr = errCh
+				mu.Unlock()
+
+				logger.Info("Started leading")
+				errCh <- run(ctx)
+				// Stop renewing the lease, so that a standby instance can take over.
+				cancel()
+			},
+			OnStoppedLeading: func() {
+				logger.Info("Stopped leading")
+			},
+			OnNewLeader: func(leader string) {
+				logger.WithField("leader", leader).Info("Observed new leader")
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "error setting up leader election")
+	}
+
+	logger.Info("Waiting to be elected as the leader")
+	// Run blocks until leadership is lost, run returns, or the context is cancelled.
+	le.Run(ctx)
+	// The lease is only lost when neither run returned nor the context was cancelled.
+	lost := ctx.Err() == nil
+
+	// Wait for run to return before returning, so that the caller doesn't exit while the node is still being served.
+	cancel()
+	mu.Lock()
+	stopped = true
+	errCh := runErr
+	mu.Unlock()
+	if errCh != nil {
+		err = <-errCh
+	}
+
+	if lost {
+		return errors.New("leader election lost")
+	}
+	if errCh != nil {
+		return err
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var testLeaderElectionConfig = leaderElectionConfig{
+	Namespace:     "kube-system",
+	LeaseDuration: 300 * time.Millisecond,
+	RenewDeadline: 200 * time.Millisecond,
+	RetryPeriod:   50 * time.Millisecond,
+}
+
+func TestRunWithLeaderElection(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	runErr := errors.New("run failed")
+	err := runWithLeaderElection(context.Background(), client, testLeaderElectionConfig, "vk-0", func(context.Context) error {
+		return runErr
+	})
+	assert.Equal(t, runErr, err)
+
+	// The lock is held in a config map named after the node.
+	_, err = client.CoreV1().ConfigMaps("kube-system").Get("virtual-kubelet-vk-0", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
+
+func TestRunWithLeaderElectionCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := runWithLeaderElection(ctx, fake.NewSimpleClientset(), testLeaderElectionConfig, "vk-0", func(context.Context) error {
+		t.Error("run must not be called once the context is cancelled")
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestRunWithLeaderElectionLost(t *testing.T) {
+	// Once elected, the lease can't be renewed anymore.
+	var failUpdates int32
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("update", "configmaps", func(k8stesting.Action) (bool, runtime.Object, error) {
+		if atomic.LoadInt32(&failUpdates) == 1 {
+			// Like the real client, an empty config map is returned along with the error.
+			return true, &corev1.ConfigMap{}, errors.New("unavailable")
+		}
+		return false, nil, nil
+	})
+
+	var stopped int32
+	err := runWithLeaderElection(context.Background(), client, testLeaderElectionConfig, "vk-0", func(ctx context.Context) error {
+		atomic.StoreInt32(&failUpdates, 1)
+		<-ctx.Done()
+		// The node takes a while to stop.
+		time.Sleep(50 * time.Millisecond)
+		atomic.StoreInt32(&stopped, 1)
+		return nil
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "leader election lost")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&stopped), "run must have returned")
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/prometheus/client_golang/prometheus"
+	ocprometheus "go.opencensus.io/exporter/prometheus"
+	"go.opencensus.io/stats/view"
+)
+
+// newPrometheusExporter returns an exporter serving the registered views in the Prometheus format, along with the
+// metrics of the Go runtime and of the process.
+func newPrometheusExporter(logger *logrus.Entry) (*ocprometheus.Exporter, error) {
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(prometheus.NewGoCollector()); err != nil {
+		return nil, err
+	}
+	if err := registry.Register(prometheus.NewProcessCollector(os.Getpid(), "")); err != nil {
+		return nil, err
+	}
+
+	exporter, err := ocprometheus.NewExporter(ocprometheus.Options{
+		Registry: registry,
+		OnError: func(err error) {
+			logger.WithError(err).Error("Error exporting metrics to Prometheus")
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	view.RegisterExporter(exporter)
+	return exporter, nil
+}
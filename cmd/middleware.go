@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers/middleware"
+)
+
+// providerMiddlewareConfig configures the middlewares decorating the provider, which are all disabled by default
+// except tracing.
+type providerMiddlewareConfig struct {
+	// Trace is overridden by --provider-trace. It is a pointer so that the configuration file can disable tracing.
+	Trace *bool `yaml:"trace"`
+	// CacheTTL is overridden by --provider-cache-ttl.
+	CacheTTL time.Duration `yaml:"cacheTTL"`
+	// RateLimit is overridden field by field by the --provider-rate-limit-* flags.
+	RateLimit rateLimitConfig `yaml:"rateLimit"`
+}
+
+// rateLimitConfig configures the rate limit of each method of the provider.
+type rateLimitConfig struct {
+	QPS   float64 `yaml:"qps"`
+	Burst int     `yaml:"burst"`
+}
+
+// validate checks the configuration, returning all the problems found.
+func (cfg providerMiddlewareConfig) validate() []string {
+	var errs []string
+	if cfg.CacheTTL < 0 {
+		errs = append(errs, "cacheTTL must not be negative")
+	}
+	if cfg.RateLimit.QPS < 0 {
+		errs = append(errs, "rateLimit.qps must not be negative")
+	}
+	if cfg.RateLimit.Burst < 0 {
+		errs = append(errs, "rateLimit.burst must not be negative")
+	}
+	return errs
+}
+
+// middlewares returns the middlewares enabled by the configuration, the outermost first.
+// Calls are traced even when they are served from the cache, which itself spares calls from the rate limit.
+func (cfg providerMiddlewareConfig) middlewares() ([]middleware.Middleware, error) {
+	var mws []middleware.Middleware
+	if cfg.Trace != nil && *cfg.Trace {
+		mws = append(mws, middleware.Trace())
+	}
+	if cfg.CacheTTL > 0 {
+		mws = append(mws, middleware.Cache(cfg.CacheTTL))
+	}
+	if cfg.RateLimit.QPS > 0 {
+		if cfg.RateLimit.Burst < 1 {
+			return nil, errors.New("the burst of the rate limit of the provider must be at least 1")
+		}
+		mws = append(mws, middleware.RateLimit(cfg.RateLimit.QPS, cfg.RateLimit.Burst))
+	}
+	return mws, nil
+}
@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/validation"
+	kubeinformers "k8s.io/client-go/informers"
+	corev1informers "k8s.io/client-go/informers/core/v1"
+
+	"github.com/virtual-kubelet/virtual-kubelet/log"
+	"github.com/virtual-kubelet/virtual-kubelet/manager"
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+	"github.com/virtual-kubelet/virtual-kubelet/providers/middleware"
+	"github.com/virtual-kubelet/virtual-kubelet/providers/register"
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet"
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
+)
+
+// nodeConfig is a virtual node in the configuration file, for a process serving several nodes.
+type nodeConfig struct {
+	Name string `yaml:"name"`
+	// Provider is the name of the provider of the node, and ProviderConfig its configuration file.
+	Provider       string `yaml:"provider"`
+	ProviderConfig string `yaml:"providerConfig"`
+	// Taints replace the default taint of the node when set, even when empty.
+	Taints []taintConfig `yaml:"taints"`
+	// Labels are set on the node, in addition to its default labels.
+	Labels map[string]string `yaml:"labels"`
+}
+
+// validate checks the node, returning all the problems found.
+func (n nodeConfig) validate() []string {
+	var errs []string
+	if n.Name == "" {
+		errs = append(errs, "name is required")
+	} else {
+		for _, msg := range validation.IsDNS1123Subdomain(n.Name) {
+			errs = append(errs, "name: "+msg)
+		}
+	}
+	if n.Provider == "" {
+		errs = append(errs, "provider is required")
+	}
+	errs = append(errs, validateTaints(n.Taints)...)
+	errs = append(errs, validateLabels(n.Labels)...)
+	return errs
+}
+
+// virtualNode is a virtual node served by the process.
+// The nodes served by the same process share the Kubernetes client, the secret and config map informers, and the
+// listeners of the kubelet API and metrics servers. Each of them has its own pod informer and provider.
+type virtualNode struct {
+	name     string
+	provider string
+	logger   *logrus.Entry
+
+	p           providers.Provider
+	rm          *manager.ResourceManager
+	podInformer corev1informers.PodInformer
+	vk          *vkubelet.Server
+}
+
+// newVirtualNode starts the pod informer of the specified node and initializes its provider, decorated with the
+// specified middlewares.
+func newVirtualNode(ctx context.Context, cfg nodeConfig, secretInformer corev1informers.SecretInformer, configMapInformer corev1informers.ConfigMapInformer, mws []middleware.Middleware) (*virtualNode, error) {
+	n := &virtualNode{
+		name:     cfg.Name,
+		provider: cfg.Provider,
+		logger: log.L.WithFields(logrus.Fields{
+			"provider": cfg.Provider,
+			"node":     cfg.Name,
+		}),
+	}
+
+	// Create a shared informer factory for Kubernetes pods in the current namespace (if specified) and scheduled to the node.
+	podInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(k8sClient, kubeSharedInformerFactoryResync, kubeinformers.WithNamespace(kubeNamespace), kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
+		options.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", cfg.Name).String()
+	}))
+	// Create a pod informer so we can pass its lister to the resource manager.
+	n.podInformer = podInformerFactory.Core().V1().Pods()
+
+	// Create a new instance of the resource manager that uses the listers above for pods, secrets and config maps.
+	var err error
+	n.rm, err = manager.NewResourceManager(n.podInformer.Lister(), secretInformer.Lister(), configMapInformer.Lister())
+	if err != nil {
+		return nil, errors.Wrap(err, "error initializing resource manager")
+	}
+
+	// Start the shared informer factory for pods.
+	go podInformerFactory.Start(ctx.Done())
+
+	// All the nodes advertise the port of the shared kubelet API listener, which routes the requests to them.
+	initConfig := register.InitConfig{
+		ConfigPath:      cfg.ProviderConfig,
+		NodeName:        cfg.Name,
+		OperatingSystem: operatingSystem,
+		ResourceManager: n.rm,
+		DaemonPort:      kubeletPort,
+		InternalIP:      os.Getenv("VKUBELET_POD_IP"),
+	}
+	p, err := register.GetProvider(cfg.Provider, initConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "error initializing provider")
+	}
+	n.p = middleware.Chain(p, mws...)
+	return n, nil
+}
+
+// healthChecks returns the health checks of the node, along with the specified checks of the shared components.
+func (n *virtualNode) healthChecks(checks []api.HealthCheck) []api.HealthCheck {
+	return append([]api.HealthCheck{
+		api.InformerSyncHealthCheck("pod-informer", n.podInformer.Informer().HasSynced),
+	}, checks...)
+}
+
+// nodesHealthzHandler creates an http handler serving the health of all the specified nodes, whose checks are named
+// after them, along with the specified checks of the shared components.
+func nodesHealthzHandler(nodes []*virtualNode, checks []api.HealthCheck) http.Handler {
+	var all []api.HealthCheck
+	for _, n := range nodes {
+		for _, c := range append([]api.HealthCheck{api.ProviderHealthCheck(n.p)}, n.healthChecks(nil)...) {
+			c.Name = fmt.Sprintf("%s/%s", c.Name, n.name)
+			all = append(all, c)
+		}
+	}
+	return vkubelet.InstrumentHandler(api.HealthzHandlerFunc(append(all, checks...)...))
+}
+
+// routeNodes creates the handler routing requests to the handler of each of the specified nodes, returned by
+// handler, passing the other requests to fallback. The handler of the node is returned when there is only one.
+func routeNodes(nodes []*virtualNode, handler func(*virtualNode) http.Handler, fallback http.Handler) http.Handler {
+	if len(nodes) == 1 {
+		return handler(nodes[0])
+	}
+	routed := make([]vkubelet.RoutedNode, 0, len(nodes))
+	for _, n := range nodes {
+		routed = append(routed, vkubelet.RoutedNode{
+			Name:    n.name,
+			Handler: handler(n),
+			Pods:    n.podInformer.Lister(),
+		})
+	}
+	return vkubelet.NodeRouter(routed, fallback)
+}
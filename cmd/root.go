@@ -29,24 +29,23 @@ import (
 	"github.com/mitchellh/go-homedir"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
+	"go.opencensus.io/stats/view"
 	"go.opencensus.io/trace"
+	"golang.org/x/sync/errgroup"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/util/sets"
 	kubeinformers "k8s.io/client-go/informers"
-	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/virtual-kubelet/virtual-kubelet/log"
-	"github.com/virtual-kubelet/virtual-kubelet/manager"
 	"github.com/virtual-kubelet/virtual-kubelet/providers"
-	"github.com/virtual-kubelet/virtual-kubelet/providers/register"
 	"github.com/virtual-kubelet/virtual-kubelet/vkubelet"
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
 )
 
 const (
-	defaultDaemonPort = "10250"
+	defaultDaemonPort = 10250
 	// kubeSharedInformerFactoryDefaultResync is the default resync period used by the shared informer factories for Kubernetes resources.
 	// It is set to the same value used by the Kubelet, and can be overridden via the "--full-resync-period" flag.
 	// https://github.com/kubernetes/kubernetes/blob/v1.12.2/pkg/kubelet/apis/config/v1beta1/defaults.go#L51
@@ -54,6 +53,8 @@ const (
 )
 
 var kubeletConfig string
+var loadedConfigFile *kubeletConfigFile
+var reloadable reloadableConfig
 var kubeConfig string
 var kubeNamespace string
 var nodeName string
@@ -64,19 +65,34 @@ var taintKey string
 var disableTaint bool
 var logLevel string
 var metricsAddr string
-var taint *corev1.Taint
+var kubeletPort int32 = defaultDaemonPort
+var certPath string
+var keyPath string
 var k8sClient *kubernetes.Clientset
-var p providers.Provider
-var rm *manager.ResourceManager
+var virtualNodes []*virtualNode
 var apiConfig *apiServerConfig
-var podInformer corev1informers.PodInformer
 var kubeSharedInformerFactoryResync time.Duration
 var podSyncWorkers int
+var enableNodeLease bool
+var nodeLeaseDurationSeconds int32
+var leaderElect bool
+var orphanedPodsReconcileInterval time.Duration
+var orphanedPodsDryRun bool
+var probeContainers bool
+var disableCapacityAdmission bool
+var streamIdleTimeout time.Duration
+var streamCreationTimeout time.Duration
+var leaderElection = leaderElectionConfig{}
+var apiAuth = apiAuthConfig{}
+var rotateServerCertificates bool
+var certDir string
 
 var userTraceExporters []string
 var userTraceConfig = TracingExporterOptions{Tags: make(map[string]string)}
 var traceSampler string
 
+var providerMiddleware = providerMiddlewareConfig{Trace: new(bool)}
+
 // Create a root context to be used by the pod controller and by the shared informer factories.
 var rootContext, rootContextCancel = context.WithCancel(context.Background())
 
@@ -90,25 +106,49 @@ This allows users to schedule kubernetes workloads on nodes that aren't running
 	Run: func(cmd *cobra.Command, args []string) {
 		defer rootContextCancel()
 
-		vk := vkubelet.New(vkubelet.Config{
-			Client:          k8sClient,
-			Namespace:       kubeNamespace,
-			NodeName:        nodeName,
-			Taint:           taint,
-			Provider:        p,
-			ResourceManager: rm,
-			PodSyncWorkers:  podSyncWorkers,
-			PodInformer:     podInformer,
-		})
+		for _, n := range virtualNodes {
+			md, _ := reloadable.metadata(n.name)
+			vkCfg := vkubelet.Config{
+				Client:          k8sClient,
+				Namespace:       kubeNamespace,
+				NodeName:        n.name,
+				Taints:          md.Taints,
+				Labels:          md.Labels,
+				Provider:        n.p,
+				ResourceManager: n.rm,
+				PodSyncWorkers:  reloadable.PodSyncWorkers,
+				PodInformer:     n.podInformer,
+
+				OrphanedPodsReconcileInterval: orphanedPodsReconcileInterval,
+				OrphanedPodsDryRun:            orphanedPodsDryRun,
+				ProbeContainers:               probeContainers,
+				DisableCapacityAdmission:      disableCapacityAdmission,
+			}
+			if enableNodeLease {
+				vkCfg.NodeLeaseDurationSeconds = nodeLeaseDurationSeconds
+			}
+			n.vk = vkubelet.New(vkCfg)
+		}
 
 		sig := make(chan os.Signal, 1)
-		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 		go func() {
-			<-sig
-			rootContextCancel()
+			for s := range sig {
+				if s != syscall.SIGHUP {
+					rootContextCancel()
+					return
+				}
+				if err := reloadConfigFile(rootContext, virtualNodes); err != nil {
+					log.G(rootContext).WithError(err).Error("Error reloading configuration file")
+				}
+			}
 		}()
 
-		c1, c2, err := setupHTTPServer(rootContext, apiConfig)
+		if apiConfig.CertificateManager != nil {
+			go apiConfig.CertificateManager.Run(rootContext)
+		}
+
+		c1, c2, err := setupHTTPServer(rootContext, apiConfig, virtualNodes)
 		if err != nil {
 			log.G(rootContext).Fatal(err)
 		}
@@ -116,7 +156,19 @@ This allows users to schedule kubernetes workloads on nodes that aren't running
 		defer c1.Close()
 		defer c2.Close()
 
-		if err := vk.Run(rootContext); err != nil && errors.Cause(err) != context.Canceled {
+		// The nodes are stopped as soon as one of them fails.
+		g, ctx := errgroup.WithContext(rootContext)
+		for _, n := range virtualNodes {
+			n := n
+			ctx := log.WithLogger(ctx, n.logger)
+			g.Go(func() error {
+				if leaderElect {
+					return runWithLeaderElection(ctx, k8sClient, leaderElection, n.name, n.vk.Run)
+				}
+				return n.vk.Run(ctx)
+			})
+		}
+		if err := g.Wait(); err != nil && errors.Cause(err) != context.Canceled {
 			log.G(rootContext).Fatal(err)
 		}
 	},
@@ -174,7 +226,7 @@ func init() {
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
-	//RootCmd.PersistentFlags().StringVar(&kubeletConfig, "config", "", "config file (default is $HOME/.virtual-kubelet.yaml)")
+	RootCmd.PersistentFlags().StringVar(&kubeletConfig, "config", "", "virtual-kubelet configuration file, whose settings are overridden by flags and environment variables; the log level, taints, labels and pod sync workers are reloaded on SIGHUP")
 	RootCmd.PersistentFlags().StringVar(&kubeConfig, "kubeconfig", "", "config file (default is $HOME/.kube/config)")
 	RootCmd.PersistentFlags().StringVar(&kubeNamespace, "namespace", "", "kubernetes namespace (default is 'all')")
 	RootCmd.PersistentFlags().StringVar(&nodeName, "nodename", defaultNodeName, "kubernetes node name")
@@ -194,6 +246,40 @@ func init() {
 	RootCmd.PersistentFlags().Var(mapVar(userTraceConfig.Tags), "trace-tag", "add tags to include with traces in key=value form")
 	RootCmd.PersistentFlags().StringVar(&traceSampler, "trace-sample-rate", "", "set probability of tracing samples")
 
+	RootCmd.PersistentFlags().DurationVar(&streamIdleTimeout, "streaming-connection-idle-timeout", api.DefaultStreamIdleTimeout, "maximum time a streaming connection (e.g. exec) can be idle before the connection is automatically closed")
+	RootCmd.PersistentFlags().DurationVar(&streamCreationTimeout, "stream-creation-timeout", api.DefaultStreamCreationTimeout, "maximum time to wait for the client to create the streams of a streaming connection (e.g. exec)")
+
+	RootCmd.PersistentFlags().StringVar(&apiAuth.ClientCAFile, "client-ca-file", "", "authenticate requests to the kubelet API presenting a client certificate signed by one of the authorities in this file")
+	RootCmd.PersistentFlags().BoolVar(&apiAuth.Anonymous, "anonymous-auth", true, "serve requests to the kubelet API which are not rejected by another authentication method as the system:anonymous user")
+	RootCmd.PersistentFlags().BoolVar(&apiAuth.TokenWebhook, "authentication-token-webhook", false, "authenticate bearer tokens of requests to the kubelet API using TokenReviews")
+	RootCmd.PersistentFlags().DurationVar(&apiAuth.TokenWebhookCacheTTL, "authentication-token-webhook-cache-ttl", 2*time.Minute, "duration to cache the results of TokenReviews")
+	RootCmd.PersistentFlags().StringVar(&apiAuth.AuthorizationMode, "authorization-mode", authorizationModeAlwaysAllow, fmt.Sprintf("authorization mode of requests to the kubelet API, either %s or %s (using SubjectAccessReviews)", authorizationModeAlwaysAllow, authorizationModeWebhook))
+	RootCmd.PersistentFlags().DurationVar(&apiAuth.AuthorizedCacheTTL, "authorization-webhook-cache-authorized-ttl", 5*time.Minute, "duration to cache the allowed results of SubjectAccessReviews")
+	RootCmd.PersistentFlags().DurationVar(&apiAuth.UnauthorizedCacheTTL, "authorization-webhook-cache-unauthorized-ttl", 30*time.Second, "duration to cache the denied results of SubjectAccessReviews")
+
+	RootCmd.PersistentFlags().BoolVar(&rotateServerCertificates, "rotate-server-certificates", false, "request the serving certificate of the kubelet API with a certificates.k8s.io CertificateSigningRequest, and rotate it before it expires; the requests must be approved by an administrator or an external controller")
+	RootCmd.PersistentFlags().StringVar(&certDir, "cert-dir", "", "directory the serving certificate is persisted to when --rotate-server-certificates is set (default is to keep it in memory only)")
+
+	RootCmd.PersistentFlags().BoolVar(&enableNodeLease, "enable-node-lease", false, "use a coordination.k8s.io/v1beta1 lease as the node heartbeat, only updating the node status when it changes (requires the NodeLease feature gate, and Kubernetes v1.21 or earlier)")
+	RootCmd.PersistentFlags().Int32Var(&nodeLeaseDurationSeconds, "node-lease-duration-seconds", vkubelet.DefaultNodeLeaseDurationSeconds, "the duration in seconds of the node lease, which is renewed every quarter of this duration")
+
+	RootCmd.PersistentFlags().BoolVar(&leaderElect, "leader-elect", false, "run the pod controller and node status updates only when elected as the leader among the instances with the same node name")
+	RootCmd.PersistentFlags().StringVar(&leaderElection.Namespace, "leader-elect-namespace", metav1.NamespaceSystem, "the namespace of the leader election lock")
+	RootCmd.PersistentFlags().DurationVar(&leaderElection.LeaseDuration, "leader-elect-lease-duration", DefaultLeaderElectionLeaseDuration, "the duration that non-leader candidates will wait before attempting to acquire leadership")
+	RootCmd.PersistentFlags().DurationVar(&leaderElection.RenewDeadline, "leader-elect-renew-deadline", DefaultLeaderElectionRenewDeadline, "the duration that the leader will retry refreshing leadership before giving it up")
+	RootCmd.PersistentFlags().DurationVar(&leaderElection.RetryPeriod, "leader-elect-retry-period", DefaultLeaderElectionRetryPeriod, "the duration candidates should wait between attempts to acquire or renew leadership")
+
+	RootCmd.PersistentFlags().DurationVar(&orphanedPodsReconcileInterval, "orphaned-pods-reconcile-interval", 5*time.Minute, "how often to look for pods which exist in the provider but not in kubernetes and delete them (0 to only do so at startup)")
+	RootCmd.PersistentFlags().BoolVar(&orphanedPodsDryRun, "orphaned-pods-dry-run", false, "only report pods which exist in the provider but not in kubernetes, through logs, events and metrics, instead of deleting them")
+
+	RootCmd.PersistentFlags().BoolVar(&probeContainers, "probe-containers", false, "run the readiness and liveness probes of containers from the virtual-kubelet, for providers which don't run them (requires pod IPs to be reachable)")
+	RootCmd.PersistentFlags().BoolVar(&disableCapacityAdmission, "disable-capacity-admission", false, "create pods whose resource requests don't fit in the capacity of the provider instead of rejecting them")
+
+	RootCmd.PersistentFlags().BoolVar(providerMiddleware.Trace, "provider-trace", true, "start a trace span for every call to the provider")
+	RootCmd.PersistentFlags().DurationVar(&providerMiddleware.CacheTTL, "provider-cache-ttl", 0, "how long to cache the pods and pod statuses returned by the provider (0 to disable caching)")
+	RootCmd.PersistentFlags().Float64Var(&providerMiddleware.RateLimit.QPS, "provider-rate-limit-qps", 0, "the maximum rate of calls per second to each pod method of the provider (0 to disable rate limiting)")
+	RootCmd.PersistentFlags().IntVar(&providerMiddleware.RateLimit.Burst, "provider-rate-limit-burst", 10, "the number of calls to each pod method of the provider allowed in bursts above the rate limit")
+
 	RootCmd.PersistentFlags().DurationVar(&kubeSharedInformerFactoryResync, "full-resync-period", kubeSharedInformerFactoryDefaultResync, "how often to perform a full resync of pods between kubernetes and the provider")
 
 	// Cobra also supports local flags, which will only run
@@ -203,30 +289,46 @@ func init() {
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
-	if provider == "" {
-		log.G(context.TODO()).Fatal("You must supply a cloud provider option: use --provider")
-	}
-
 	// Find home directory.
 	home, err := homedir.Dir()
 	if err != nil {
 		log.G(context.TODO()).WithError(err).Fatal("Error reading homedir")
 	}
 
+	// Settings of the configuration file are overridden by the flags and the environment variables below.
+	recordChangedFlags(RootCmd.PersistentFlags())
 	if kubeletConfig != "" {
-		// Use config file from the flag.
-		viper.SetConfigFile(kubeletConfig)
-	} else {
-		// Search config in home directory with name ".virtual-kubelet" (without extension).
-		viper.AddConfigPath(home)
-		viper.SetConfigName(".virtual-kubelet")
+		loadedConfigFile, err = loadConfigFile(kubeletConfig)
+		if err != nil {
+			log.G(context.TODO()).WithError(err).Fatal("Error loading configuration file")
+		}
+		applyConfigFile(loadedConfigFile)
 	}
 
-	viper.AutomaticEnv() // read in environment variables that match
-
-	// If a config file is found, read it in.
-	if err := viper.ReadInConfig(); err == nil {
-		log.G(context.TODO()).Debugf("Using config file %s", viper.ConfigFileUsed())
+	// A single node is configured with the flags, unless the configuration file lists the nodes to serve.
+	var nodeConfigs []nodeConfig
+	if loadedConfigFile != nil {
+		nodeConfigs = loadedConfigFile.Nodes
+	}
+	if len(nodeConfigs) > 0 {
+		if flagChanged("nodename") || flagChanged("provider") || flagChanged("provider-config") {
+			log.G(context.TODO()).Fatal("The --nodename, --provider and --provider-config options can't be used along with the nodes of the configuration file")
+		}
+	} else {
+		if provider == "" {
+			log.G(context.TODO()).Fatal("You must supply a cloud provider option: use --provider")
+		}
+		nodeConfigs = []nodeConfig{{Name: nodeName, Provider: provider, ProviderConfig: providerConfig}}
+	}
+	if port, ok := os.LookupEnv("KUBELET_PORT"); ok {
+		parsed, err := strconv.ParseInt(port, 10, 32)
+		if err != nil {
+			log.G(context.TODO()).WithError(err).WithField("value", port).Fatal("Invalid value from KUBELET_PORT in environment")
+		}
+		kubeletPort = int32(parsed)
+	}
+	if envSet("APISERVER_CERT_LOCATION", "APISERVER_KEY_LOCATION") {
+		certPath, keyPath = os.Getenv("APISERVER_CERT_LOCATION"), os.Getenv("APISERVER_KEY_LOCATION")
 	}
 
 	if kubeConfig == "" {
@@ -244,84 +346,96 @@ func initConfig() {
 		log.G(context.TODO()).WithField("OperatingSystem", operatingSystem).Fatalf("Operating system not supported. Valid options are: %s", strings.Join(providers.ValidOperatingSystems.Names(), " | "))
 	}
 
-	level, err := log.ParseLevel(logLevel)
+	reloadable, err = resolveReloadableConfig(loadedConfigFile)
 	if err != nil {
-		log.G(context.TODO()).WithField("logLevel", logLevel).Fatal("log level is not supported")
+		log.G(context.TODO()).WithError(err).Fatal("Invalid configuration")
 	}
 
-	logrus.SetLevel(level)
+	logrus.SetLevel(reloadable.LogLevel)
 
-	logger := log.L.WithFields(logrus.Fields{
-		"provider":        provider,
+	fields := logrus.Fields{
 		"operatingSystem": operatingSystem,
-		"node":            nodeName,
 		"namespace":       kubeNamespace,
-	})
-	log.L = logger
-
-	if !disableTaint {
-		taint, err = getTaint(taintKey, provider)
-		if err != nil {
-			logger.WithError(err).Fatal("Error setting up desired kubernetes node taint")
-		}
 	}
+	if len(nodeConfigs) == 1 {
+		fields["provider"] = nodeConfigs[0].Provider
+		fields["node"] = nodeConfigs[0].Name
+	}
+	logger := log.L.WithFields(fields)
+	log.L = logger
 
 	k8sClient, err = newClient(kubeConfig)
 	if err != nil {
 		logger.WithError(err).Fatal("Error creating kubernetes client")
 	}
 
-	// Create a shared informer factory for Kubernetes pods in the current namespace (if specified) and scheduled to the current node.
-	podInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(k8sClient, kubeSharedInformerFactoryResync, kubeinformers.WithNamespace(kubeNamespace), kubeinformers.WithTweakListOptions(func(options *metav1.ListOptions) {
-		options.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", nodeName).String()
-	}))
-	// Create a pod informer so we can pass its lister to the resource manager.
-	podInformer = podInformerFactory.Core().V1().Pods()
-
-	// Create another shared informer factory for Kubernetes secrets and configmaps (not subject to any selectors).
+	// Create a shared informer factory for Kubernetes secrets and configmaps (not subject to any selectors), which is
+	// shared by all the nodes.
 	scmInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(k8sClient, kubeSharedInformerFactoryResync)
-	// Create a secret informer and a config map informer so we can pass their listers to the resource manager.
+	// Create a secret informer and a config map informer so we can pass their listers to the resource managers.
 	secretInformer := scmInformerFactory.Core().V1().Secrets()
 	configMapInformer := scmInformerFactory.Core().V1().ConfigMaps()
 
-	// Create a new instance of the resource manager that uses the listers above for pods, secrets and config maps.
-	rm, err = manager.NewResourceManager(podInformer.Lister(), secretInformer.Lister(), configMapInformer.Lister())
+	mws, err := providerMiddleware.middlewares()
 	if err != nil {
-		logger.WithError(err).Fatal("Error initializing resource manager")
+		logger.WithError(err).Fatal("Error initializing provider middlewares")
+	}
+	for _, cfg := range nodeConfigs {
+		n, err := newVirtualNode(rootContext, cfg, secretInformer, configMapInformer, mws)
+		if err != nil {
+			logger.WithError(err).WithField("node", cfg.Name).Fatal("Error initializing node")
+		}
+		virtualNodes = append(virtualNodes, n)
 	}
 
-	// Start the shared informer factory for pods.
-	go podInformerFactory.Start(rootContext.Done())
 	// Start the shared informer factory for secrets and configmaps.
 	go scmInformerFactory.Start(rootContext.Done())
 
-	daemonPortEnv := getEnv("KUBELET_PORT", defaultDaemonPort)
-	daemonPort, err := strconv.ParseInt(daemonPortEnv, 10, 32)
-	if err != nil {
-		logger.WithError(err).WithField("value", daemonPortEnv).Fatal("Invalid value from KUBELET_PORT in environment")
+	apiConfig = getAPIConfig(kubeletPort, certPath, keyPath, metricsAddr, streamIdleTimeout, streamCreationTimeout)
+	apiConfig.ClientCAPath = apiAuth.ClientCAFile
+	if rotateServerCertificates {
+		// The serving certificate of a node is only valid for that node.
+		if len(virtualNodes) > 1 {
+			logger.Fatal("Rotating the serving certificate is not supported when serving several nodes")
+		}
+		apiConfig.CertificateManager, err = vkubelet.NewCertificateManager(vkubelet.CertificateManagerConfig{
+			Client:   k8sClient.CertificatesV1beta1().CertificateSigningRequests(),
+			NodeName: virtualNodes[0].name,
+			Provider: virtualNodes[0].p,
+			Dir:      certDir,
+		})
+		if err != nil {
+			logger.WithError(err).Fatal("Error initializing certificate manager")
+		}
 	}
-
-	initConfig := register.InitConfig{
-		ConfigPath:      providerConfig,
-		NodeName:        nodeName,
-		OperatingSystem: operatingSystem,
-		ResourceManager: rm,
-		DaemonPort:      int32(daemonPort),
-		InternalIP:      os.Getenv("VKUBELET_POD_IP"),
+	// Requests are authorized against the node they are for.
+	apiConfig.Auth = vkubelet.AuthConfig{
+		Anonymous:            apiAuth.Anonymous,
+		TokenReviewCacheTTL:  apiAuth.TokenWebhookCacheTTL,
+		AuthorizedCacheTTL:   apiAuth.AuthorizedCacheTTL,
+		UnauthorizedCacheTTL: apiAuth.UnauthorizedCacheTTL,
 	}
-
-	p, err = register.GetProvider(provider, initConfig)
-	if err != nil {
-		logger.WithError(err).Fatal("Error initializing provider")
+	if apiAuth.TokenWebhook {
+		apiConfig.Auth.TokenReviews = k8sClient.AuthenticationV1().TokenReviews()
+	}
+	switch apiAuth.AuthorizationMode {
+	case authorizationModeAlwaysAllow:
+	case authorizationModeWebhook:
+		apiConfig.Auth.SubjectAccessReviews = k8sClient.AuthorizationV1().SubjectAccessReviews()
+	default:
+		logger.WithField("authorizationMode", apiAuth.AuthorizationMode).Fatalf("Authorization mode not supported. Valid options are: %s | %s", authorizationModeAlwaysAllow, authorizationModeWebhook)
+	}
+	apiConfig.HealthChecks = []api.HealthCheck{
+		api.InformerSyncHealthCheck("secret-informer", secretInformer.Informer().HasSynced),
+		api.InformerSyncHealthCheck("configmap-informer", configMapInformer.Informer().HasSynced),
 	}
 
-	apiConfig, err = getAPIConfig(metricsAddr)
-	if err != nil {
-		logger.WithError(err).Fatal("Error reading API config")
+	if enableNodeLease && nodeLeaseDurationSeconds <= 0 {
+		logger.Fatal("The node lease duration should be greater than zero")
 	}
 
-	if podSyncWorkers <= 0 {
-		logger.Fatal("The number of pod synchronization workers should not be negative")
+	if orphanedPodsReconcileInterval < 0 {
+		logger.Fatal("The orphaned pods reconciliation interval should not be negative")
 	}
 
 	for k := range userTraceConfig.Tags {
@@ -330,8 +444,13 @@ func initConfig() {
 		}
 	}
 	userTraceConfig.Tags["operatingSystem"] = operatingSystem
-	userTraceConfig.Tags["provider"] = provider
-	userTraceConfig.Tags["nodeName"] = nodeName
+	names, providerNames := sets.NewString(), sets.NewString()
+	for _, n := range virtualNodes {
+		names.Insert(n.name)
+		providerNames.Insert(n.provider)
+	}
+	userTraceConfig.Tags["provider"] = strings.Join(providerNames.List(), ",")
+	userTraceConfig.Tags["nodeName"] = strings.Join(names.List(), ",")
 	for _, e := range userTraceExporters {
 		if e == "zpages" {
 			go setupZpages()
@@ -342,26 +461,28 @@ func initConfig() {
 			log.L.WithError(err).WithField("exporter", e).Fatal("Cannot initialize exporter")
 		}
 		trace.RegisterExporter(exporter)
+		// Exporters which also support metrics, such as the OpenCensus agent, export the views registered below.
+		if ve, ok := exporter.(view.Exporter); ok {
+			view.RegisterExporter(ve)
+		}
+	}
+	// Metrics are always served in the Prometheus format on the metrics address.
+	exporter, err := newPrometheusExporter(logger)
+	if err != nil {
+		logger.WithError(err).Fatal("Cannot initialize Prometheus exporter")
+	}
+	apiConfig.MetricsHandler = exporter
+	if err := view.Register(vkubelet.OrphanedPodsViews...); err != nil {
+		logger.WithError(err).Fatal("Cannot register metrics views")
+	}
+	if err := view.Register(vkubelet.MetricsViews...); err != nil {
+		logger.WithError(err).Fatal("Cannot register metrics views")
 	}
 	if len(userTraceExporters) > 0 {
-		var s trace.Sampler
-		switch strings.ToLower(traceSampler) {
-		case "":
-		case "always":
-			s = trace.AlwaysSample()
-		case "never":
-			s = trace.NeverSample()
-		default:
-			rate, err := strconv.Atoi(traceSampler)
-			if err != nil {
-				logger.WithError(err).WithField("rate", traceSampler).Fatal("unsupported trace sample rate, supported values: always, never, or number 0-100")
-			}
-			if rate < 0 || rate > 100 {
-				logger.WithField("rate", traceSampler).Fatal("trace sample rate must not be less than zero or greater than 100")
-			}
-			s = trace.ProbabilitySampler(float64(rate) / 100)
+		s, err := parseTraceSampler(traceSampler)
+		if err != nil {
+			logger.WithError(err).Fatal("Invalid trace sample rate")
 		}
-
 		if s != nil {
 			trace.ApplyConfig(
 				trace.Config{
@@ -0,0 +1,117 @@
+// Package fieldpath resolves the pod fields and container resources referenced by environment variables and downward
+// API volumes, in the same way as the Kubelet.
+package fieldpath
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// ExtractFieldPathAsString returns the value of the metadata field of the pod referenced by the specified path.
+// The supported fields are the same as the Kubelet's, including subscripted labels and annotations such as "metadata.labels['app']".
+// https://github.com/kubernetes/kubernetes/blob/v1.13.1/pkg/fieldpath/fieldpath.go#L44-L78
+func ExtractFieldPathAsString(pod *corev1.Pod, fieldPath string) (string, error) {
+	// Handle subscripted paths such as "metadata.labels['app']".
+	if strings.HasSuffix(fieldPath, "']") {
+		parts := strings.SplitN(strings.TrimSuffix(fieldPath, "']"), "['", 2)
+		if len(parts) == 2 && len(parts[1]) > 0 {
+			switch parts[0] {
+			case "metadata.annotations":
+				return pod.Annotations[parts[1]], nil
+			case "metadata.labels":
+				return pod.Labels[parts[1]], nil
+			}
+		}
+		return "", fmt.Errorf("fieldPath %q does not support subscript", fieldPath)
+	}
+
+	switch fieldPath {
+	case "metadata.annotations":
+		return formatMap(pod.Annotations), nil
+	case "metadata.labels":
+		return formatMap(pod.Labels), nil
+	case "metadata.name":
+		return pod.Name, nil
+	case "metadata.namespace":
+		return pod.Namespace, nil
+	case "metadata.uid":
+		return string(pod.UID), nil
+	}
+	return "", fmt.Errorf("unsupported fieldPath: %v", fieldPath)
+}
+
+// formatMap formats a map as sorted key="value" lines, in the same way as the Kubelet does.
+func formatMap(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("%v=%q", key, m[key]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ContainerResourceValue returns the value of the resource request or limit of a container referenced by the specified selector.
+// The selector's container name takes precedence over the specified container, which may be nil when the selector names one.
+// As done by the Kubelet, limits which are not set default to the passed in allocatable resources of the node.
+// https://github.com/kubernetes/kubernetes/blob/v1.13.1/pkg/kubelet/kubelet_resources.go
+func ContainerResourceValue(fs *corev1.ResourceFieldSelector, pod *corev1.Pod, container *corev1.Container, allocatable corev1.ResourceList) (string, error) {
+	if fs.ContainerName != "" && (container == nil || fs.ContainerName != container.Name) {
+		container = nil
+		for _, containers := range [][]corev1.Container{pod.Spec.Containers, pod.Spec.InitContainers} {
+			for idx := range containers {
+				if containers[idx].Name == fs.ContainerName {
+					container = &containers[idx]
+				}
+			}
+		}
+	}
+	if container == nil {
+		return "", fmt.Errorf("container %q not found in pod %s", fs.ContainerName, pod.Name)
+	}
+
+	divisor := resource.MustParse("1")
+	if !fs.Divisor.IsZero() {
+		divisor = fs.Divisor
+	}
+
+	var (
+		q     resource.Quantity
+		isCPU bool
+	)
+	switch fs.Resource {
+	case "limits.cpu", "limits.memory", "limits.ephemeral-storage":
+		name := corev1.ResourceName(strings.TrimPrefix(fs.Resource, "limits."))
+		var ok bool
+		if q, ok = container.Resources.Limits[name]; !ok {
+			q = allocatable[name]
+		}
+		isCPU = name == corev1.ResourceCPU
+	case "requests.cpu", "requests.memory", "requests.ephemeral-storage":
+		name := corev1.ResourceName(strings.TrimPrefix(fs.Resource, "requests."))
+		q = container.Resources.Requests[name]
+		isCPU = name == corev1.ResourceCPU
+	default:
+		return "", fmt.Errorf("unsupported container resource: %v", fs.Resource)
+	}
+
+	// Round up to the nearest multiple of the divisor, as done by the Kubelet.
+	// CPU is compared in millicores, so that divisors such as "1m" are supported.
+	var v float64
+	if isCPU {
+		v = math.Ceil(float64(q.MilliValue()) / float64(divisor.MilliValue()))
+	} else {
+		v = math.Ceil(float64(q.Value()) / float64(divisor.Value()))
+	}
+	return strconv.FormatInt(int64(v), 10), nil
+}
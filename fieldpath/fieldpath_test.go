@@ -0,0 +1,64 @@
+package fieldpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/virtual-kubelet/virtual-kubelet/fieldpath"
+)
+
+func TestExtractFieldPathAsString(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:   "pod-0",
+		Labels: map[string]string{"b": "2", "a": "1"},
+	}}
+
+	for path, expected := range map[string]string{
+		"metadata.name":        "pod-0",
+		"metadata.labels":      "a=\"1\"\nb=\"2\"",
+		"metadata.labels['b']": "2",
+		"metadata.labels['c']": "",
+		"metadata.annotations": "",
+	} {
+		val, err := fieldpath.ExtractFieldPathAsString(pod, path)
+		assert.NoError(t, err, path)
+		assert.Equal(t, expected, val, path)
+	}
+
+	for _, path := range []string{"metadata.name['a']", "spec.nodeName"} {
+		_, err := fieldpath.ExtractFieldPathAsString(pod, path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestContainerResourceValue(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+		Name: "app",
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m")},
+		},
+	}}}}
+	allocatable := corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")}
+
+	for _, tc := range []struct {
+		fs       corev1.ResourceFieldSelector
+		expected string
+	}{
+		{corev1.ResourceFieldSelector{ContainerName: "app", Resource: "requests.cpu"}, "1"},
+		{corev1.ResourceFieldSelector{ContainerName: "app", Resource: "requests.cpu", Divisor: resource.MustParse("1m")}, "250"},
+		{corev1.ResourceFieldSelector{ContainerName: "app", Resource: "limits.memory", Divisor: resource.MustParse("1Mi")}, "1024"},
+	} {
+		val, err := fieldpath.ContainerResourceValue(&tc.fs, pod, nil, allocatable)
+		assert.NoError(t, err, tc.fs.Resource)
+		assert.Equal(t, tc.expected, val, tc.fs.Resource)
+	}
+
+	_, err := fieldpath.ContainerResourceValue(&corev1.ResourceFieldSelector{ContainerName: "sidecar", Resource: "requests.cpu"}, pod, nil, allocatable)
+	assert.Error(t, err)
+	_, err = fieldpath.ContainerResourceValue(&corev1.ResourceFieldSelector{ContainerName: "app", Resource: "limits.gpu"}, pod, nil, allocatable)
+	assert.Error(t, err)
+}
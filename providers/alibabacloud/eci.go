@@ -293,7 +293,10 @@ func (p *ECIProvider) GetContainerLogs(ctx context.Context, namespace, podName,
 	request := eci.CreateDescribeContainerLogRequest()
 	request.ContainerGroupId = eciId
 	request.ContainerName = containerName
-	request.Tail = requests.Integer(tail)
+	// All the lines are returned when no tail is requested.
+	if tail > 0 {
+		request.Tail = requests.Integer(tail)
+	}
 
 	// get logs from cg
 	logContent := ""
@@ -331,11 +331,15 @@ func (c *Cluster) GetContainerLogs(namespace, podName, containerName string, tai
 
 	logs := ""
 
-	err = client.logsapi.GetLogEventsPages(&cloudwatchlogs.GetLogEventsInput{
-		Limit:         aws.Int64(int64(tail)),
+	input := &cloudwatchlogs.GetLogEventsInput{
 		LogGroupName:  aws.String(c.cloudWatchLogGroupName),
 		LogStreamName: describeResult.LogStreams[0].LogStreamName,
-	}, func(page *cloudwatchlogs.GetLogEventsOutput, lastPage bool) bool {
+	}
+	if tail > 0 {
+		input.Limit = aws.Int64(int64(tail))
+	}
+
+	err = client.logsapi.GetLogEventsPages(input, func(page *cloudwatchlogs.GetLogEventsOutput, lastPage bool) bool {
 		for _, event := range page.Events {
 			logs += *event.Message
 			logs += "\n"
@@ -26,9 +26,9 @@ import (
 	client "github.com/virtual-kubelet/virtual-kubelet/providers/azure/client"
 	"github.com/virtual-kubelet/virtual-kubelet/providers/azure/client/aci"
 	"github.com/virtual-kubelet/virtual-kubelet/providers/azure/client/network"
+	"github.com/virtual-kubelet/virtual-kubelet/volume"
 	"go.opencensus.io/trace"
 	"k8s.io/api/core/v1"
-	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -472,8 +472,10 @@ func getKubeProxyExtension(secretPath, masterURI, clusterCIDR string) (*aci.Exte
 	return &extension, nil
 }
 
-func addAzureAttributes(span *trace.Span, p *ACIProvider) {
-	span.AddAttributes(
+// addAzureAttributes adds the resource group and region of the provider to the span of the call in the context,
+// which is started by the tracing middleware.
+func addAzureAttributes(ctx context.Context, p *ACIProvider) {
+	trace.FromContext(ctx).AddAttributes(
 		trace.StringAttribute("azure.resourceGroup", p.resourceGroup),
 		trace.StringAttribute("azure.region", p.region),
 	)
@@ -482,9 +484,7 @@ func addAzureAttributes(span *trace.Span, p *ACIProvider) {
 // CreatePod accepts a Pod definition and creates
 // an ACI deployment
 func (p *ACIProvider) CreatePod(ctx context.Context, pod *v1.Pod) error {
-	ctx, span := trace.StartSpan(ctx, "aci.CreatePod")
-	defer span.End()
-	addAzureAttributes(span, p)
+	addAzureAttributes(ctx, p)
 
 	var containerGroup aci.ContainerGroup
 	containerGroup.Location = p.region
@@ -502,7 +502,7 @@ func (p *ACIProvider) CreatePod(ctx context.Context, pod *v1.Pod) error {
 		return err
 	}
 	// get volumes
-	volumes, err := p.getVolumes(pod)
+	volumes, err := p.getVolumes(ctx, pod)
 	if err != nil {
 		return err
 	}
@@ -684,9 +684,7 @@ func (p *ACIProvider) UpdatePod(ctx context.Context, pod *v1.Pod) error {
 
 // DeletePod deletes the specified pod out of ACI.
 func (p *ACIProvider) DeletePod(ctx context.Context, pod *v1.Pod) error {
-	ctx, span := trace.StartSpan(ctx, "aci.DeletePod")
-	defer span.End()
-	addAzureAttributes(span, p)
+	addAzureAttributes(ctx, p)
 
 	err := p.aciClient.DeleteContainerGroup(ctx, p.resourceGroup, fmt.Sprintf("%s-%s", pod.Namespace, pod.Name))
 	return wrapError(err)
@@ -695,9 +693,7 @@ func (p *ACIProvider) DeletePod(ctx context.Context, pod *v1.Pod) error {
 // GetPod returns a pod by name that is running inside ACI
 // returns nil if a pod by that name is not found.
 func (p *ACIProvider) GetPod(ctx context.Context, namespace, name string) (*v1.Pod, error) {
-	ctx, span := trace.StartSpan(ctx, "aci.GetPod")
-	defer span.End()
-	addAzureAttributes(span, p)
+	addAzureAttributes(ctx, p)
 
 	cg, err, status := p.aciClient.GetContainerGroup(ctx, p.resourceGroup, fmt.Sprintf("%s-%s", namespace, name))
 	if err != nil {
@@ -716,9 +712,8 @@ func (p *ACIProvider) GetPod(ctx context.Context, namespace, name string) (*v1.P
 
 // GetContainerLogs returns the logs of a pod by name that is running inside ACI.
 func (p *ACIProvider) GetContainerLogs(ctx context.Context, namespace, podName, containerName string, tail int) (string, error) {
-	ctx, span := trace.StartSpan(ctx, "aci.GetContainerLogs")
-	defer span.End()
-	addAzureAttributes(span, p)
+	span := trace.FromContext(ctx)
+	addAzureAttributes(ctx, p)
 
 	logContent := ""
 	cg, err, _ := p.aciClient.GetContainerGroup(ctx, p.resourceGroup, fmt.Sprintf("%s-%s", namespace, podName))
@@ -831,9 +826,7 @@ func (p *ACIProvider) ExecInContainer(name string, uid types.UID, container stri
 // GetPodStatus returns the status of a pod by name that is running inside ACI
 // returns nil if a pod by that name is not found.
 func (p *ACIProvider) GetPodStatus(ctx context.Context, namespace, name string) (*v1.PodStatus, error) {
-	ctx, span := trace.StartSpan(ctx, "aci.GetPodStatus")
-	defer span.End()
-	addAzureAttributes(span, p)
+	addAzureAttributes(ctx, p)
 
 	pod, err := p.GetPod(ctx, namespace, name)
 	if err != nil {
@@ -849,9 +842,7 @@ func (p *ACIProvider) GetPodStatus(ctx context.Context, namespace, name string)
 
 // GetPods returns a list of all pods known to be running within ACI.
 func (p *ACIProvider) GetPods(ctx context.Context) ([]*v1.Pod, error) {
-	ctx, span := trace.StartSpan(ctx, "aci.GetPods")
-	defer span.End()
-	addAzureAttributes(span, p)
+	addAzureAttributes(ctx, p)
 
 	cgs, err := p.aciClient.ListContainerGroups(ctx, p.resourceGroup)
 	if err != nil {
@@ -1223,7 +1214,7 @@ func getProbe(probe *v1.Probe) (*aci.ContainerProbe, error) {
 	}, nil
 }
 
-func (p *ACIProvider) getVolumes(pod *v1.Pod) ([]aci.Volume, error) {
+func (p *ACIProvider) getVolumes(ctx context.Context, pod *v1.Pod) ([]aci.Volume, error) {
 	volumes := make([]aci.Volume, 0, len(pod.Spec.Volumes))
 	for _, v := range pod.Spec.Volumes {
 		// Handle the case for the AzureFile volume.
@@ -1271,43 +1262,17 @@ func (p *ACIProvider) getVolumes(pod *v1.Pod) ([]aci.Volume, error) {
 			continue
 		}
 
-		// Handle the case for Secret volume.
-		if v.Secret != nil {
-			paths := make(map[string]string)
-			secret, err := p.resourceManager.GetSecret(v.Secret.SecretName, pod.Namespace)
-			if v.Secret.Optional != nil && !*v.Secret.Optional && k8serr.IsNotFound(err) {
-				return nil, fmt.Errorf("Secret %s is required by Pod %s and does not exist", v.Secret.SecretName, pod.Name)
-			}
-			if secret == nil {
-				continue
-			}
-
-			for k, v := range secret.Data {
-				paths[k] = base64.StdEncoding.EncodeToString(v)
-			}
-
-			if len(paths) != 0 {
-				volumes = append(volumes, aci.Volume{
-					Name:   v.Name,
-					Secret: paths,
-				})
-			}
-			continue
-		}
-
-		// Handle the case for ConfigMap volume.
-		if v.ConfigMap != nil {
-			paths := make(map[string]string)
-			configMap, err := p.resourceManager.GetConfigMap(v.ConfigMap.Name, pod.Namespace)
-			if v.ConfigMap.Optional != nil && !*v.ConfigMap.Optional && k8serr.IsNotFound(err) {
-				return nil, fmt.Errorf("ConfigMap %s is required by Pod %s and does not exist", v.ConfigMap.Name, pod.Name)
-			}
-			if configMap == nil {
-				continue
+		// Handle the case for Secret, ConfigMap, DownwardAPI and Projected volumes.
+		// ACI only supports secret volumes, which do not honour file modes, so these are rendered as such.
+		if volume.Supported(&v) {
+			files, err := volume.Render(p.resourceManager, pod, &v, p.Capacity(ctx))
+			if err != nil {
+				return nil, err
 			}
 
-			for k, v := range configMap.BinaryData {
-				paths[k] = base64.StdEncoding.EncodeToString(v)
+			paths := make(map[string]string, len(files))
+			for _, f := range files {
+				paths[f.Path] = base64.StdEncoding.EncodeToString(f.Data)
 			}
 
 			if len(paths) != 0 {
@@ -17,7 +17,10 @@ import (
 func (c *Client) GetContainerLogs(ctx context.Context, resourceGroup, containerGroupName, containerName string, tail int) (*Logs, error) {
 	urlParams := url.Values{
 		"api-version": []string{apiVersion},
-		"tail":        []string{fmt.Sprintf("%d", tail)},
+	}
+	// All available logs are returned when the tail parameter is omitted.
+	if tail > 0 {
+		urlParams.Set("tail", fmt.Sprintf("%d", tail))
 	}
 
 	// Create the url.
@@ -17,9 +17,7 @@ import (
 
 // GetStatsSummary returns the stats summary for pods running on ACI
 func (p *ACIProvider) GetStatsSummary(ctx context.Context) (summary *stats.Summary, err error) {
-	ctx, span := trace.StartSpan(ctx, "GetSummaryStats")
-	defer span.End()
-	addAzureAttributes(span, p)
+	span := trace.FromContext(ctx)
 
 	p.metricsSync.Lock()
 	defer p.metricsSync.Unlock()
@@ -0,0 +1,275 @@
+// Package chaos provides a provider which injects faults, such as latency, errors and lost pods, in the calls to
+// another provider, to test how the virtual-kubelet behaves when the backend of a provider is unreliable.
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/cpuguy83/strongerrors"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"github.com/virtual-kubelet/virtual-kubelet/log"
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+)
+
+// Provider injects faults in the calls to the provider it wraps.
+// It implements all the optional interfaces, forwarding them to the wrapped provider and injecting faults in them too,
+// as required from a providers.Wrapper.
+type Provider struct {
+	providers.Provider
+	config Config
+	start  time.Time
+
+	mu   sync.Mutex
+	rand *rand.Rand
+	// lostPods holds the pods which are lost or will be, by namespace and name.
+	lostPods map[string]*lostPod
+}
+
+type lostPod struct {
+	pod     *v1.Pod
+	lostAt  time.Time
+	deleted bool
+}
+
+var _ providers.Wrapper = (*Provider)(nil)
+
+// NewProvider returns a provider injecting the configured faults in the calls to the specified provider.
+func NewProvider(p providers.Provider, config Config) (*Provider, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Provider{
+		Provider: p,
+		config:   config,
+		start:    time.Now(),
+		rand:     rand.New(rand.NewSource(seed)),
+		lostPods: make(map[string]*lostPod),
+	}, nil
+}
+
+// Unwrap implements providers.Wrapper.
+func (p *Provider) Unwrap() providers.Provider {
+	return p.Provider
+}
+
+func (p *Provider) float64() float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.rand.Float64()
+}
+
+func (p *Provider) faults(method string) MethodFaults {
+	if f, ok := p.config.Methods[method]; ok {
+		return f
+	}
+	return p.config.Methods[AnyMethod]
+}
+
+// inject injects the faults configured for the specified method, returning the error the call must fail with, if any.
+func (p *Provider) inject(ctx context.Context, method string) error {
+	f := p.faults(method)
+	logger := log.G(ctx).WithField("method", method)
+
+	latency := f.Latency.Duration
+	if f.Jitter.Duration > 0 {
+		latency += time.Duration(p.float64() * float64(f.Jitter.Duration))
+	}
+	if latency > 0 {
+		t := time.NewTimer(latency)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			if methods[method] {
+				return ctx.Err()
+			}
+		}
+	}
+	if !methods[method] {
+		return nil
+	}
+
+	if p.inOutage() {
+		logger.Debug("Injecting provider outage")
+		return strongerrors.Unavailable(fmt.Errorf("chaos: provider outage"))
+	}
+
+	switch r := p.float64(); {
+	case r < f.ErrorRate:
+		logger.Debug("Injecting provider error")
+		return strongerrors.Unavailable(fmt.Errorf("chaos: injected failure of %s", method))
+	case r < f.ErrorRate+f.NotFoundRate:
+		logger.Debug("Injecting provider not found error")
+		return strongerrors.NotFound(fmt.Errorf("chaos: injected not found error of %s", method))
+	}
+	return nil
+}
+
+// inOutage returns whether one of the configured outages is ongoing.
+func (p *Provider) inOutage() bool {
+	elapsed := time.Since(p.start)
+	for _, o := range p.config.Outages {
+		if elapsed >= o.Start.Duration && elapsed < o.Start.Duration+o.Duration.Duration {
+			return true
+		}
+	}
+	return false
+}
+
+func podKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// lost returns whether the specified pod is lost, deleting it from the wrapped provider the first time it is found lost.
+func (p *Provider) lost(ctx context.Context, namespace, name string) bool {
+	p.mu.Lock()
+	lp, ok := p.lostPods[podKey(namespace, name)]
+	if !ok || time.Now().Before(lp.lostAt) {
+		p.mu.Unlock()
+		return false
+	}
+	deleted := lp.deleted
+	lp.deleted = true
+	p.mu.Unlock()
+
+	if !deleted {
+		log.G(ctx).WithField("namespace", namespace).WithField("name", name).Debug("Losing pod")
+		if err := p.Provider.DeletePod(ctx, lp.pod); err != nil {
+			log.G(ctx).WithError(err).Warn("Error deleting lost pod from the provider")
+		}
+	}
+	return true
+}
+
+func notFound(namespace, name string) error {
+	return strongerrors.NotFound(fmt.Errorf("chaos: pod %s/%s was lost", namespace, name))
+}
+
+// CreatePod creates the pod in the wrapped provider, deciding whether it will be lost.
+// Pods which were lost are forgotten when they are created again.
+func (p *Provider) CreatePod(ctx context.Context, pod *v1.Pod) error {
+	if err := p.inject(ctx, "CreatePod"); err != nil {
+		return err
+	}
+	key := podKey(pod.Namespace, pod.Name)
+	p.mu.Lock()
+	delete(p.lostPods, key)
+	p.mu.Unlock()
+
+	if err := p.Provider.CreatePod(ctx, pod); err != nil {
+		return err
+	}
+	if p.config.LostPods.Rate > 0 && p.float64() < p.config.LostPods.Rate {
+		p.mu.Lock()
+		p.lostPods[key] = &lostPod{pod: pod.DeepCopy(), lostAt: time.Now().Add(p.config.LostPods.After.Duration)}
+		p.mu.Unlock()
+	}
+	return nil
+}
+
+func (p *Provider) UpdatePod(ctx context.Context, pod *v1.Pod) error {
+	if err := p.inject(ctx, "UpdatePod"); err != nil {
+		return err
+	}
+	if p.lost(ctx, pod.Namespace, pod.Name) {
+		return notFound(pod.Namespace, pod.Name)
+	}
+	return p.Provider.UpdatePod(ctx, pod)
+}
+
+// DeletePod deletes the pod from the wrapped provider, forgetting whether it was lost or would have been.
+func (p *Provider) DeletePod(ctx context.Context, pod *v1.Pod) error {
+	if err := p.inject(ctx, "DeletePod"); err != nil {
+		return err
+	}
+	lost := p.lost(ctx, pod.Namespace, pod.Name)
+	p.mu.Lock()
+	delete(p.lostPods, podKey(pod.Namespace, pod.Name))
+	p.mu.Unlock()
+	if lost {
+		return notFound(pod.Namespace, pod.Name)
+	}
+	return p.Provider.DeletePod(ctx, pod)
+}
+
+func (p *Provider) GetPod(ctx context.Context, namespace, name string) (*v1.Pod, error) {
+	if err := p.inject(ctx, "GetPod"); err != nil {
+		return nil, err
+	}
+	if p.lost(ctx, namespace, name) {
+		return nil, notFound(namespace, name)
+	}
+	return p.Provider.GetPod(ctx, namespace, name)
+}
+
+func (p *Provider) GetContainerLogs(ctx context.Context, namespace, podName, containerName string, tail int) (string, error) {
+	if err := p.inject(ctx, "GetContainerLogs"); err != nil {
+		return "", err
+	}
+	if p.lost(ctx, namespace, podName) {
+		return "", notFound(namespace, podName)
+	}
+	return p.Provider.GetContainerLogs(ctx, namespace, podName, containerName, tail)
+}
+
+func (p *Provider) GetPodStatus(ctx context.Context, namespace, name string) (*v1.PodStatus, error) {
+	if err := p.inject(ctx, "GetPodStatus"); err != nil {
+		return nil, err
+	}
+	if p.lost(ctx, namespace, name) {
+		return nil, notFound(namespace, name)
+	}
+	return p.Provider.GetPodStatus(ctx, namespace, name)
+}
+
+func (p *Provider) GetPods(ctx context.Context) ([]*v1.Pod, error) {
+	if err := p.inject(ctx, "GetPods"); err != nil {
+		return nil, err
+	}
+	pods, err := p.Provider.GetPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+	found := make([]*v1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		if !p.lost(ctx, pod.Namespace, pod.Name) {
+			found = append(found, pod)
+		}
+	}
+	return found, nil
+}
+
+func (p *Provider) Capacity(ctx context.Context) v1.ResourceList {
+	p.inject(ctx, "Capacity")
+	return p.Provider.Capacity(ctx)
+}
+
+func (p *Provider) NodeConditions(ctx context.Context) []v1.NodeCondition {
+	p.inject(ctx, "NodeConditions")
+	return p.Provider.NodeConditions(ctx)
+}
+
+func (p *Provider) NodeAddresses(ctx context.Context) []v1.NodeAddress {
+	p.inject(ctx, "NodeAddresses")
+	return p.Provider.NodeAddresses(ctx)
+}
+
+// ExecInContainer executes the command in the wrapped provider. It doesn't take a context, so latency can't be cut short.
+func (p *Provider) ExecInContainer(name string, uid types.UID, container string, cmd []string, in io.Reader, out, errstream io.WriteCloser, tty bool, resize <-chan remotecommand.TerminalSize, timeout time.Duration) error {
+	if err := p.inject(context.Background(), "ExecInContainer"); err != nil {
+		return err
+	}
+	return p.Provider.ExecInContainer(name, uid, container, cmd, in, out, errstream, tty, resize, timeout)
+}
@@ -0,0 +1,207 @@
+package chaos_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cpuguy83/strongerrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+	"github.com/virtual-kubelet/virtual-kubelet/providers/chaos"
+)
+
+// fakeProvider keeps the pods it is asked to create in memory.
+type fakeProvider struct {
+	providers.Provider
+	pods map[string]*v1.Pod
+}
+
+func newFakeProvider() *fakeProvider {
+	return &fakeProvider{pods: make(map[string]*v1.Pod)}
+}
+
+func (p *fakeProvider) CreatePod(ctx context.Context, pod *v1.Pod) error {
+	p.pods[pod.Name] = pod
+	return nil
+}
+
+func (p *fakeProvider) DeletePod(ctx context.Context, pod *v1.Pod) error {
+	delete(p.pods, pod.Name)
+	return nil
+}
+
+func (p *fakeProvider) GetPod(ctx context.Context, namespace, name string) (*v1.Pod, error) {
+	return p.pods[name], nil
+}
+
+func (p *fakeProvider) GetPods(ctx context.Context) ([]*v1.Pod, error) {
+	var pods []*v1.Pod
+	for _, pod := range p.pods {
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+func (p *fakeProvider) Capacity(ctx context.Context) v1.ResourceList {
+	return v1.ResourceList{}
+}
+
+func newPod(name string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}
+}
+
+func TestLoadConfig(t *testing.T) {
+	config, err := chaos.LoadConfig("chaos.toml")
+	require.NoError(t, err)
+	assert.Equal(t, "mock", config.Provider)
+	assert.Equal(t, int64(42), config.Seed)
+	assert.Equal(t, 50*time.Millisecond, config.Methods[chaos.AnyMethod].Latency.Duration)
+	assert.Equal(t, 0.2, config.Methods["CreatePod"].ErrorRate)
+	assert.Equal(t, 2*time.Minute, config.LostPods.After.Duration)
+	require.Len(t, config.Outages, 1)
+	assert.Equal(t, time.Minute, config.Outages[0].Duration.Duration)
+
+	err = chaos.Config{
+		Provider: "chaos",
+		Methods: map[string]chaos.MethodFaults{
+			"GetNode":  {},
+			"GetPod":   {ErrorRate: 0.6, NotFoundRate: 0.6},
+			"Capacity": {ErrorRate: 0.5},
+		},
+	}.Validate()
+	require.Error(t, err)
+	for _, msg := range []string{
+		"must not be the chaos provider itself",
+		"Methods.GetNode: unknown method",
+		"Methods.GetPod: ErrorRate and NotFoundRate",
+		"Methods.Capacity: errors can't be injected",
+	} {
+		assert.Contains(t, err.Error(), msg)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	p, err := chaos.NewProvider(newFakeProvider(), chaos.Config{
+		Provider: "fake",
+		Methods: map[string]chaos.MethodFaults{
+			"CreatePod":     {ErrorRate: 1},
+			chaos.AnyMethod: {NotFoundRate: 1},
+			"GetPods":       {},
+		},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	err = p.CreatePod(ctx, newPod("pod-0"))
+	assert.True(t, strongerrors.IsUnavailable(err), "unexpected error: %v", err)
+	_, err = p.GetPod(ctx, "default", "pod-0")
+	assert.True(t, strongerrors.IsNotFound(err), "unexpected error: %v", err)
+	_, err = p.GetPods(ctx)
+	assert.NoError(t, err)
+	assert.NotNil(t, p.Capacity(ctx))
+}
+
+func TestLatency(t *testing.T) {
+	p, err := chaos.NewProvider(newFakeProvider(), chaos.Config{
+		Provider: "fake",
+		Methods:  map[string]chaos.MethodFaults{chaos.AnyMethod: {Latency: chaos.Duration{Duration: time.Hour}}},
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = p.GetPods(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	// Methods which can't fail are still called once the context is done.
+	assert.NotNil(t, p.Capacity(ctx))
+}
+
+func TestOutages(t *testing.T) {
+	p, err := chaos.NewProvider(newFakeProvider(), chaos.Config{
+		Provider: "fake",
+		Outages: []chaos.Outage{
+			{Duration: chaos.Duration{Duration: time.Hour}},
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = p.GetPods(context.Background())
+	assert.True(t, strongerrors.IsUnavailable(err), "unexpected error: %v", err)
+}
+
+func TestLostPods(t *testing.T) {
+	fp := newFakeProvider()
+	p, err := chaos.NewProvider(fp, chaos.Config{
+		Provider: "fake",
+		LostPods: chaos.LostPods{Rate: 1},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, p.CreatePod(ctx, newPod("pod-0")))
+	pods, err := p.GetPods(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, pods)
+	_, err = p.GetPod(ctx, "default", "pod-0")
+	assert.True(t, strongerrors.IsNotFound(err), "unexpected error: %v", err)
+	// Lost pods are deleted from the wrapped provider.
+	assert.Empty(t, fp.pods)
+
+	// Deleting the pod forgets it was lost.
+	err = p.DeletePod(ctx, newPod("pod-0"))
+	assert.True(t, strongerrors.IsNotFound(err), "unexpected error: %v", err)
+	require.NoError(t, fp.CreatePod(ctx, newPod("pod-0")))
+	pod, err := p.GetPod(ctx, "default", "pod-0")
+	require.NoError(t, err)
+	assert.NotNil(t, pod)
+}
+
+// terminatingProvider stops pods gracefully, and notifies their status.
+type terminatingProvider struct {
+	*fakeProvider
+	stopped []string
+	notify  func(*v1.Pod)
+}
+
+func (p *terminatingProvider) StopPod(ctx context.Context, pod *v1.Pod, gracePeriod time.Duration) error {
+	p.stopped = append(p.stopped, pod.Name)
+	return nil
+}
+
+func (p *terminatingProvider) NotifyPods(ctx context.Context, f func(*v1.Pod)) {
+	p.notify = f
+}
+
+func TestOptionalInterfaces(t *testing.T) {
+	tp := &terminatingProvider{fakeProvider: newFakeProvider()}
+	p, err := chaos.NewProvider(tp, chaos.Config{
+		Provider: "fake",
+		Methods:  map[string]chaos.MethodFaults{"StopPod": {ErrorRate: 1}},
+		LostPods: chaos.LostPods{Rate: 1},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	err = p.StopPod(ctx, newPod("pod-0"), time.Second)
+	assert.True(t, strongerrors.IsUnavailable(err), "unexpected error: %v", err)
+	assert.Empty(t, tp.stopped)
+
+	_, err = p.GetStatsSummary(ctx)
+	assert.True(t, strongerrors.IsNotImplemented(err), "unexpected error: %v", err)
+
+	// The notifications of lost pods are dropped.
+	var notified []string
+	p.NotifyPods(ctx, func(pod *v1.Pod) {
+		notified = append(notified, pod.Name)
+	})
+	require.NotNil(t, tp.notify)
+	require.NoError(t, p.CreatePod(ctx, newPod("pod-1")))
+	tp.notify(newPod("pod-0"))
+	tp.notify(newPod("pod-1"))
+	assert.Equal(t, []string{"pod-0"}, notified)
+}
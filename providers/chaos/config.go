@@ -0,0 +1,166 @@
+package chaos
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// AnyMethod is the key of the faults injected in the calls to the methods which aren't configured explicitly.
+const AnyMethod = "*"
+
+// methods are the methods of the provider faults can be injected in.
+// Errors can't be injected in the methods which can't fail, such as Capacity, but latency can.
+var methods = map[string]bool{
+	"CreatePod":        true,
+	"UpdatePod":        true,
+	"DeletePod":        true,
+	"GetPod":           true,
+	"GetContainerLogs": true,
+	"GetPodStatus":     true,
+	"GetPods":          true,
+	"Capacity":         false,
+	"NodeConditions":   false,
+	"NodeAddresses":    false,
+	"ExecInContainer":  true,
+
+	// The methods of the optional interfaces.
+	"GetStatsSummary":       true,
+	"GetContainerLogStream": true,
+	"AttachToContainer":     true,
+	"PortForward":           true,
+	"StopPod":               true,
+	"AdmitPod":              false,
+}
+
+// Config represents the contents of the configuration file of the chaos provider.
+type Config struct {
+	// Provider is the name of the registered provider faults are injected in, such as "mock".
+	Provider string
+	// ProviderConfig is the configuration file of the provider faults are injected in.
+	ProviderConfig string
+	// Seed seeds the random decisions to inject faults, so that runs can be reproduced.
+	// The decisions are different for every run when it is zero.
+	Seed int64
+
+	// Methods are the faults injected in the calls to each method of the provider, by name of the method.
+	// The faults of AnyMethod are injected in the calls to the methods which aren't listed.
+	Methods map[string]MethodFaults
+	// LostPods configures pods to be lost, as if the backend of the provider had lost track of them.
+	LostPods LostPods
+	// Outages are the time windows, relative to the start of the provider, during which all the calls fail.
+	Outages []Outage
+}
+
+// MethodFaults are the faults injected in the calls to a method of the provider.
+type MethodFaults struct {
+	// Latency is added to every call, along with a random duration of up to Jitter.
+	Latency Duration
+	Jitter  Duration
+	// ErrorRate is the probability, between 0 and 1, of calls failing with an unavailability error.
+	ErrorRate float64
+	// NotFoundRate is the probability, between 0 and 1, of calls failing with a not found error.
+	NotFoundRate float64
+}
+
+// LostPods configures pods to be lost.
+// Lost pods are deleted from the provider, and aren't found anymore.
+type LostPods struct {
+	// Rate is the probability, between 0 and 1, of pods created successfully to be lost.
+	Rate float64
+	// After is how long after their creation pods are lost.
+	After Duration
+}
+
+// Outage is a time window during which all the calls to the methods of the provider which can fail fail.
+type Outage struct {
+	// Start is the time the outage starts at, relative to the start of the provider.
+	Start Duration
+	// Duration is how long the outage lasts.
+	Duration Duration
+}
+
+// Duration is a time.Duration which is written as a string, such as "1m30s", in the configuration file.
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	var err error
+	d.Duration, err = time.ParseDuration(string(text))
+	return err
+}
+
+// LoadConfig loads the specified chaos provider configuration file.
+func LoadConfig(path string) (Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Config{}, err
+	}
+	defer f.Close()
+	return loadConfig(f)
+}
+
+func loadConfig(r io.Reader) (Config, error) {
+	var config Config
+	md, err := toml.DecodeReader(r, &config)
+	if err != nil {
+		return config, err
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return config, fmt.Errorf("unknown configuration keys: %v", undecoded)
+	}
+	return config, config.Validate()
+}
+
+// Validate checks the configuration, returning all the problems found.
+func (c Config) Validate() error {
+	var errs []string
+	if c.Provider == "" {
+		errs = append(errs, "Provider is a required field")
+	}
+	if c.Provider == "chaos" {
+		errs = append(errs, "Provider must not be the chaos provider itself")
+	}
+	for name, f := range c.Methods {
+		canFail, ok := methods[name]
+		if !ok && name != AnyMethod {
+			errs = append(errs, fmt.Sprintf("Methods.%s: unknown method", name))
+			continue
+		}
+		if f.Latency.Duration < 0 || f.Jitter.Duration < 0 {
+			errs = append(errs, fmt.Sprintf("Methods.%s: Latency and Jitter must not be negative", name))
+		}
+		if !validRate(f.ErrorRate) || !validRate(f.NotFoundRate) || f.ErrorRate+f.NotFoundRate > 1 {
+			errs = append(errs, fmt.Sprintf("Methods.%s: ErrorRate and NotFoundRate must be between 0 and 1, and add up to at most 1", name))
+		}
+		if ok && !canFail && (f.ErrorRate > 0 || f.NotFoundRate > 0) {
+			errs = append(errs, fmt.Sprintf("Methods.%s: errors can't be injected in a method which can't fail", name))
+		}
+	}
+	if !validRate(c.LostPods.Rate) {
+		errs = append(errs, "LostPods.Rate must be between 0 and 1")
+	}
+	if c.LostPods.After.Duration < 0 {
+		errs = append(errs, "LostPods.After must not be negative")
+	}
+	for i, o := range c.Outages {
+		if o.Start.Duration < 0 || o.Duration.Duration <= 0 {
+			errs = append(errs, fmt.Sprintf("Outages[%d]: Start must not be negative and Duration must be positive", i))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid chaos provider configuration: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func validRate(r float64) bool {
+	return r >= 0 && r <= 1
+}
@@ -0,0 +1,133 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cpuguy83/strongerrors"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/remotecommand"
+	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
+)
+
+// Whether the wrapped provider implements an optional interface is checked with providers.Unwrap. The methods of the
+// interfaces it doesn't implement return a not implemented error, or behave as if the interface wasn't implemented
+// when they can't fail.
+
+func notImplemented(method string) error {
+	return strongerrors.NotImplemented(fmt.Errorf("%s is not implemented by the provider", method))
+}
+
+// GetStatsSummary implements providers.PodMetricsProvider. The stats of the lost pods are left out.
+func (p *Provider) GetStatsSummary(ctx context.Context) (*stats.Summary, error) {
+	mp, ok := p.Provider.(providers.PodMetricsProvider)
+	if !ok {
+		return nil, notImplemented("GetStatsSummary")
+	}
+	if err := p.inject(ctx, "GetStatsSummary"); err != nil {
+		return nil, err
+	}
+	summary, err := mp.GetStatsSummary(ctx)
+	if err != nil || summary == nil {
+		return summary, err
+	}
+	found := summary.Pods[:0]
+	for _, ps := range summary.Pods {
+		if !p.lost(ctx, ps.PodRef.Namespace, ps.PodRef.Name) {
+			found = append(found, ps)
+		}
+	}
+	summary.Pods = found
+	return summary, nil
+}
+
+// GetContainerLogStream implements providers.ContainerLogsStreamer.
+func (p *Provider) GetContainerLogStream(ctx context.Context, namespace, podName, containerName string, opts api.ContainerLogOpts) (io.ReadCloser, error) {
+	ls, ok := p.Provider.(providers.ContainerLogsStreamer)
+	if !ok {
+		return nil, notImplemented("GetContainerLogStream")
+	}
+	if err := p.inject(ctx, "GetContainerLogStream"); err != nil {
+		return nil, err
+	}
+	if p.lost(ctx, namespace, podName) {
+		return nil, notFound(namespace, podName)
+	}
+	return ls.GetContainerLogStream(ctx, namespace, podName, containerName, opts)
+}
+
+// AttachToContainer implements providers.ContainerAttacher.
+func (p *Provider) AttachToContainer(name string, uid types.UID, container string, in io.Reader, out, errstream io.WriteCloser, tty bool, resize <-chan remotecommand.TerminalSize) error {
+	a, ok := p.Provider.(providers.ContainerAttacher)
+	if !ok {
+		return notImplemented("AttachToContainer")
+	}
+	if err := p.inject(context.Background(), "AttachToContainer"); err != nil {
+		return err
+	}
+	return a.AttachToContainer(name, uid, container, in, out, errstream, tty, resize)
+}
+
+// PortForward implements providers.PortForwarder.
+func (p *Provider) PortForward(name string, uid types.UID, port int32, stream io.ReadWriteCloser) error {
+	pf, ok := p.Provider.(providers.PortForwarder)
+	if !ok {
+		return notImplemented("PortForward")
+	}
+	if err := p.inject(context.Background(), "PortForward"); err != nil {
+		return err
+	}
+	return pf.PortForward(name, uid, port, stream)
+}
+
+// StopPod implements providers.PodTerminator.
+func (p *Provider) StopPod(ctx context.Context, pod *v1.Pod, gracePeriod time.Duration) error {
+	pt, ok := p.Provider.(providers.PodTerminator)
+	if !ok {
+		return notImplemented("StopPod")
+	}
+	if err := p.inject(ctx, "StopPod"); err != nil {
+		return err
+	}
+	if p.lost(ctx, pod.Namespace, pod.Name) {
+		return notFound(pod.Namespace, pod.Name)
+	}
+	return pt.StopPod(ctx, pod, gracePeriod)
+}
+
+// AdmitPod implements providers.PodAdmitHandler. Pods are admitted when the wrapped provider doesn't implement it.
+func (p *Provider) AdmitPod(ctx context.Context, pod *v1.Pod, otherPods []*v1.Pod) providers.PodAdmitResult {
+	h, ok := p.Provider.(providers.PodAdmitHandler)
+	if !ok {
+		return providers.PodAdmitResult{Admit: true}
+	}
+	p.inject(ctx, "AdmitPod")
+	return h.AdmitPod(ctx, pod, otherPods)
+}
+
+// DelegateRestarts implements providers.RestartDelegator.
+func (p *Provider) DelegateRestarts() bool {
+	rd, ok := p.Provider.(providers.RestartDelegator)
+	return ok && rd.DelegateRestarts()
+}
+
+// NotifyPods implements providers.PodNotifier. The notifications of the lost pods are dropped, as are the
+// notifications sent during outages, so that the changes they notify are missed.
+func (p *Provider) NotifyPods(ctx context.Context, f func(*v1.Pod)) {
+	pn, ok := p.Provider.(providers.PodNotifier)
+	if !ok {
+		return
+	}
+	pn.NotifyPods(ctx, func(pod *v1.Pod) {
+		if p.inOutage() || p.lost(ctx, pod.Namespace, pod.Name) {
+			return
+		}
+		f(pod)
+	})
+}
@@ -3,7 +3,7 @@
 package cri
 
 import (
-	"bufio"
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -12,7 +12,6 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
-	"strings"
 	"syscall"
 	"time"
 
@@ -20,9 +19,10 @@ import (
 	"github.com/cpuguy83/strongerrors"
 	"github.com/virtual-kubelet/virtual-kubelet/manager"
 	"github.com/virtual-kubelet/virtual-kubelet/providers"
+	"github.com/virtual-kubelet/virtual-kubelet/volume"
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
 	"google.golang.org/grpc"
 	"k8s.io/api/core/v1"
-	k8serr "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	types "k8s.io/apimachinery/pkg/types"
@@ -39,10 +39,10 @@ const PodVolRootPerms = 0755
 const PodVolPerms = 0755
 const PodSecretVolPerms = 0755
 const PodSecretVolDir = "/secrets"
-const PodSecretFilePerms = 0644
 const PodConfigMapVolPerms = 0755
 const PodConfigMapVolDir = "/configmaps"
-const PodConfigMapFilePerms = 0644
+const PodDownwardAPIVolDir = "/downwardapi"
+const PodProjectedVolDir = "/projected"
 
 // CRIProvider implements the virtual-kubelet provider interface and manages pods in a CRI runtime
 // NOTE: CRIProvider is not inteded as an alternative to Kubelet, rather it's intended for testing and POC purposes
@@ -331,7 +331,7 @@ func convertMountPropagationToCRI(input *v1.MountPropagationMode) criapi.MountPr
 }
 
 // Create a CRI specification for the container mounts from the Pod and Container specs
-func createCtrMounts(container *v1.Container, pod *v1.Pod, podVolRoot string, rm *manager.ResourceManager) ([]*criapi.Mount, error) {
+func createCtrMounts(container *v1.Container, pod *v1.Pod, podVolRoot string, rm *manager.ResourceManager, allocatable v1.ResourceList) ([]*criapi.Mount, error) {
 	mounts := []*criapi.Mount{}
 	for _, mountSpec := range container.VolumeMounts {
 		podVolSpec := findPodVolumeSpec(pod, mountSpec.Name)
@@ -356,64 +356,16 @@ func createCtrMounts(container *v1.Container, pod *v1.Pod, podVolRoot string, rm
 			if err != nil {
 				return nil, fmt.Errorf("Error making emptyDir for path %s: %v", newMount.HostPath, err)
 			}
-		} else if podVolSpec.Secret != nil {
-			spec := podVolSpec.Secret
-			podSecretDir := filepath.Join(podVolRoot, PodSecretVolDir, mountSpec.Name)
-			newMount.HostPath = podSecretDir
-			err := os.MkdirAll(newMount.HostPath, PodSecretVolPerms)
+		} else if vol := (&v1.Volume{Name: mountSpec.Name, VolumeSource: *podVolSpec}); volume.Supported(vol) {
+			newMount.HostPath = filepath.Join(podVolRoot, renderedVolDir(vol), mountSpec.Name)
+			files, err := volume.Render(rm, pod, vol, allocatable)
 			if err != nil {
-				return nil, fmt.Errorf("Error making secret dir for path %s: %v", newMount.HostPath, err)
+				return nil, err
 			}
-			secret, err := rm.GetSecret(spec.SecretName, pod.Namespace)
-			if spec.Optional != nil && !*spec.Optional && k8serr.IsNotFound(err) {
-				return nil, fmt.Errorf("Secret %s is required by Pod %s and does not exist", spec.SecretName, pod.Name)
-			}
-			if err != nil {
-				return nil, fmt.Errorf("Error getting secret %s from API server: %v", spec.SecretName, err)
-			}
-			if secret == nil {
-				continue
-			}
-			// TODO: Check podVolSpec.Secret.Items and map to specified paths
-			// TODO: Check podVolSpec.Secret.StringData
-			// TODO: What to do with podVolSpec.Secret.SecretType?
-			for k, v := range secret.Data {
-				// TODO: Arguably the wrong place to be writing files, but clear enough for now
-				// TODO: Ensure that these files are deleted in failure cases
-				fullPath := filepath.Join(podSecretDir, k)
-				err = ioutil.WriteFile(fullPath, v, PodSecretFilePerms) // Not encoded
-				if err != nil {
-					return nil, fmt.Errorf("Could not write secret file %s", fullPath)
-				}
-			}
-		} else if podVolSpec.ConfigMap != nil {
-			spec := podVolSpec.ConfigMap
-			podConfigMapDir := filepath.Join(podVolRoot, PodConfigMapVolDir, mountSpec.Name)
-			newMount.HostPath = podConfigMapDir
-			err := os.MkdirAll(newMount.HostPath, PodConfigMapVolPerms)
-			if err != nil {
-				return nil, fmt.Errorf("Error making configmap dir for path %s: %v", newMount.HostPath, err)
-			}
-			configMap, err := rm.GetConfigMap(spec.Name, pod.Namespace)
-			if spec.Optional != nil && !*spec.Optional && k8serr.IsNotFound(err) {
-				return nil, fmt.Errorf("Configmap %s is required by Pod %s and does not exist", spec.Name, pod.Name)
-			}
-			if err != nil {
-				return nil, fmt.Errorf("Error getting configmap %s from API server: %v", spec.Name, err)
-			}
-			if configMap == nil {
-				continue
-			}
-			// TODO: Check podVolSpec.ConfigMap.Items and map to paths
-			// TODO: Check podVolSpec.ConfigMap.BinaryData
-			for k, v := range configMap.Data {
-				// TODO: Arguably the wrong place to be writing files, but clear enough for now
-				// TODO: Ensure that these files are deleted in failure cases
-				fullPath := filepath.Join(podConfigMapDir, k)
-				err = ioutil.WriteFile(fullPath, []byte(v), PodConfigMapFilePerms)
-				if err != nil {
-					return nil, fmt.Errorf("Could not write configmap file %s", fullPath)
-				}
+			// TODO: Arguably the wrong place to be writing files, but clear enough for now
+			// TODO: Ensure that these files are deleted in failure cases
+			if err := writeVolumeFiles(newMount.HostPath, files); err != nil {
+				return nil, err
 			}
 		} else {
 			continue
@@ -423,6 +375,45 @@ func createCtrMounts(container *v1.Container, pod *v1.Pod, podVolRoot string, rm
 	return mounts, nil
 }
 
+// Return the directory under the pod volume root holding the rendered volumes of the same type
+func renderedVolDir(vol *v1.Volume) string {
+	switch {
+	case vol.Secret != nil:
+		return PodSecretVolDir
+	case vol.ConfigMap != nil:
+		return PodConfigMapVolDir
+	case vol.DownwardAPI != nil:
+		return PodDownwardAPIVolDir
+	default:
+		return PodProjectedVolDir
+	}
+}
+
+// Write the files of a rendered volume to the given directory
+func writeVolumeFiles(dir string, files []volume.File) error {
+	err := os.MkdirAll(dir, PodVolPerms)
+	if err != nil {
+		return fmt.Errorf("Error making volume dir for path %s: %v", dir, err)
+	}
+	for _, f := range files {
+		fullPath := filepath.Join(dir, f.Path)
+		err = os.MkdirAll(filepath.Dir(fullPath), PodVolPerms)
+		if err != nil {
+			return fmt.Errorf("Error making volume dir for path %s: %v", fullPath, err)
+		}
+		err = ioutil.WriteFile(fullPath, f.Data, f.Mode)
+		if err != nil {
+			return fmt.Errorf("Could not write volume file %s", fullPath)
+		}
+		// WriteFile only sets the mode of new files and is subject to the umask
+		err = os.Chmod(fullPath, f.Mode)
+		if err != nil {
+			return fmt.Errorf("Could not set mode of volume file %s", fullPath)
+		}
+	}
+	return nil
+}
+
 // Test a bool pointer. If nil, return default value
 func valueOrDefaultBool(input *bool, defVal bool) bool {
 	if input != nil {
@@ -461,7 +452,7 @@ func createCtrLinuxConfig(container *v1.Container, pod *v1.Pod) *criapi.LinuxCon
 
 // Generate the CRI ContainerConfig from the Pod and container specs
 // TODO: Probably incomplete
-func generateContainerConfig(container *v1.Container, pod *v1.Pod, imageRef, podVolRoot string, rm *manager.ResourceManager, attempt uint32) (*criapi.ContainerConfig, error) {
+func generateContainerConfig(container *v1.Container, pod *v1.Pod, imageRef, podVolRoot string, rm *manager.ResourceManager, allocatable v1.ResourceList, attempt uint32) (*criapi.ContainerConfig, error) {
 	// TODO: Probably incomplete
 	config := &criapi.ContainerConfig{
 		Metadata: &criapi.ContainerMetadata{
@@ -481,7 +472,7 @@ func generateContainerConfig(container *v1.Container, pod *v1.Pod, imageRef, pod
 		StdinOnce:   container.StdinOnce,
 		Tty:         container.TTY,
 	}
-	mounts, err := createCtrMounts(container, pod, podVolRoot, rm)
+	mounts, err := createCtrMounts(container, pod, podVolRoot, rm, allocatable)
 	if err != nil {
 		return nil, err
 	}
@@ -535,7 +526,7 @@ func (p *CRIProvider) CreatePod(ctx context.Context, pod *v1.Pod) error {
 			return err
 		}
 		log.Printf("Creating container %s", c.Name)
-		cConfig, err := generateContainerConfig(&c, pod, imageRef, volPath, p.resourceManager, attempt)
+		cConfig, err := generateContainerConfig(&c, pod, imageRef, volPath, p.resourceManager, p.Capacity(ctx), attempt)
 		log.Debugf("%v", cConfig)
 		if err != nil {
 			return err
@@ -607,26 +598,6 @@ func (p *CRIProvider) GetPod(ctx context.Context, namespace, name string) (*v1.P
 	return createPodSpecFromCRI(pod, p.nodeName), nil
 }
 
-// Reads a log file into a string
-func readLogFile(filename string, tail int) (string, error) {
-	file, err := os.Open(filename)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	// TODO: This is not an efficient algorithm for tailing very large logs files
-	scanner := bufio.NewScanner(file)
-	lines := []string{}
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
-	}
-	if tail > 0 && tail < len(lines) {
-		lines = lines[len(lines)-tail:]
-	}
-	return strings.Join(lines, ""), nil
-}
-
 // Provider function to read the logs of a container
 func (p *CRIProvider) GetContainerLogs(ctx context.Context, namespace, podName, containerName string, tail int) (string, error) {
 	log.Printf("receive GetContainerLogs %q", containerName)
@@ -645,7 +616,17 @@ func (p *CRIProvider) GetContainerLogs(ctx context.Context, namespace, podName,
 		return "", strongerrors.NotFound(fmt.Errorf("Cannot find container %s in pod %s namespace %s", containerName, podName, namespace))
 	}
 
-	return readLogFile(container.LogPath, tail)
+	file, err := os.Open(container.LogPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var logs bytes.Buffer
+	if err := copyLogs(ctx, file, &logs, api.ContainerLogOpts{Tail: tail}); err != nil {
+		return "", err
+	}
+	return logs.String(), nil
 }
 
 // Get full pod name as defined in the provider context
@@ -0,0 +1,176 @@
+// +build linux
+
+package cri
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/cpuguy83/strongerrors"
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
+)
+
+// logPollInterval is the interval at which the log file of a container is polled for new lines when following it.
+const logPollInterval = time.Second
+
+// criLogLine is a line of a log file written by the container runtime, in the CRI logging format:
+// "<RFC3339Nano timestamp> <stream> <P|F> <content>", where P marks the lines split by the runtime.
+type criLogLine struct {
+	timestamp time.Time
+	content   []byte
+	partial   bool
+}
+
+// parseCRILogLine parses a line of a CRI log file, without its trailing newline.
+func parseCRILogLine(line []byte) (criLogLine, error) {
+	fields := bytes.SplitN(line, []byte(" "), 4)
+	if len(fields) < 3 {
+		return criLogLine{}, fmt.Errorf("invalid CRI log line %q", line)
+	}
+	ts, err := time.Parse(time.RFC3339Nano, string(fields[0]))
+	if err != nil {
+		return criLogLine{}, fmt.Errorf("invalid timestamp in CRI log line %q: %v", line, err)
+	}
+	l := criLogLine{timestamp: ts, partial: string(fields[2]) == "P"}
+	if len(fields) == 4 {
+		l.content = fields[3]
+	}
+	return l, nil
+}
+
+// logWriter writes the content of CRI log lines, joining the partial lines.
+type logWriter struct {
+	w          io.Writer
+	timestamps bool
+	// partial is whether the last line written was partial, in which case the next one continues it.
+	partial bool
+}
+
+func (lw *logWriter) write(l criLogLine) error {
+	var buf bytes.Buffer
+	if lw.timestamps && !lw.partial {
+		buf.WriteString(l.timestamp.Format(time.RFC3339Nano))
+		buf.WriteByte(' ')
+	}
+	buf.Write(l.content)
+	if !l.partial {
+		buf.WriteByte('\n')
+	}
+	lw.partial = l.partial
+	_, err := lw.w.Write(buf.Bytes())
+	return err
+}
+
+// copyLogs copies the CRI log lines read from r to w, according to the specified options. When following, the end
+// of r is polled for new lines until the context is done.
+func copyLogs(ctx context.Context, r io.Reader, w io.Writer, opts api.ContainerLogOpts) error {
+	since := opts.SinceTime
+	if opts.SinceSeconds > 0 {
+		since = time.Now().Add(-time.Duration(opts.SinceSeconds) * time.Second)
+	}
+	lw := &logWriter{w: w, timestamps: opts.Timestamps}
+	br := bufio.NewReader(r)
+
+	// The lines already written are read until the end of the file, keeping the last ones when tailing.
+	var (
+		tail    []criLogLine
+		pending []byte
+	)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			// A line being written is only handled once complete.
+			pending = line
+			break
+		}
+		if err != nil {
+			return err
+		}
+		l, err := parseCRILogLine(bytes.TrimSuffix(line, []byte("\n")))
+		if err != nil || l.timestamp.Before(since) {
+			continue
+		}
+		if opts.Tail <= 0 {
+			if err := lw.write(l); err != nil {
+				return err
+			}
+			continue
+		}
+		tail = append(tail, l)
+		if len(tail) > opts.Tail {
+			tail = tail[1:]
+		}
+	}
+	for _, l := range tail {
+		if err := lw.write(l); err != nil {
+			return err
+		}
+	}
+	if !opts.Follow {
+		return nil
+	}
+
+	for {
+		line, err := br.ReadBytes('\n')
+		pending = append(pending, line...)
+		if err == io.EOF {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(logPollInterval):
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		l, err := parseCRILogLine(bytes.TrimSuffix(pending, []byte("\n")))
+		pending = nil
+		if err != nil {
+			continue
+		}
+		if err := lw.write(l); err != nil {
+			return err
+		}
+	}
+}
+
+// GetContainerLogStream implements providers.ContainerLogsStreamer, reading the log file of the container written by
+// the runtime. The logs of the previous instance of the container aren't supported.
+func (p *CRIProvider) GetContainerLogStream(ctx context.Context, namespace, podName, containerName string, opts api.ContainerLogOpts) (io.ReadCloser, error) {
+	if opts.Previous {
+		return nil, strongerrors.NotImplemented(fmt.Errorf("the logs of the previous instance of a container are not supported"))
+	}
+
+	err := p.refreshNodeState()
+	if err != nil {
+		return nil, err
+	}
+
+	pod := p.findPodByName(namespace, podName)
+	if pod == nil {
+		return nil, strongerrors.NotFound(fmt.Errorf("Pod %s in namespace %s not found", podName, namespace))
+	}
+	container := pod.containers[containerName]
+	if container == nil {
+		return nil, strongerrors.NotFound(fmt.Errorf("Cannot find container %s in pod %s namespace %s", containerName, podName, namespace))
+	}
+
+	file, err := os.Open(container.LogPath)
+	if err != nil {
+		return nil, err
+	}
+	// The logs are copied until the end of the file, or until the context is done or the stream is closed when
+	// following them.
+	r, w := io.Pipe()
+	go func() {
+		defer file.Close()
+		w.CloseWithError(copyLogs(ctx, file, w, opts))
+	}()
+	return r, nil
+}
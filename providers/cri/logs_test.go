@@ -0,0 +1,54 @@
+// +build linux
+
+package cri
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
+)
+
+const criLogs = `2018-10-01T12:00:00.000000001Z stdout F line 1
+2018-10-01T12:00:01Z stderr P line
+2018-10-01T12:00:01.5Z stderr F  2
+invalid
+2018-10-01T12:00:02Z stdout F line 3
+2018-10-01T12:00:03Z stdout F
+`
+
+func TestCopyLogs(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts api.ContainerLogOpts
+		logs string
+	}{
+		{"all", api.ContainerLogOpts{}, "line 1\nline 2\nline 3\n\n"},
+		{"tail", api.ContainerLogOpts{Tail: 2}, "line 3\n\n"},
+		{"since", api.ContainerLogOpts{SinceTime: time.Date(2018, 10, 1, 12, 0, 2, 0, time.UTC)}, "line 3\n\n"},
+		{"timestamps", api.ContainerLogOpts{Tail: 4, Timestamps: true}, "2018-10-01T12:00:01Z line 2\n2018-10-01T12:00:02Z line 3\n2018-10-01T12:00:03Z \n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, copyLogs(context.Background(), strings.NewReader(criLogs), &out, tc.opts))
+			assert.Equal(t, tc.logs, out.String())
+		})
+	}
+}
+
+func TestCopyLogsFollow(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// The incomplete line is left out until the context is done.
+	var out bytes.Buffer
+	logs := criLogs + "2018-10-01T12:00:04Z stdout F line"
+	require.NoError(t, copyLogs(ctx, strings.NewReader(logs), &out, api.ContainerLogOpts{Tail: 1, Follow: true}))
+	assert.Equal(t, "\n", out.String())
+}
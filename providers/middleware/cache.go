@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"k8s.io/api/core/v1"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+)
+
+// Cache returns a middleware caching the results of GetPod, GetPodStatus and GetPods for the specified duration.
+//
+// The cached results of a pod are invalidated when it is created, updated or deleted through the middleware, as is the
+// cached list of pods, and when the decorated provider notifies a change of the status of the pod, if it implements
+// providers.PodNotifier. Other changes made to pods, such as pods exiting in providers which don't notify them, are
+// only seen once the results have expired. Errors aren't cached.
+func Cache(ttl time.Duration) Middleware {
+	return func(p providers.Provider) providers.Provider {
+		return &cache{
+			wrapper:  wrapper{p},
+			ttl:      ttl,
+			pods:     make(map[string]cachedPod),
+			statuses: make(map[string]cachedStatus),
+		}
+	}
+}
+
+type cachedPod struct {
+	pod     *v1.Pod
+	expires time.Time
+}
+
+type cachedStatus struct {
+	status  *v1.PodStatus
+	expires time.Time
+}
+
+type cachedPods struct {
+	pods    []*v1.Pod
+	expires time.Time
+}
+
+type cache struct {
+	wrapper
+	ttl time.Duration
+
+	mu       sync.Mutex
+	pods     map[string]cachedPod
+	statuses map[string]cachedStatus
+	list     cachedPods
+	// generation is incremented on every invalidation, so that results fetched before it aren't cached after it.
+	generation uint64
+}
+
+func podKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// invalidate drops the cached results of the specified pod, and the cached list of pods.
+func (c *cache) invalidate(pod *v1.Pod) {
+	key := podKey(pod.Namespace, pod.Name)
+	c.mu.Lock()
+	delete(c.pods, key)
+	delete(c.statuses, key)
+	c.list = cachedPods{}
+	c.generation++
+	c.mu.Unlock()
+}
+
+// store calls f to cache results fetched since the specified generation, unless the cache was invalidated meanwhile.
+func (c *cache) store(generation uint64, f func(expires time.Time)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		f(time.Now().Add(c.ttl))
+	}
+}
+
+func (c *cache) CreatePod(ctx context.Context, pod *v1.Pod) error {
+	defer c.invalidate(pod)
+	return c.Provider.CreatePod(ctx, pod)
+}
+
+func (c *cache) UpdatePod(ctx context.Context, pod *v1.Pod) error {
+	defer c.invalidate(pod)
+	return c.Provider.UpdatePod(ctx, pod)
+}
+
+func (c *cache) DeletePod(ctx context.Context, pod *v1.Pod) error {
+	defer c.invalidate(pod)
+	return c.Provider.DeletePod(ctx, pod)
+}
+
+// NotifyPods implements providers.PodNotifier, invalidating the cached results of the pods notified before passing them
+// on, so that their status is fetched again.
+func (c *cache) NotifyPods(ctx context.Context, f func(*v1.Pod)) {
+	c.wrapper.NotifyPods(ctx, func(pod *v1.Pod) {
+		c.invalidate(pod)
+		f(pod)
+	})
+}
+
+// The cached results are copied when they are stored and returned, for callers to be able to modify them.
+
+func (c *cache) GetPod(ctx context.Context, namespace, name string) (*v1.Pod, error) {
+	key := podKey(namespace, name)
+	c.mu.Lock()
+	cached, ok := c.pods[key]
+	generation := c.generation
+	c.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.pod.DeepCopy(), nil
+	}
+
+	pod, err := c.Provider.GetPod(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	c.store(generation, func(expires time.Time) {
+		c.pods[key] = cachedPod{pod: pod.DeepCopy(), expires: expires}
+	})
+	return pod, nil
+}
+
+func (c *cache) GetPodStatus(ctx context.Context, namespace, name string) (*v1.PodStatus, error) {
+	key := podKey(namespace, name)
+	c.mu.Lock()
+	cached, ok := c.statuses[key]
+	generation := c.generation
+	c.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.status.DeepCopy(), nil
+	}
+
+	status, err := c.Provider.GetPodStatus(ctx, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	c.store(generation, func(expires time.Time) {
+		c.statuses[key] = cachedStatus{status: status.DeepCopy(), expires: expires}
+	})
+	return status, nil
+}
+
+func (c *cache) GetPods(ctx context.Context) ([]*v1.Pod, error) {
+	c.mu.Lock()
+	cached := c.list
+	generation := c.generation
+	c.mu.Unlock()
+	if time.Now().Before(cached.expires) {
+		return copyPods(cached.pods), nil
+	}
+
+	pods, err := c.Provider.GetPods(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.store(generation, func(expires time.Time) {
+		c.list = cachedPods{pods: copyPods(pods), expires: expires}
+	})
+	return pods, nil
+}
+
+func copyPods(pods []*v1.Pod) []*v1.Pod {
+	if pods == nil {
+		return nil
+	}
+	copied := make([]*v1.Pod, 0, len(pods))
+	for _, pod := range pods {
+		copied = append(copied, pod.DeepCopy())
+	}
+	return copied
+}
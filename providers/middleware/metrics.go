@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+
+	"github.com/virtual-kubelet/virtual-kubelet/log"
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+)
+
+var (
+	// NodeKey is the name of the node a measurement is about, telling apart the nodes served by the same process.
+	NodeKey, _ = tag.NewKey("node")
+	// methodKey is the name of the provider method a measurement is about.
+	methodKey, _ = tag.NewKey("method")
+
+	// callDuration and callErrors measure the calls to the methods of the provider.
+	callDuration = stats.Float64("virtual-kubelet/provider_call_duration_seconds", "Duration of the calls to the provider", "s")
+	callErrors   = stats.Int64("virtual-kubelet/provider_call_errors", "Number of calls to the provider which returned an error", stats.UnitDimensionless)
+
+	// MetricsViews are the views of the metrics recorded by the Metrics middleware, to be registered for them to be
+	// exported.
+	MetricsViews = []*view.View{
+		{
+			Name:        callDuration.Name(),
+			Description: callDuration.Description(),
+			Measure:     callDuration,
+			Aggregation: view.Distribution(0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60),
+			TagKeys:     []tag.Key{NodeKey, methodKey},
+		},
+		{
+			Name:        callErrors.Name(),
+			Description: callErrors.Description(),
+			Measure:     callErrors,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{NodeKey, methodKey},
+		},
+	}
+)
+
+// Metrics returns a middleware recording the duration and errors of the calls to the provider of the specified node
+// with OpenCensus, tagged with the name of the node and of the method called.
+func Metrics(nodeName string) Middleware {
+	return func(p providers.Provider) providers.Provider {
+		return newInterceptor(p, func(ctx context.Context, c call, f func(context.Context) error) error {
+			return recordCall(ctx, nodeName, c, f)
+		})
+	}
+}
+
+func recordCall(ctx context.Context, nodeName string, c call, f func(context.Context) error) error {
+	start := time.Now()
+	err := f(ctx)
+
+	mctx, tagErr := tag.New(ctx, tag.Upsert(NodeKey, nodeName), tag.Upsert(methodKey, c.Method))
+	if tagErr != nil {
+		// Invalid node names only lose their metrics, the call itself is done.
+		log.G(ctx).WithError(tagErr).WithField("method", c.Method).Error("Error tagging the metrics of the provider call")
+		return err
+	}
+	stats.Record(mctx, callDuration.M(time.Since(start).Seconds()))
+	if err != nil {
+		stats.Record(mctx, callErrors.M(1))
+	}
+	return err
+}
@@ -0,0 +1,142 @@
+// Package middleware provides decorators of providers, which add behaviors such as caching, rate limiting and
+// instrumentation to the calls to any provider.
+//
+// The middlewares implement all the optional interfaces, such as providers.PodMetricsProvider, and decorate the calls
+// made through them as well. Whether the decorated provider supports an optional interface must be checked on the
+// provider returned by providers.Unwrap.
+package middleware
+
+import (
+	"context"
+
+	"k8s.io/api/core/v1"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+)
+
+// Middleware decorates a provider.
+type Middleware func(providers.Provider) providers.Provider
+
+// Chain decorates the passed in provider with the middlewares, the first one being the outermost.
+func Chain(p providers.Provider, middlewares ...Middleware) providers.Provider {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		p = middlewares[i](p)
+	}
+	return p
+}
+
+// wrapper is embedded by the decorators, for the methods they don't decorate to be forwarded to the decorated provider.
+type wrapper struct {
+	providers.Provider
+}
+
+// Unwrap implements providers.Wrapper.
+func (w wrapper) Unwrap() providers.Provider {
+	return w.Provider
+}
+
+// call describes a call to a method of a provider.
+type call struct {
+	Method string
+	// Namespace and Name are those of the pod the call is about, if any.
+	Namespace, Name string
+}
+
+// interceptFunc is called around the calls to the methods of a provider, f making the call.
+// Methods which can't fail, such as Capacity, always return a nil error from f, and f must always be called for them.
+type interceptFunc func(ctx context.Context, c call, f func(context.Context) error) error
+
+// interceptor calls a function around the calls to the methods of the decorated provider which take a context.
+type interceptor struct {
+	wrapper
+	intercept interceptFunc
+}
+
+func newInterceptor(p providers.Provider, intercept interceptFunc) *interceptor {
+	return &interceptor{wrapper: wrapper{p}, intercept: intercept}
+}
+
+func (p *interceptor) CreatePod(ctx context.Context, pod *v1.Pod) error {
+	return p.intercept(ctx, call{"CreatePod", pod.Namespace, pod.Name}, func(ctx context.Context) error {
+		return p.Provider.CreatePod(ctx, pod)
+	})
+}
+
+func (p *interceptor) UpdatePod(ctx context.Context, pod *v1.Pod) error {
+	return p.intercept(ctx, call{"UpdatePod", pod.Namespace, pod.Name}, func(ctx context.Context) error {
+		return p.Provider.UpdatePod(ctx, pod)
+	})
+}
+
+func (p *interceptor) DeletePod(ctx context.Context, pod *v1.Pod) error {
+	return p.intercept(ctx, call{"DeletePod", pod.Namespace, pod.Name}, func(ctx context.Context) error {
+		return p.Provider.DeletePod(ctx, pod)
+	})
+}
+
+func (p *interceptor) GetPod(ctx context.Context, namespace, name string) (*v1.Pod, error) {
+	var pod *v1.Pod
+	err := p.intercept(ctx, call{"GetPod", namespace, name}, func(ctx context.Context) error {
+		var err error
+		pod, err = p.Provider.GetPod(ctx, namespace, name)
+		return err
+	})
+	return pod, err
+}
+
+func (p *interceptor) GetContainerLogs(ctx context.Context, namespace, podName, containerName string, tail int) (string, error) {
+	var logs string
+	err := p.intercept(ctx, call{"GetContainerLogs", namespace, podName}, func(ctx context.Context) error {
+		var err error
+		logs, err = p.Provider.GetContainerLogs(ctx, namespace, podName, containerName, tail)
+		return err
+	})
+	return logs, err
+}
+
+func (p *interceptor) GetPodStatus(ctx context.Context, namespace, name string) (*v1.PodStatus, error) {
+	var status *v1.PodStatus
+	err := p.intercept(ctx, call{"GetPodStatus", namespace, name}, func(ctx context.Context) error {
+		var err error
+		status, err = p.Provider.GetPodStatus(ctx, namespace, name)
+		return err
+	})
+	return status, err
+}
+
+func (p *interceptor) GetPods(ctx context.Context) ([]*v1.Pod, error) {
+	var pods []*v1.Pod
+	err := p.intercept(ctx, call{Method: "GetPods"}, func(ctx context.Context) error {
+		var err error
+		pods, err = p.Provider.GetPods(ctx)
+		return err
+	})
+	return pods, err
+}
+
+func (p *interceptor) Capacity(ctx context.Context) v1.ResourceList {
+	var capacity v1.ResourceList
+	p.intercept(ctx, call{Method: "Capacity"}, func(ctx context.Context) error {
+		capacity = p.Provider.Capacity(ctx)
+		return nil
+	})
+	return capacity
+}
+
+func (p *interceptor) NodeConditions(ctx context.Context) []v1.NodeCondition {
+	var conditions []v1.NodeCondition
+	p.intercept(ctx, call{Method: "NodeConditions"}, func(ctx context.Context) error {
+		conditions = p.Provider.NodeConditions(ctx)
+		return nil
+	})
+	return conditions
+}
+
+func (p *interceptor) NodeAddresses(ctx context.Context) []v1.NodeAddress {
+	var addresses []v1.NodeAddress
+	p.intercept(ctx, call{Method: "NodeAddresses"}, func(ctx context.Context) error {
+		addresses = p.Provider.NodeAddresses(ctx)
+		return nil
+	})
+	return addresses
+}
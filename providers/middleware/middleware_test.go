@@ -0,0 +1,283 @@
+package middleware_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cpuguy83/strongerrors"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/trace"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+	"github.com/virtual-kubelet/virtual-kubelet/providers/middleware"
+)
+
+// fakeProvider counts the calls to its pod methods, whose pod creations fail.
+type fakeProvider struct {
+	providers.Provider
+	getPodCalls  int32
+	getPodsCalls int32
+	traced       int32
+}
+
+func (p *fakeProvider) CreatePod(context.Context, *v1.Pod) error {
+	return errors.New("failed")
+}
+
+func (p *fakeProvider) UpdatePod(ctx context.Context, pod *v1.Pod) error {
+	trace.FromContext(ctx).AddAttributes(trace.StringAttribute("fake.region", "local"))
+	return nil
+}
+
+func (p *fakeProvider) GetPod(ctx context.Context, namespace, name string) (*v1.Pod, error) {
+	atomic.AddInt32(&p.getPodCalls, 1)
+	if trace.FromContext(ctx) != nil {
+		atomic.AddInt32(&p.traced, 1)
+	}
+	if name == "missing" {
+		return nil, strongerrors.NotFound(errors.New("not found"))
+	}
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}, nil
+}
+
+func (p *fakeProvider) GetPods(context.Context) ([]*v1.Pod, error) {
+	atomic.AddInt32(&p.getPodsCalls, 1)
+	return []*v1.Pod{{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod-0"}}}, nil
+}
+
+func (p *fakeProvider) Capacity(context.Context) v1.ResourceList {
+	return v1.ResourceList{}
+}
+
+func TestChain(t *testing.T) {
+	fp := &fakeProvider{}
+	p := middleware.Chain(fp, middleware.Trace(), middleware.Cache(time.Hour), middleware.Metrics("vk"))
+	assert.Equal(t, fp, providers.Unwrap(p))
+
+	// The calls served from the cache are traced.
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, err := p.GetPod(ctx, "default", "pod-0")
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fp.getPodCalls))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fp.traced))
+}
+
+// spanRecorder records the spans exported by OpenCensus.
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*trace.SpanData
+}
+
+func (r *spanRecorder) ExportSpan(s *trace.SpanData) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.spans = append(r.spans, s)
+}
+
+func TestTrace(t *testing.T) {
+	r := &spanRecorder{}
+	trace.RegisterExporter(r)
+	defer trace.UnregisterExporter(r)
+
+	p := middleware.Chain(&fakeProvider{}, middleware.Trace())
+	ctx, span := trace.StartSpan(context.Background(), "test", trace.WithSampler(trace.AlwaysSample()))
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod-0"}}
+	require.NoError(t, p.UpdatePod(ctx, pod))
+	span.End()
+
+	// The attributes added by the provider are set on the span of the call.
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	require.Len(t, r.spans, 2)
+	assert.Equal(t, "provider.UpdatePod", r.spans[0].Name)
+	assert.Equal(t, map[string]interface{}{"namespace": "default", "name": "pod-0", "fake.region": "local"}, r.spans[0].Attributes)
+}
+
+func TestCache(t *testing.T) {
+	fp := &fakeProvider{}
+	p := middleware.Cache(time.Hour)(fp)
+	ctx := context.Background()
+
+	pod, err := p.GetPod(ctx, "default", "pod-0")
+	require.NoError(t, err)
+	// Results can be modified by callers without affecting the cache.
+	pod.Name = "modified"
+	pod, err = p.GetPod(ctx, "default", "pod-0")
+	require.NoError(t, err)
+	assert.Equal(t, "pod-0", pod.Name)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fp.getPodCalls))
+
+	pods, err := p.GetPods(ctx)
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	_, err = p.GetPods(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fp.getPodsCalls))
+
+	// Updating the pod invalidates its results and the list of pods.
+	require.NoError(t, p.UpdatePod(ctx, pod))
+	_, err = p.GetPod(ctx, "default", "pod-0")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fp.getPodCalls))
+	_, err = p.GetPods(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fp.getPodsCalls))
+
+	// Errors aren't cached.
+	for i := 0; i < 2; i++ {
+		_, err = p.GetPod(ctx, "default", "missing")
+		assert.True(t, strongerrors.IsNotFound(err))
+	}
+	assert.Equal(t, int32(4), atomic.LoadInt32(&fp.getPodCalls))
+
+	// Results expire.
+	fp = &fakeProvider{}
+	p = middleware.Cache(time.Nanosecond)(fp)
+	for i := 0; i < 2; i++ {
+		_, err = p.GetPod(ctx, "default", "pod-0")
+		require.NoError(t, err)
+		time.Sleep(time.Millisecond)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fp.getPodCalls))
+}
+
+func TestRateLimit(t *testing.T) {
+	fp := &fakeProvider{}
+	p := middleware.RateLimit(0.001, 1)(fp)
+
+	_, err := p.GetPod(context.Background(), "default", "pod-0")
+	require.NoError(t, err)
+
+	// The next token is only available in a thousand seconds, past the deadline of the call.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = p.GetPod(ctx, "default", "pod-0")
+	assert.True(t, strongerrors.IsExhausted(err), "unexpected error: %v", err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fp.getPodCalls))
+
+	// Each method has its own limit, and node methods aren't limited.
+	_, err = p.GetPods(ctx)
+	require.NoError(t, err)
+	assert.NotNil(t, p.Capacity(ctx))
+	assert.NotNil(t, p.Capacity(ctx))
+}
+
+func TestMetrics(t *testing.T) {
+	require.NoError(t, view.Register(middleware.MetricsViews...))
+	defer view.Unregister(middleware.MetricsViews...)
+
+	p := middleware.Metrics("vk-0")(&fakeProvider{})
+	assert.Error(t, p.CreatePod(context.Background(), &v1.Pod{}))
+	_, err := middleware.Metrics("vk-1")(&fakeProvider{}).GetPods(context.Background())
+	assert.NoError(t, err)
+
+	// The rows are indexed by the node and the method they are about.
+	rows := func(name string) map[string]view.AggregationData {
+		rows, err := view.RetrieveData(name)
+		require.NoError(t, err)
+		data := make(map[string]view.AggregationData)
+		for _, row := range rows {
+			var node, method string
+			for _, tag := range row.Tags {
+				switch tag.Key.Name() {
+				case "node":
+					node = tag.Value
+				case "method":
+					method = tag.Value
+				}
+			}
+			data[node+"/"+method] = row.Data
+		}
+		return data
+	}
+	calls := rows(middleware.MetricsViews[0].Name)
+	require.NotNil(t, calls["vk-0/CreatePod"])
+	assert.Equal(t, int64(1), calls["vk-0/CreatePod"].(*view.DistributionData).Count)
+	require.NotNil(t, calls["vk-1/GetPods"])
+	assert.Equal(t, int64(1), calls["vk-1/GetPods"].(*view.DistributionData).Count)
+	assert.Nil(t, calls["vk-1/CreatePod"])
+	failures := rows(middleware.MetricsViews[1].Name)
+	require.NotNil(t, failures["vk-0/CreatePod"])
+	assert.Equal(t, int64(1), failures["vk-0/CreatePod"].(*view.CountData).Value)
+	assert.Nil(t, failures["vk-1/GetPods"])
+}
+
+// TestMetricsInvalidNodeName verifies that the calls are still made when their metrics can't be tagged.
+func TestMetricsInvalidNodeName(t *testing.T) {
+	fp := &fakeProvider{}
+	p := middleware.Metrics("vk-\x00")(fp)
+	_, err := p.GetPod(context.Background(), "default", "pod-0")
+	assert.NoError(t, err)
+	assert.Error(t, p.CreatePod(context.Background(), &v1.Pod{}))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fp.getPodCalls))
+}
+
+// notifierProvider notifies the status of its pods, and stops them gracefully.
+type notifierProvider struct {
+	*fakeProvider
+	notify            func(*v1.Pod)
+	getPodStatusCalls int32
+	stopPodCalls      int32
+}
+
+func (p *notifierProvider) NotifyPods(ctx context.Context, f func(*v1.Pod)) {
+	p.notify = f
+}
+
+func (p *notifierProvider) GetPodStatus(context.Context, string, string) (*v1.PodStatus, error) {
+	atomic.AddInt32(&p.getPodStatusCalls, 1)
+	return &v1.PodStatus{}, nil
+}
+
+func (p *notifierProvider) StopPod(ctx context.Context, pod *v1.Pod, gracePeriod time.Duration) error {
+	atomic.AddInt32(&p.stopPodCalls, 1)
+	if trace.FromContext(ctx) != nil {
+		atomic.AddInt32(&p.traced, 1)
+	}
+	return nil
+}
+
+func TestOptionalInterfaces(t *testing.T) {
+	np := &notifierProvider{fakeProvider: &fakeProvider{}}
+	p := middleware.Chain(np, middleware.Trace(), middleware.Cache(time.Hour), middleware.RateLimit(1000, 1000))
+	ctx := context.Background()
+
+	// The calls made through the optional interfaces are decorated.
+	_, ok := providers.Unwrap(p).(providers.PodTerminator)
+	require.True(t, ok)
+	require.NoError(t, p.(providers.PodTerminator).StopPod(ctx, &v1.Pod{}, time.Second))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&np.stopPodCalls))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&np.traced))
+
+	// The interfaces the provider doesn't implement aren't supported.
+	_, ok = providers.Unwrap(p).(providers.PodMetricsProvider)
+	require.False(t, ok)
+	_, err := p.(providers.PodMetricsProvider).GetStatsSummary(ctx)
+	assert.True(t, strongerrors.IsNotImplemented(err), "unexpected error: %v", err)
+	assert.True(t, p.(providers.PodAdmitHandler).AdmitPod(ctx, &v1.Pod{}, nil).Admit)
+	assert.False(t, p.(providers.RestartDelegator).DelegateRestarts())
+
+	// Notifications invalidate the cached status of the pod before they are passed on.
+	var notified []string
+	p.(providers.PodNotifier).NotifyPods(ctx, func(pod *v1.Pod) {
+		notified = append(notified, pod.Name)
+		_, err := p.GetPodStatus(ctx, pod.Namespace, pod.Name)
+		assert.NoError(t, err)
+	})
+	require.NotNil(t, np.notify)
+	_, err = p.GetPodStatus(ctx, "default", "pod-0")
+	require.NoError(t, err)
+	np.notify(&v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod-0"}})
+	assert.Equal(t, []string{"pod-0"}, notified)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&np.getPodStatusCalls))
+}
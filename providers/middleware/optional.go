@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/cpuguy83/strongerrors"
+	"github.com/pkg/errors"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/remotecommand"
+	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
+)
+
+// The decorators implement all the optional interfaces of providers, forwarding them to the decorated provider, so
+// that the calls made through them are decorated too. Whether an optional interface is supported must be checked on
+// the provider returned by providers.Unwrap: the methods of the interfaces the decorated provider doesn't implement
+// return a not implemented error, or behave as if the interface wasn't implemented when they can't fail.
+
+func notImplemented(method string) error {
+	return strongerrors.NotImplemented(errors.Errorf("%s is not implemented by the provider", method))
+}
+
+// GetStatsSummary implements providers.PodMetricsProvider.
+func (w wrapper) GetStatsSummary(ctx context.Context) (*stats.Summary, error) {
+	mp, ok := w.Provider.(providers.PodMetricsProvider)
+	if !ok {
+		return nil, notImplemented("GetStatsSummary")
+	}
+	return mp.GetStatsSummary(ctx)
+}
+
+// GetContainerLogStream implements providers.ContainerLogsStreamer.
+func (w wrapper) GetContainerLogStream(ctx context.Context, namespace, podName, containerName string, opts api.ContainerLogOpts) (io.ReadCloser, error) {
+	ls, ok := w.Provider.(providers.ContainerLogsStreamer)
+	if !ok {
+		return nil, notImplemented("GetContainerLogStream")
+	}
+	return ls.GetContainerLogStream(ctx, namespace, podName, containerName, opts)
+}
+
+// AttachToContainer implements providers.ContainerAttacher.
+func (w wrapper) AttachToContainer(name string, uid types.UID, container string, in io.Reader, out, err io.WriteCloser, tty bool, resize <-chan remotecommand.TerminalSize) error {
+	a, ok := w.Provider.(providers.ContainerAttacher)
+	if !ok {
+		return notImplemented("AttachToContainer")
+	}
+	return a.AttachToContainer(name, uid, container, in, out, err, tty, resize)
+}
+
+// PortForward implements providers.PortForwarder.
+func (w wrapper) PortForward(name string, uid types.UID, port int32, stream io.ReadWriteCloser) error {
+	pf, ok := w.Provider.(providers.PortForwarder)
+	if !ok {
+		return notImplemented("PortForward")
+	}
+	return pf.PortForward(name, uid, port, stream)
+}
+
+// StopPod implements providers.PodTerminator.
+func (w wrapper) StopPod(ctx context.Context, pod *v1.Pod, gracePeriod time.Duration) error {
+	pt, ok := w.Provider.(providers.PodTerminator)
+	if !ok {
+		return notImplemented("StopPod")
+	}
+	return pt.StopPod(ctx, pod, gracePeriod)
+}
+
+// AdmitPod implements providers.PodAdmitHandler. Pods are admitted when the decorated provider doesn't implement it.
+func (w wrapper) AdmitPod(ctx context.Context, pod *v1.Pod, otherPods []*v1.Pod) providers.PodAdmitResult {
+	h, ok := w.Provider.(providers.PodAdmitHandler)
+	if !ok {
+		return providers.PodAdmitResult{Admit: true}
+	}
+	return h.AdmitPod(ctx, pod, otherPods)
+}
+
+// DelegateRestarts implements providers.RestartDelegator.
+func (w wrapper) DelegateRestarts() bool {
+	rd, ok := w.Provider.(providers.RestartDelegator)
+	return ok && rd.DelegateRestarts()
+}
+
+// NotifyPods implements providers.PodNotifier. Nothing is notified when the decorated provider doesn't implement it.
+func (w wrapper) NotifyPods(ctx context.Context, f func(*v1.Pod)) {
+	if pn, ok := w.Provider.(providers.PodNotifier); ok {
+		pn.NotifyPods(ctx, f)
+	}
+}
+
+// The calls to the methods of the optional interfaces which don't take a context are intercepted with a background
+// context, as they are for ExecInContainer.
+
+func (p *interceptor) ExecInContainer(name string, uid types.UID, container string, cmd []string, in io.Reader, out, errstream io.WriteCloser, tty bool, resize <-chan remotecommand.TerminalSize, timeout time.Duration) error {
+	return p.intercept(context.Background(), call{Method: "ExecInContainer"}, func(context.Context) error {
+		return p.Provider.ExecInContainer(name, uid, container, cmd, in, out, errstream, tty, resize, timeout)
+	})
+}
+
+func (p *interceptor) GetStatsSummary(ctx context.Context) (*stats.Summary, error) {
+	var summary *stats.Summary
+	err := p.intercept(ctx, call{Method: "GetStatsSummary"}, func(ctx context.Context) error {
+		var err error
+		summary, err = p.wrapper.GetStatsSummary(ctx)
+		return err
+	})
+	return summary, err
+}
+
+func (p *interceptor) GetContainerLogStream(ctx context.Context, namespace, podName, containerName string, opts api.ContainerLogOpts) (io.ReadCloser, error) {
+	var stream io.ReadCloser
+	err := p.intercept(ctx, call{"GetContainerLogStream", namespace, podName}, func(ctx context.Context) error {
+		var err error
+		stream, err = p.wrapper.GetContainerLogStream(ctx, namespace, podName, containerName, opts)
+		return err
+	})
+	return stream, err
+}
+
+func (p *interceptor) AttachToContainer(name string, uid types.UID, container string, in io.Reader, out, errstream io.WriteCloser, tty bool, resize <-chan remotecommand.TerminalSize) error {
+	return p.intercept(context.Background(), call{Method: "AttachToContainer"}, func(context.Context) error {
+		return p.wrapper.AttachToContainer(name, uid, container, in, out, errstream, tty, resize)
+	})
+}
+
+func (p *interceptor) PortForward(name string, uid types.UID, port int32, stream io.ReadWriteCloser) error {
+	return p.intercept(context.Background(), call{Method: "PortForward"}, func(context.Context) error {
+		return p.wrapper.PortForward(name, uid, port, stream)
+	})
+}
+
+func (p *interceptor) StopPod(ctx context.Context, pod *v1.Pod, gracePeriod time.Duration) error {
+	return p.intercept(ctx, call{"StopPod", pod.Namespace, pod.Name}, func(ctx context.Context) error {
+		return p.wrapper.StopPod(ctx, pod, gracePeriod)
+	})
+}
+
+func (p *interceptor) AdmitPod(ctx context.Context, pod *v1.Pod, otherPods []*v1.Pod) providers.PodAdmitResult {
+	var result providers.PodAdmitResult
+	p.intercept(ctx, call{"AdmitPod", pod.Namespace, pod.Name}, func(ctx context.Context) error {
+		result = p.wrapper.AdmitPod(ctx, pod, otherPods)
+		return nil
+	})
+	return result
+}
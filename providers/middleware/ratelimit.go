@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/cpuguy83/strongerrors"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+)
+
+// rateLimitedMethods are the methods of the provider which are rate limited.
+// The methods which can't fail, such as Capacity, are called once per node status update and aren't limited.
+var rateLimitedMethods = []string{"CreatePod", "UpdatePod", "DeletePod", "GetPod", "GetContainerLogs", "GetPodStatus", "GetPods", "GetContainerLogStream", "StopPod"}
+
+// RateLimit returns a middleware limiting the calls to each method of the provider with a token bucket, which is
+// refilled with qps tokens per second and holds at most burst tokens.
+// Calls wait for a token, unless their context is cancelled or its deadline would be exceeded.
+func RateLimit(qps float64, burst int) Middleware {
+	return func(p providers.Provider) providers.Provider {
+		limiters := make(map[string]*rate.Limiter, len(rateLimitedMethods))
+		for _, m := range rateLimitedMethods {
+			limiters[m] = rate.NewLimiter(rate.Limit(qps), burst)
+		}
+
+		return newInterceptor(p, func(ctx context.Context, c call, f func(context.Context) error) error {
+			if l, ok := limiters[c.Method]; ok {
+				if err := l.Wait(ctx); err != nil {
+					if ctx.Err() != nil {
+						return errors.Wrapf(ctx.Err(), "error waiting for the rate limit of %s", c.Method)
+					}
+					return strongerrors.Exhausted(errors.Wrapf(err, "rate limit of %s exceeded", c.Method))
+				}
+			}
+			return f(ctx)
+		})
+	}
+}
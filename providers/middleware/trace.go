@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/cpuguy83/strongerrors/status/ocstatus"
+	"go.opencensus.io/trace"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+)
+
+// Trace returns a middleware starting an OpenCensus span for every call to the provider, named after the method
+// called, with the namespace and name of the pod as attributes. The span is passed to the provider in the context,
+// so that providers don't need to start their own.
+func Trace() Middleware {
+	return func(p providers.Provider) providers.Provider {
+		return newInterceptor(p, traceCall)
+	}
+}
+
+func traceCall(ctx context.Context, c call, f func(context.Context) error) error {
+	ctx, span := trace.StartSpan(ctx, "provider."+c.Method)
+	defer span.End()
+	if c.Name != "" {
+		span.AddAttributes(
+			trace.StringAttribute("namespace", c.Namespace),
+			trace.StringAttribute("name", c.Name),
+		)
+	}
+
+	err := f(ctx)
+	span.SetStatus(ocstatus.FromError(err))
+	return err
+}
@@ -0,0 +1,486 @@
+// Package plugin allows providers to run out of process, as separate binaries serving the gRPC protocol defined in the
+// pluginv1 package.
+//
+// The virtual-kubelet uses Provider to forward its calls to such a binary, which can use Serve to expose any
+// implementation of providers.Provider over the protocol.
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cpuguy83/strongerrors"
+	grpcstatus "github.com/cpuguy83/strongerrors/status"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/remotecommand"
+	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
+	utilexec "k8s.io/utils/exec"
+
+	"github.com/virtual-kubelet/virtual-kubelet/log"
+	"github.com/virtual-kubelet/virtual-kubelet/providers/plugin/pluginv1"
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
+)
+
+const (
+	// dialTimeout is the maximum amount of time to wait for the plugin to accept connections.
+	dialTimeout = 30 * time.Second
+	// streamChunkSize is the maximum size of the chunks of data sent on streams.
+	streamChunkSize = 32 * 1024
+)
+
+// Provider implements the virtual-kubelet provider interface by forwarding all calls to a plugin.
+type Provider struct {
+	conn            *grpc.ClientConn
+	client          pluginv1.ProviderClient
+	operatingSystem string
+}
+
+// NewProvider connects to the plugin listening on the specified endpoint, and returns a provider forwarding calls to it.
+// The endpoint is either a unix socket, such as "unix:///run/provider.sock", or a TCP address, such as "tcp://127.0.0.1:10300".
+// Addresses without a scheme are considered to be unix sockets when they are absolute paths, and TCP addresses otherwise.
+func NewProvider(endpoint string) (*Provider, error) {
+	network, addr, err := parseEndpoint(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+
+	conn, err := grpc.DialContext(ctx, addr, grpc.WithInsecure(), grpc.WithBlock(), grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+		return net.DialTimeout(network, addr, timeout)
+	}))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error connecting to plugin at %s", endpoint)
+	}
+
+	p := &Provider{
+		conn:   conn,
+		client: pluginv1.NewProviderClient(conn),
+	}
+
+	// The operating system is retrieved once, as it can't change and the provider interface doesn't allow reporting errors.
+	res, err := p.client.OperatingSystem(ctx, &pluginv1.OperatingSystemRequest{})
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(fromGRPC(err), "error getting the operating system of the plugin")
+	}
+	p.operatingSystem = res.OperatingSystem
+
+	return p, nil
+}
+
+// parseEndpoint returns the network and address of the specified plugin endpoint.
+func parseEndpoint(endpoint string) (string, string, error) {
+	switch {
+	case strings.HasPrefix(endpoint, "unix://"):
+		return "unix", strings.TrimPrefix(endpoint, "unix://"), nil
+	case strings.HasPrefix(endpoint, "tcp://"):
+		return "tcp", strings.TrimPrefix(endpoint, "tcp://"), nil
+	case strings.Contains(endpoint, "://"):
+		return "", "", strongerrors.InvalidArgument(errors.Errorf("unsupported plugin endpoint: %s", endpoint))
+	case strings.HasPrefix(endpoint, "/"):
+		return "unix", endpoint, nil
+	case endpoint == "":
+		return "", "", strongerrors.InvalidArgument(errors.New("no plugin endpoint specified"))
+	default:
+		return "tcp", endpoint, nil
+	}
+}
+
+// Close closes the connection to the plugin.
+func (p *Provider) Close() error {
+	return p.conn.Close()
+}
+
+// CreatePod takes a Kubernetes Pod and deploys it within the plugin.
+func (p *Provider) CreatePod(ctx context.Context, pod *v1.Pod) error {
+	b, err := pod.Marshal()
+	if err != nil {
+		return errors.Wrap(err, "error encoding pod")
+	}
+	_, err = p.client.CreatePod(ctx, &pluginv1.CreatePodRequest{Pod: b})
+	return fromGRPC(err)
+}
+
+// UpdatePod takes a Kubernetes Pod and updates it within the plugin.
+func (p *Provider) UpdatePod(ctx context.Context, pod *v1.Pod) error {
+	b, err := pod.Marshal()
+	if err != nil {
+		return errors.Wrap(err, "error encoding pod")
+	}
+	_, err = p.client.UpdatePod(ctx, &pluginv1.UpdatePodRequest{Pod: b})
+	return fromGRPC(err)
+}
+
+// DeletePod takes a Kubernetes Pod and deletes it from the plugin.
+func (p *Provider) DeletePod(ctx context.Context, pod *v1.Pod) error {
+	b, err := pod.Marshal()
+	if err != nil {
+		return errors.Wrap(err, "error encoding pod")
+	}
+	_, err = p.client.DeletePod(ctx, &pluginv1.DeletePodRequest{Pod: b})
+	return fromGRPC(err)
+}
+
+// GetPod retrieves a pod by name from the plugin.
+// It returns nil if the pod is not known to the plugin.
+func (p *Provider) GetPod(ctx context.Context, namespace, name string) (*v1.Pod, error) {
+	res, err := p.client.GetPod(ctx, &pluginv1.GetPodRequest{Namespace: namespace, Name: name})
+	if err != nil {
+		return nil, fromGRPC(err)
+	}
+	if len(res.Pod) == 0 {
+		return nil, nil
+	}
+	pod := &v1.Pod{}
+	if err := pod.Unmarshal(res.Pod); err != nil {
+		return nil, errors.Wrap(err, "error decoding pod")
+	}
+	return pod, nil
+}
+
+// GetPodStatus retrieves the status of a pod by name from the plugin.
+// It returns nil if the pod is not known to the plugin.
+func (p *Provider) GetPodStatus(ctx context.Context, namespace, name string) (*v1.PodStatus, error) {
+	res, err := p.client.GetPodStatus(ctx, &pluginv1.GetPodStatusRequest{Namespace: namespace, Name: name})
+	if err != nil {
+		return nil, fromGRPC(err)
+	}
+	if len(res.Status) == 0 {
+		return nil, nil
+	}
+	status := &v1.PodStatus{}
+	if err := status.Unmarshal(res.Status); err != nil {
+		return nil, errors.Wrap(err, "error decoding pod status")
+	}
+	return status, nil
+}
+
+// GetPods retrieves a list of all pods running on the plugin.
+func (p *Provider) GetPods(ctx context.Context) ([]*v1.Pod, error) {
+	res, err := p.client.GetPods(ctx, &pluginv1.GetPodsRequest{})
+	if err != nil {
+		return nil, fromGRPC(err)
+	}
+	pods := make([]*v1.Pod, 0, len(res.Pods))
+	for _, b := range res.Pods {
+		pod := &v1.Pod{}
+		if err := pod.Unmarshal(b); err != nil {
+			return nil, errors.Wrap(err, "error decoding pod")
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+// GetContainerLogs retrieves the logs of a container by name from the plugin.
+func (p *Provider) GetContainerLogs(ctx context.Context, namespace, podName, containerName string, tail int) (string, error) {
+	r, err := p.GetContainerLogStream(ctx, namespace, podName, containerName, api.ContainerLogOpts{Tail: tail})
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	var b strings.Builder
+	if _, err := io.Copy(&b, r); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// GetContainerLogStream returns a stream of the logs of a container, according to the specified options.
+func (p *Provider) GetContainerLogStream(ctx context.Context, namespace, podName, containerName string, opts api.ContainerLogOpts) (io.ReadCloser, error) {
+	req := &pluginv1.GetContainerLogsRequest{
+		Namespace:     namespace,
+		PodName:       podName,
+		ContainerName: containerName,
+		Tail:          int64(opts.Tail),
+		LimitBytes:    int64(opts.LimitBytes),
+		Timestamps:    opts.Timestamps,
+		Follow:        opts.Follow,
+		Previous:      opts.Previous,
+		SinceSeconds:  int64(opts.SinceSeconds),
+	}
+	if !opts.SinceTime.IsZero() {
+		req.SinceTime = opts.SinceTime.UnixNano()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	stream, err := p.client.GetContainerLogs(ctx, req)
+	if err != nil {
+		cancel()
+		return nil, fromGRPC(err)
+	}
+
+	// The first message is received before returning, so that errors such as the container not being found are
+	// returned by this method rather than when reading the logs.
+	res, err := stream.Recv()
+	if err != nil && err != io.EOF {
+		cancel()
+		return nil, fromGRPC(err)
+	}
+	r := &logStream{stream: stream, cancel: cancel, eof: err == io.EOF}
+	if res != nil {
+		r.buf = res.Data
+	}
+	return r, nil
+}
+
+// logStream reads the logs streamed by the GetContainerLogs method.
+type logStream struct {
+	stream pluginv1.Provider_GetContainerLogsClient
+	cancel context.CancelFunc
+	buf    []byte
+	eof    bool
+}
+
+func (r *logStream) Read(b []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.eof {
+			return 0, io.EOF
+		}
+		res, err := r.stream.Recv()
+		if err == io.EOF {
+			r.eof = true
+			continue
+		}
+		if err != nil {
+			return 0, fromGRPC(err)
+		}
+		r.buf = res.Data
+	}
+	n := copy(b, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (r *logStream) Close() error {
+	r.cancel()
+	return nil
+}
+
+// ExecInContainer executes a command in a container in the pod, copying data
+// between in/out/err and the container's stdin/stdout/stderr.
+func (p *Provider) ExecInContainer(name string, uid types.UID, container string, cmd []string, in io.Reader, out, errstream io.WriteCloser, tty bool, resize <-chan remotecommand.TerminalSize, timeout time.Duration) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := p.client.ExecInContainer(ctx)
+	if err != nil {
+		return fromGRPC(err)
+	}
+
+	// Requests are sent concurrently from the input and resize goroutines, which gRPC doesn't allow on a single stream.
+	var mu sync.Mutex
+	send := func(req *pluginv1.ExecInContainerRequest) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return stream.Send(req)
+	}
+
+	start := &pluginv1.ExecStart{
+		Name:           name,
+		Uid:            string(uid),
+		Container:      container,
+		Command:        cmd,
+		Tty:            tty,
+		Stdin:          in != nil,
+		TimeoutSeconds: int64(timeout / time.Second),
+	}
+	if err := send(&pluginv1.ExecInContainerRequest{Start: start}); err != nil {
+		return fromGRPC(err)
+	}
+
+	if in != nil {
+		go func() {
+			b := make([]byte, streamChunkSize)
+			for {
+				n, err := in.Read(b)
+				if n > 0 {
+					if send(&pluginv1.ExecInContainerRequest{Stdin: append([]byte(nil), b[:n]...)}) != nil {
+						return
+					}
+				}
+				if err != nil {
+					send(&pluginv1.ExecInContainerRequest{CloseStdin: true})
+					return
+				}
+			}
+		}()
+	}
+
+	if resize != nil {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case size, ok := <-resize:
+					if !ok {
+						return
+					}
+					if send(&pluginv1.ExecInContainerRequest{Resize: &pluginv1.TerminalSize{Width: uint32(size.Width), Height: uint32(size.Height)}}) != nil {
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	var exitCode int32
+	for {
+		res, err := stream.Recv()
+		if err == io.EOF {
+			if exitCode != 0 {
+				return utilexec.CodeExitError{
+					Err:  fmt.Errorf("command terminated with non-zero exit code %d", exitCode),
+					Code: int(exitCode),
+				}
+			}
+			return nil
+		}
+		if err != nil {
+			return fromGRPC(err)
+		}
+		if res.ExitCode != 0 {
+			exitCode = res.ExitCode
+		}
+		if len(res.Stdout) > 0 && out != nil {
+			if _, err := out.Write(res.Stdout); err != nil {
+				return err
+			}
+		}
+		if len(res.Stderr) > 0 && errstream != nil {
+			if _, err := errstream.Write(res.Stderr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Capacity returns a resource list with the capacity constraints of the plugin.
+func (p *Provider) Capacity(ctx context.Context) v1.ResourceList {
+	res, err := p.client.Capacity(ctx, &pluginv1.CapacityRequest{})
+	if err != nil {
+		log.G(ctx).WithError(err).Error("Error getting the capacity of the plugin")
+		return nil
+	}
+	capacity := make(v1.ResourceList, len(res.Capacity))
+	for name, value := range res.Capacity {
+		q, err := resource.ParseQuantity(value)
+		if err != nil {
+			log.G(ctx).WithError(err).WithField("resource", name).Error("Ignoring invalid capacity reported by the plugin")
+			continue
+		}
+		capacity[v1.ResourceName(name)] = q
+	}
+	return capacity
+}
+
+// NodeConditions returns a list of conditions (Ready, OutOfDisk, etc), which is
+// polled periodically to update the node status within Kubernetes.
+// The node is reported as not ready when the plugin can't be reached.
+func (p *Provider) NodeConditions(ctx context.Context) []v1.NodeCondition {
+	res, err := p.client.NodeConditions(ctx, &pluginv1.NodeConditionsRequest{})
+	if err != nil {
+		log.G(ctx).WithError(err).Error("Error getting the node conditions of the plugin")
+		now := metav1.Now()
+		return []v1.NodeCondition{{
+			Type:               v1.NodeReady,
+			Status:             v1.ConditionFalse,
+			LastHeartbeatTime:  now,
+			LastTransitionTime: now,
+			Reason:             "PluginUnavailable",
+			Message:            status.Convert(err).Message(),
+		}}
+	}
+	conditions := make([]v1.NodeCondition, 0, len(res.Conditions))
+	for _, b := range res.Conditions {
+		var c v1.NodeCondition
+		if err := c.Unmarshal(b); err != nil {
+			log.G(ctx).WithError(err).Error("Ignoring invalid node condition reported by the plugin")
+			continue
+		}
+		conditions = append(conditions, c)
+	}
+	return conditions
+}
+
+// NodeAddresses returns a list of addresses for the node status
+// within Kubernetes.
+func (p *Provider) NodeAddresses(ctx context.Context) []v1.NodeAddress {
+	res, err := p.client.NodeAddresses(ctx, &pluginv1.NodeAddressesRequest{})
+	if err != nil {
+		log.G(ctx).WithError(err).Error("Error getting the node addresses of the plugin")
+		return nil
+	}
+	addresses := make([]v1.NodeAddress, 0, len(res.Addresses))
+	for _, b := range res.Addresses {
+		var a v1.NodeAddress
+		if err := a.Unmarshal(b); err != nil {
+			log.G(ctx).WithError(err).Error("Ignoring invalid node address reported by the plugin")
+			continue
+		}
+		addresses = append(addresses, a)
+	}
+	return addresses
+}
+
+// NodeDaemonEndpoints returns NodeDaemonEndpoints for the node status
+// within Kubernetes.
+func (p *Provider) NodeDaemonEndpoints(ctx context.Context) *v1.NodeDaemonEndpoints {
+	endpoints := &v1.NodeDaemonEndpoints{}
+	res, err := p.client.NodeDaemonEndpoints(ctx, &pluginv1.NodeDaemonEndpointsRequest{})
+	if err != nil {
+		log.G(ctx).WithError(err).Error("Error getting the node daemon endpoints of the plugin")
+		return endpoints
+	}
+	if err := endpoints.Unmarshal(res.Endpoints); err != nil {
+		log.G(ctx).WithError(err).Error("Ignoring invalid node daemon endpoints reported by the plugin")
+		return &v1.NodeDaemonEndpoints{}
+	}
+	return endpoints
+}
+
+// OperatingSystem returns the operating system the plugin is for.
+func (p *Provider) OperatingSystem() string {
+	return p.operatingSystem
+}
+
+// GetStatsSummary returns the stats of the pods running on the plugin.
+func (p *Provider) GetStatsSummary(ctx context.Context) (*stats.Summary, error) {
+	res, err := p.client.GetStatsSummary(ctx, &pluginv1.GetStatsSummaryRequest{})
+	if err != nil {
+		return nil, fromGRPC(err)
+	}
+	summary := &stats.Summary{}
+	if err := json.Unmarshal(res.Summary, summary); err != nil {
+		return nil, errors.Wrap(err, "error decoding stats summary")
+	}
+	return summary, nil
+}
+
+// fromGRPC converts the specified error returned by a call to the plugin into the corresponding strongerrors error.
+func fromGRPC(err error) error {
+	if err == nil {
+		return nil
+	}
+	s, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	return grpcstatus.FromGRPC(s)
+}
@@ -0,0 +1,227 @@
+package plugin_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cpuguy83/strongerrors"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/utils/exec"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+	"github.com/virtual-kubelet/virtual-kubelet/providers/plugin"
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
+)
+
+// fakeProvider is a provider storing pods in memory, which echoes the input of the commands run in its containers.
+type fakeProvider struct {
+	providers.Provider
+	pods    map[string]*v1.Pod
+	logOpts api.ContainerLogOpts
+}
+
+func (p *fakeProvider) CreatePod(_ context.Context, pod *v1.Pod) error {
+	p.pods[pod.Namespace+"/"+pod.Name] = pod
+	return nil
+}
+
+func (p *fakeProvider) DeletePod(_ context.Context, pod *v1.Pod) error {
+	key := pod.Namespace + "/" + pod.Name
+	if _, ok := p.pods[key]; !ok {
+		return strongerrors.NotFound(errors.Errorf("pod %s not found", key))
+	}
+	delete(p.pods, key)
+	return nil
+}
+
+func (p *fakeProvider) GetPod(_ context.Context, namespace, name string) (*v1.Pod, error) {
+	return p.pods[namespace+"/"+name], nil
+}
+
+func (p *fakeProvider) GetPods(context.Context) ([]*v1.Pod, error) {
+	pods := make([]*v1.Pod, 0, len(p.pods))
+	for _, pod := range p.pods {
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+func (p *fakeProvider) GetContainerLogStream(_ context.Context, namespace, podName, containerName string, opts api.ContainerLogOpts) (io.ReadCloser, error) {
+	p.logOpts = opts
+	return ioutil.NopCloser(strings.NewReader(strings.Repeat("line\n", 10000))), nil
+}
+
+func (p *fakeProvider) ExecInContainer(name string, uid types.UID, container string, cmd []string, in io.Reader, out, err io.WriteCloser, tty bool, resize <-chan remotecommand.TerminalSize, timeout time.Duration) error {
+	if container != "container-0" {
+		return strongerrors.NotFound(errors.Errorf("container %s not found", container))
+	}
+	if cmd[0] == "false" {
+		return utilexec.CodeExitError{Err: errors.New("command exited with code 3"), Code: 3}
+	}
+	io.WriteString(err, strings.Join(cmd, " "))
+	_, copyErr := io.Copy(out, in)
+	return copyErr
+}
+
+func (p *fakeProvider) Capacity(context.Context) v1.ResourceList {
+	return v1.ResourceList{
+		v1.ResourceCPU:    resource.MustParse("20"),
+		v1.ResourceMemory: resource.MustParse("100Gi"),
+	}
+}
+
+func (p *fakeProvider) NodeConditions(context.Context) []v1.NodeCondition {
+	return []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}
+}
+
+func (p *fakeProvider) NodeAddresses(context.Context) []v1.NodeAddress {
+	return []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}}
+}
+
+func (p *fakeProvider) NodeDaemonEndpoints(context.Context) *v1.NodeDaemonEndpoints {
+	return &v1.NodeDaemonEndpoints{KubeletEndpoint: v1.DaemonEndpoint{Port: 10250}}
+}
+
+func (p *fakeProvider) OperatingSystem() string {
+	return "Linux"
+}
+
+// nopWriteCloser is a buffer implementing io.WriteCloser.
+type nopWriteCloser struct {
+	bytes.Buffer
+}
+
+func (*nopWriteCloser) Close() error {
+	return nil
+}
+
+// startPlugin serves the specified provider on a unix socket, and returns a provider connected to it along with a
+// function stopping the plugin.
+func startPlugin(t *testing.T, fp providers.Provider) (*plugin.Provider, func()) {
+	dir, err := ioutil.TempDir("", "plugin")
+	require.NoError(t, err)
+
+	socket := filepath.Join(dir, "provider.sock")
+	lis, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	go plugin.Serve(lis, fp)
+
+	p, err := plugin.NewProvider("unix://" + socket)
+	require.NoError(t, err)
+	return p, func() {
+		p.Close()
+		lis.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// TestPods verifies that pods are created, retrieved and deleted through the plugin, and that errors are preserved.
+func TestPods(t *testing.T) {
+	p, stop := startPlugin(t, &fakeProvider{pods: make(map[string]*v1.Pod)})
+	defer stop()
+	ctx := context.Background()
+	assert.Equal(t, "Linux", p.OperatingSystem())
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod-0", UID: "pod-0"},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "container-0", Image: "busybox"}}},
+	}
+	require.NoError(t, p.CreatePod(ctx, pod))
+
+	got, err := p.GetPod(ctx, "default", "pod-0")
+	require.NoError(t, err)
+	assert.Equal(t, pod, got)
+
+	pods, err := p.GetPods(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*v1.Pod{pod}, pods)
+
+	require.NoError(t, p.DeletePod(ctx, pod))
+	got, err = p.GetPod(ctx, "default", "pod-0")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	err = p.DeletePod(ctx, pod)
+	assert.True(t, strongerrors.IsNotFound(err), "unexpected error: %v", err)
+
+	// The fake provider doesn't implement the optional PodMetricsProvider interface.
+	_, err = p.GetStatsSummary(ctx)
+	assert.True(t, strongerrors.IsNotImplemented(err), "unexpected error: %v", err)
+}
+
+// TestNode verifies that the node status reported by the plugin is preserved.
+func TestNode(t *testing.T) {
+	p, stop := startPlugin(t, &fakeProvider{})
+	defer stop()
+	ctx := context.Background()
+
+	capacity := p.Capacity(ctx)
+	assert.Equal(t, "20", capacity.Cpu().String())
+	assert.Equal(t, "100Gi", capacity.Memory().String())
+	assert.Equal(t, []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}, p.NodeConditions(ctx))
+	assert.Equal(t, []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}}, p.NodeAddresses(ctx))
+	assert.Equal(t, int32(10250), p.NodeDaemonEndpoints(ctx).KubeletEndpoint.Port)
+}
+
+// TestContainerLogs verifies that logs are streamed from the plugin with all their options.
+func TestContainerLogs(t *testing.T) {
+	fp := &fakeProvider{}
+	p, stop := startPlugin(t, fp)
+	defer stop()
+
+	since := time.Now().Add(-time.Hour).Truncate(time.Second)
+	opts := api.ContainerLogOpts{Tail: 10, LimitBytes: 1024, Timestamps: true, Follow: true, Previous: true, SinceTime: since}
+	r, err := p.GetContainerLogStream(context.Background(), "default", "pod-0", "container-0", opts)
+	require.NoError(t, err)
+	defer r.Close()
+
+	logs, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Repeat("line\n", 10000), string(logs))
+	assert.True(t, fp.logOpts.SinceTime.Equal(since))
+	fp.logOpts.SinceTime = opts.SinceTime
+	assert.Equal(t, opts, fp.logOpts)
+}
+
+// TestExecInContainer verifies that the input and outputs of commands are streamed to and from the plugin.
+func TestExecInContainer(t *testing.T) {
+	p, stop := startPlugin(t, &fakeProvider{})
+	defer stop()
+
+	var stdout, stderr nopWriteCloser
+	err := p.ExecInContainer("default-pod-0", "pod-0", "container-0", []string{"cat", "-"}, strings.NewReader("hello"), &stdout, &stderr, false, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", stdout.String())
+	assert.Equal(t, "cat -", stderr.String())
+
+	err = p.ExecInContainer("default-pod-0", "pod-0", "missing", []string{"cat"}, nil, &stdout, &stderr, false, nil, 0)
+	assert.True(t, strongerrors.IsNotFound(err), "unexpected error: %v", err)
+}
+
+// TestExecInContainerExitCode verifies that the exit code of commands is carried back from the plugin.
+func TestExecInContainerExitCode(t *testing.T) {
+	p, stop := startPlugin(t, &fakeProvider{})
+	defer stop()
+
+	var stdout, stderr nopWriteCloser
+	err := p.ExecInContainer("default-pod-0", "pod-0", "container-0", []string{"false"}, nil, &stdout, &stderr, false, nil, 0)
+	exitErr, ok := err.(utilexec.ExitError)
+	require.True(t, ok, "unexpected error: %v", err)
+	assert.True(t, exitErr.Exited())
+	assert.Equal(t, 3, exitErr.ExitStatus())
+}
@@ -0,0 +1,6 @@
+// Package pluginv1 implements version 1 of the gRPC protocol between the virtual-kubelet and out-of-process providers,
+// which is described in provider.proto.
+//
+// The code of the package is generated from provider.proto with `make generate`, which requires protoc and the
+// version of protoc-gen-go matching the vendored github.com/golang/protobuf.
+package pluginv1
@@ -0,0 +1,1966 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: provider.proto
+
+package pluginv1
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
+
+type CreatePodRequest struct {
+	// pod is a k8s.io.api.core.v1.Pod.
+	Pod                  []byte   `protobuf:"bytes,1,opt,name=pod,proto3" json:"pod,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CreatePodRequest) Reset()         { *m = CreatePodRequest{} }
+func (m *CreatePodRequest) String() string { return proto.CompactTextString(m) }
+func (*CreatePodRequest) ProtoMessage()    {}
+func (*CreatePodRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{0}
+}
+func (m *CreatePodRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CreatePodRequest.Unmarshal(m, b)
+}
+func (m *CreatePodRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CreatePodRequest.Marshal(b, m, deterministic)
+}
+func (dst *CreatePodRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CreatePodRequest.Merge(dst, src)
+}
+func (m *CreatePodRequest) XXX_Size() int {
+	return xxx_messageInfo_CreatePodRequest.Size(m)
+}
+func (m *CreatePodRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreatePodRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreatePodRequest proto.InternalMessageInfo
+
+func (m *CreatePodRequest) GetPod() []byte {
+	if m != nil {
+		return m.Pod
+	}
+	return nil
+}
+
+type CreatePodResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CreatePodResponse) Reset()         { *m = CreatePodResponse{} }
+func (m *CreatePodResponse) String() string { return proto.CompactTextString(m) }
+func (*CreatePodResponse) ProtoMessage()    {}
+func (*CreatePodResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{1}
+}
+func (m *CreatePodResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CreatePodResponse.Unmarshal(m, b)
+}
+func (m *CreatePodResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CreatePodResponse.Marshal(b, m, deterministic)
+}
+func (dst *CreatePodResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CreatePodResponse.Merge(dst, src)
+}
+func (m *CreatePodResponse) XXX_Size() int {
+	return xxx_messageInfo_CreatePodResponse.Size(m)
+}
+func (m *CreatePodResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreatePodResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreatePodResponse proto.InternalMessageInfo
+
+type UpdatePodRequest struct {
+	// pod is a k8s.io.api.core.v1.Pod.
+	Pod                  []byte   `protobuf:"bytes,1,opt,name=pod,proto3" json:"pod,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpdatePodRequest) Reset()         { *m = UpdatePodRequest{} }
+func (m *UpdatePodRequest) String() string { return proto.CompactTextString(m) }
+func (*UpdatePodRequest) ProtoMessage()    {}
+func (*UpdatePodRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{2}
+}
+func (m *UpdatePodRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdatePodRequest.Unmarshal(m, b)
+}
+func (m *UpdatePodRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdatePodRequest.Marshal(b, m, deterministic)
+}
+func (dst *UpdatePodRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdatePodRequest.Merge(dst, src)
+}
+func (m *UpdatePodRequest) XXX_Size() int {
+	return xxx_messageInfo_UpdatePodRequest.Size(m)
+}
+func (m *UpdatePodRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdatePodRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdatePodRequest proto.InternalMessageInfo
+
+func (m *UpdatePodRequest) GetPod() []byte {
+	if m != nil {
+		return m.Pod
+	}
+	return nil
+}
+
+type UpdatePodResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpdatePodResponse) Reset()         { *m = UpdatePodResponse{} }
+func (m *UpdatePodResponse) String() string { return proto.CompactTextString(m) }
+func (*UpdatePodResponse) ProtoMessage()    {}
+func (*UpdatePodResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{3}
+}
+func (m *UpdatePodResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdatePodResponse.Unmarshal(m, b)
+}
+func (m *UpdatePodResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdatePodResponse.Marshal(b, m, deterministic)
+}
+func (dst *UpdatePodResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdatePodResponse.Merge(dst, src)
+}
+func (m *UpdatePodResponse) XXX_Size() int {
+	return xxx_messageInfo_UpdatePodResponse.Size(m)
+}
+func (m *UpdatePodResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdatePodResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdatePodResponse proto.InternalMessageInfo
+
+type DeletePodRequest struct {
+	// pod is a k8s.io.api.core.v1.Pod.
+	Pod                  []byte   `protobuf:"bytes,1,opt,name=pod,proto3" json:"pod,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeletePodRequest) Reset()         { *m = DeletePodRequest{} }
+func (m *DeletePodRequest) String() string { return proto.CompactTextString(m) }
+func (*DeletePodRequest) ProtoMessage()    {}
+func (*DeletePodRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{4}
+}
+func (m *DeletePodRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeletePodRequest.Unmarshal(m, b)
+}
+func (m *DeletePodRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeletePodRequest.Marshal(b, m, deterministic)
+}
+func (dst *DeletePodRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeletePodRequest.Merge(dst, src)
+}
+func (m *DeletePodRequest) XXX_Size() int {
+	return xxx_messageInfo_DeletePodRequest.Size(m)
+}
+func (m *DeletePodRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeletePodRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeletePodRequest proto.InternalMessageInfo
+
+func (m *DeletePodRequest) GetPod() []byte {
+	if m != nil {
+		return m.Pod
+	}
+	return nil
+}
+
+type DeletePodResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeletePodResponse) Reset()         { *m = DeletePodResponse{} }
+func (m *DeletePodResponse) String() string { return proto.CompactTextString(m) }
+func (*DeletePodResponse) ProtoMessage()    {}
+func (*DeletePodResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{5}
+}
+func (m *DeletePodResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeletePodResponse.Unmarshal(m, b)
+}
+func (m *DeletePodResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeletePodResponse.Marshal(b, m, deterministic)
+}
+func (dst *DeletePodResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeletePodResponse.Merge(dst, src)
+}
+func (m *DeletePodResponse) XXX_Size() int {
+	return xxx_messageInfo_DeletePodResponse.Size(m)
+}
+func (m *DeletePodResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeletePodResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeletePodResponse proto.InternalMessageInfo
+
+type GetPodRequest struct {
+	Namespace            string   `protobuf:"bytes,1,opt,name=namespace" json:"namespace,omitempty"`
+	Name                 string   `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetPodRequest) Reset()         { *m = GetPodRequest{} }
+func (m *GetPodRequest) String() string { return proto.CompactTextString(m) }
+func (*GetPodRequest) ProtoMessage()    {}
+func (*GetPodRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{6}
+}
+func (m *GetPodRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetPodRequest.Unmarshal(m, b)
+}
+func (m *GetPodRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetPodRequest.Marshal(b, m, deterministic)
+}
+func (dst *GetPodRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetPodRequest.Merge(dst, src)
+}
+func (m *GetPodRequest) XXX_Size() int {
+	return xxx_messageInfo_GetPodRequest.Size(m)
+}
+func (m *GetPodRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetPodRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetPodRequest proto.InternalMessageInfo
+
+func (m *GetPodRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *GetPodRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type GetPodResponse struct {
+	// pod is a k8s.io.api.core.v1.Pod. It is empty when the pod is not known to the provider.
+	Pod                  []byte   `protobuf:"bytes,1,opt,name=pod,proto3" json:"pod,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetPodResponse) Reset()         { *m = GetPodResponse{} }
+func (m *GetPodResponse) String() string { return proto.CompactTextString(m) }
+func (*GetPodResponse) ProtoMessage()    {}
+func (*GetPodResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{7}
+}
+func (m *GetPodResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetPodResponse.Unmarshal(m, b)
+}
+func (m *GetPodResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetPodResponse.Marshal(b, m, deterministic)
+}
+func (dst *GetPodResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetPodResponse.Merge(dst, src)
+}
+func (m *GetPodResponse) XXX_Size() int {
+	return xxx_messageInfo_GetPodResponse.Size(m)
+}
+func (m *GetPodResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetPodResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetPodResponse proto.InternalMessageInfo
+
+func (m *GetPodResponse) GetPod() []byte {
+	if m != nil {
+		return m.Pod
+	}
+	return nil
+}
+
+type GetPodStatusRequest struct {
+	Namespace            string   `protobuf:"bytes,1,opt,name=namespace" json:"namespace,omitempty"`
+	Name                 string   `protobuf:"bytes,2,opt,name=name" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetPodStatusRequest) Reset()         { *m = GetPodStatusRequest{} }
+func (m *GetPodStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*GetPodStatusRequest) ProtoMessage()    {}
+func (*GetPodStatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{8}
+}
+func (m *GetPodStatusRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetPodStatusRequest.Unmarshal(m, b)
+}
+func (m *GetPodStatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetPodStatusRequest.Marshal(b, m, deterministic)
+}
+func (dst *GetPodStatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetPodStatusRequest.Merge(dst, src)
+}
+func (m *GetPodStatusRequest) XXX_Size() int {
+	return xxx_messageInfo_GetPodStatusRequest.Size(m)
+}
+func (m *GetPodStatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetPodStatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetPodStatusRequest proto.InternalMessageInfo
+
+func (m *GetPodStatusRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *GetPodStatusRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+type GetPodStatusResponse struct {
+	// status is a k8s.io.api.core.v1.PodStatus. It is empty when the pod is not known to the provider.
+	Status               []byte   `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetPodStatusResponse) Reset()         { *m = GetPodStatusResponse{} }
+func (m *GetPodStatusResponse) String() string { return proto.CompactTextString(m) }
+func (*GetPodStatusResponse) ProtoMessage()    {}
+func (*GetPodStatusResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{9}
+}
+func (m *GetPodStatusResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetPodStatusResponse.Unmarshal(m, b)
+}
+func (m *GetPodStatusResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetPodStatusResponse.Marshal(b, m, deterministic)
+}
+func (dst *GetPodStatusResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetPodStatusResponse.Merge(dst, src)
+}
+func (m *GetPodStatusResponse) XXX_Size() int {
+	return xxx_messageInfo_GetPodStatusResponse.Size(m)
+}
+func (m *GetPodStatusResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetPodStatusResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetPodStatusResponse proto.InternalMessageInfo
+
+func (m *GetPodStatusResponse) GetStatus() []byte {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+type GetPodsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetPodsRequest) Reset()         { *m = GetPodsRequest{} }
+func (m *GetPodsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetPodsRequest) ProtoMessage()    {}
+func (*GetPodsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{10}
+}
+func (m *GetPodsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetPodsRequest.Unmarshal(m, b)
+}
+func (m *GetPodsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetPodsRequest.Marshal(b, m, deterministic)
+}
+func (dst *GetPodsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetPodsRequest.Merge(dst, src)
+}
+func (m *GetPodsRequest) XXX_Size() int {
+	return xxx_messageInfo_GetPodsRequest.Size(m)
+}
+func (m *GetPodsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetPodsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetPodsRequest proto.InternalMessageInfo
+
+type GetPodsResponse struct {
+	// pods are k8s.io.api.core.v1.Pod.
+	Pods                 [][]byte `protobuf:"bytes,1,rep,name=pods,proto3" json:"pods,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetPodsResponse) Reset()         { *m = GetPodsResponse{} }
+func (m *GetPodsResponse) String() string { return proto.CompactTextString(m) }
+func (*GetPodsResponse) ProtoMessage()    {}
+func (*GetPodsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{11}
+}
+func (m *GetPodsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetPodsResponse.Unmarshal(m, b)
+}
+func (m *GetPodsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetPodsResponse.Marshal(b, m, deterministic)
+}
+func (dst *GetPodsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetPodsResponse.Merge(dst, src)
+}
+func (m *GetPodsResponse) XXX_Size() int {
+	return xxx_messageInfo_GetPodsResponse.Size(m)
+}
+func (m *GetPodsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetPodsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetPodsResponse proto.InternalMessageInfo
+
+func (m *GetPodsResponse) GetPods() [][]byte {
+	if m != nil {
+		return m.Pods
+	}
+	return nil
+}
+
+type GetContainerLogsRequest struct {
+	Namespace     string `protobuf:"bytes,1,opt,name=namespace" json:"namespace,omitempty"`
+	PodName       string `protobuf:"bytes,2,opt,name=pod_name,json=podName" json:"pod_name,omitempty"`
+	ContainerName string `protobuf:"bytes,3,opt,name=container_name,json=containerName" json:"container_name,omitempty"`
+	// tail is the number of lines from the end of the logs to return. All lines are returned when it is zero.
+	Tail int64 `protobuf:"varint,4,opt,name=tail" json:"tail,omitempty"`
+	// limit_bytes is the maximum number of bytes to return. There is no limit when it is zero.
+	LimitBytes   int64 `protobuf:"varint,5,opt,name=limit_bytes,json=limitBytes" json:"limit_bytes,omitempty"`
+	Timestamps   bool  `protobuf:"varint,6,opt,name=timestamps" json:"timestamps,omitempty"`
+	Follow       bool  `protobuf:"varint,7,opt,name=follow" json:"follow,omitempty"`
+	Previous     bool  `protobuf:"varint,8,opt,name=previous" json:"previous,omitempty"`
+	SinceSeconds int64 `protobuf:"varint,9,opt,name=since_seconds,json=sinceSeconds" json:"since_seconds,omitempty"`
+	// since_time is the number of nanoseconds since the Unix epoch from which logs are returned, if not zero.
+	SinceTime            int64    `protobuf:"varint,10,opt,name=since_time,json=sinceTime" json:"since_time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetContainerLogsRequest) Reset()         { *m = GetContainerLogsRequest{} }
+func (m *GetContainerLogsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetContainerLogsRequest) ProtoMessage()    {}
+func (*GetContainerLogsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{12}
+}
+func (m *GetContainerLogsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetContainerLogsRequest.Unmarshal(m, b)
+}
+func (m *GetContainerLogsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetContainerLogsRequest.Marshal(b, m, deterministic)
+}
+func (dst *GetContainerLogsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetContainerLogsRequest.Merge(dst, src)
+}
+func (m *GetContainerLogsRequest) XXX_Size() int {
+	return xxx_messageInfo_GetContainerLogsRequest.Size(m)
+}
+func (m *GetContainerLogsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetContainerLogsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetContainerLogsRequest proto.InternalMessageInfo
+
+func (m *GetContainerLogsRequest) GetNamespace() string {
+	if m != nil {
+		return m.Namespace
+	}
+	return ""
+}
+
+func (m *GetContainerLogsRequest) GetPodName() string {
+	if m != nil {
+		return m.PodName
+	}
+	return ""
+}
+
+func (m *GetContainerLogsRequest) GetContainerName() string {
+	if m != nil {
+		return m.ContainerName
+	}
+	return ""
+}
+
+func (m *GetContainerLogsRequest) GetTail() int64 {
+	if m != nil {
+		return m.Tail
+	}
+	return 0
+}
+
+func (m *GetContainerLogsRequest) GetLimitBytes() int64 {
+	if m != nil {
+		return m.LimitBytes
+	}
+	return 0
+}
+
+func (m *GetContainerLogsRequest) GetTimestamps() bool {
+	if m != nil {
+		return m.Timestamps
+	}
+	return false
+}
+
+func (m *GetContainerLogsRequest) GetFollow() bool {
+	if m != nil {
+		return m.Follow
+	}
+	return false
+}
+
+func (m *GetContainerLogsRequest) GetPrevious() bool {
+	if m != nil {
+		return m.Previous
+	}
+	return false
+}
+
+func (m *GetContainerLogsRequest) GetSinceSeconds() int64 {
+	if m != nil {
+		return m.SinceSeconds
+	}
+	return 0
+}
+
+func (m *GetContainerLogsRequest) GetSinceTime() int64 {
+	if m != nil {
+		return m.SinceTime
+	}
+	return 0
+}
+
+type GetContainerLogsResponse struct {
+	Data                 []byte   `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetContainerLogsResponse) Reset()         { *m = GetContainerLogsResponse{} }
+func (m *GetContainerLogsResponse) String() string { return proto.CompactTextString(m) }
+func (*GetContainerLogsResponse) ProtoMessage()    {}
+func (*GetContainerLogsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{13}
+}
+func (m *GetContainerLogsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetContainerLogsResponse.Unmarshal(m, b)
+}
+func (m *GetContainerLogsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetContainerLogsResponse.Marshal(b, m, deterministic)
+}
+func (dst *GetContainerLogsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetContainerLogsResponse.Merge(dst, src)
+}
+func (m *GetContainerLogsResponse) XXX_Size() int {
+	return xxx_messageInfo_GetContainerLogsResponse.Size(m)
+}
+func (m *GetContainerLogsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetContainerLogsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetContainerLogsResponse proto.InternalMessageInfo
+
+func (m *GetContainerLogsResponse) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+type ExecStart struct {
+	Name      string   `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Uid       string   `protobuf:"bytes,2,opt,name=uid" json:"uid,omitempty"`
+	Container string   `protobuf:"bytes,3,opt,name=container" json:"container,omitempty"`
+	Command   []string `protobuf:"bytes,4,rep,name=command" json:"command,omitempty"`
+	Tty       bool     `protobuf:"varint,5,opt,name=tty" json:"tty,omitempty"`
+	// stdin is whether the command reads its input from the following requests.
+	Stdin bool `protobuf:"varint,6,opt,name=stdin" json:"stdin,omitempty"`
+	// timeout_seconds is the maximum duration of the command. There is no limit when it is zero.
+	TimeoutSeconds       int64    `protobuf:"varint,7,opt,name=timeout_seconds,json=timeoutSeconds" json:"timeout_seconds,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExecStart) Reset()         { *m = ExecStart{} }
+func (m *ExecStart) String() string { return proto.CompactTextString(m) }
+func (*ExecStart) ProtoMessage()    {}
+func (*ExecStart) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{14}
+}
+func (m *ExecStart) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExecStart.Unmarshal(m, b)
+}
+func (m *ExecStart) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExecStart.Marshal(b, m, deterministic)
+}
+func (dst *ExecStart) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExecStart.Merge(dst, src)
+}
+func (m *ExecStart) XXX_Size() int {
+	return xxx_messageInfo_ExecStart.Size(m)
+}
+func (m *ExecStart) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExecStart.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExecStart proto.InternalMessageInfo
+
+func (m *ExecStart) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ExecStart) GetUid() string {
+	if m != nil {
+		return m.Uid
+	}
+	return ""
+}
+
+func (m *ExecStart) GetContainer() string {
+	if m != nil {
+		return m.Container
+	}
+	return ""
+}
+
+func (m *ExecStart) GetCommand() []string {
+	if m != nil {
+		return m.Command
+	}
+	return nil
+}
+
+func (m *ExecStart) GetTty() bool {
+	if m != nil {
+		return m.Tty
+	}
+	return false
+}
+
+func (m *ExecStart) GetStdin() bool {
+	if m != nil {
+		return m.Stdin
+	}
+	return false
+}
+
+func (m *ExecStart) GetTimeoutSeconds() int64 {
+	if m != nil {
+		return m.TimeoutSeconds
+	}
+	return 0
+}
+
+type TerminalSize struct {
+	Width                uint32   `protobuf:"varint,1,opt,name=width" json:"width,omitempty"`
+	Height               uint32   `protobuf:"varint,2,opt,name=height" json:"height,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TerminalSize) Reset()         { *m = TerminalSize{} }
+func (m *TerminalSize) String() string { return proto.CompactTextString(m) }
+func (*TerminalSize) ProtoMessage()    {}
+func (*TerminalSize) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{15}
+}
+func (m *TerminalSize) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TerminalSize.Unmarshal(m, b)
+}
+func (m *TerminalSize) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TerminalSize.Marshal(b, m, deterministic)
+}
+func (dst *TerminalSize) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TerminalSize.Merge(dst, src)
+}
+func (m *TerminalSize) XXX_Size() int {
+	return xxx_messageInfo_TerminalSize.Size(m)
+}
+func (m *TerminalSize) XXX_DiscardUnknown() {
+	xxx_messageInfo_TerminalSize.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TerminalSize proto.InternalMessageInfo
+
+func (m *TerminalSize) GetWidth() uint32 {
+	if m != nil {
+		return m.Width
+	}
+	return 0
+}
+
+func (m *TerminalSize) GetHeight() uint32 {
+	if m != nil {
+		return m.Height
+	}
+	return 0
+}
+
+type ExecInContainerRequest struct {
+	// start is only set in the first request.
+	Start *ExecStart `protobuf:"bytes,1,opt,name=start" json:"start,omitempty"`
+	Stdin []byte     `protobuf:"bytes,2,opt,name=stdin,proto3" json:"stdin,omitempty"`
+	// close_stdin is set once the input of the command has been fully sent.
+	CloseStdin           bool          `protobuf:"varint,3,opt,name=close_stdin,json=closeStdin" json:"close_stdin,omitempty"`
+	Resize               *TerminalSize `protobuf:"bytes,4,opt,name=resize" json:"resize,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *ExecInContainerRequest) Reset()         { *m = ExecInContainerRequest{} }
+func (m *ExecInContainerRequest) String() string { return proto.CompactTextString(m) }
+func (*ExecInContainerRequest) ProtoMessage()    {}
+func (*ExecInContainerRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{16}
+}
+func (m *ExecInContainerRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExecInContainerRequest.Unmarshal(m, b)
+}
+func (m *ExecInContainerRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExecInContainerRequest.Marshal(b, m, deterministic)
+}
+func (dst *ExecInContainerRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExecInContainerRequest.Merge(dst, src)
+}
+func (m *ExecInContainerRequest) XXX_Size() int {
+	return xxx_messageInfo_ExecInContainerRequest.Size(m)
+}
+func (m *ExecInContainerRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExecInContainerRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExecInContainerRequest proto.InternalMessageInfo
+
+func (m *ExecInContainerRequest) GetStart() *ExecStart {
+	if m != nil {
+		return m.Start
+	}
+	return nil
+}
+
+func (m *ExecInContainerRequest) GetStdin() []byte {
+	if m != nil {
+		return m.Stdin
+	}
+	return nil
+}
+
+func (m *ExecInContainerRequest) GetCloseStdin() bool {
+	if m != nil {
+		return m.CloseStdin
+	}
+	return false
+}
+
+func (m *ExecInContainerRequest) GetResize() *TerminalSize {
+	if m != nil {
+		return m.Resize
+	}
+	return nil
+}
+
+type ExecInContainerResponse struct {
+	Stdout []byte `protobuf:"bytes,1,opt,name=stdout,proto3" json:"stdout,omitempty"`
+	Stderr []byte `protobuf:"bytes,2,opt,name=stderr,proto3" json:"stderr,omitempty"`
+	// exit_code is only set in the last response, when the command exited with a non-zero code.
+	ExitCode             int32    `protobuf:"varint,3,opt,name=exit_code,json=exitCode" json:"exit_code,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExecInContainerResponse) Reset()         { *m = ExecInContainerResponse{} }
+func (m *ExecInContainerResponse) String() string { return proto.CompactTextString(m) }
+func (*ExecInContainerResponse) ProtoMessage()    {}
+func (*ExecInContainerResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{17}
+}
+func (m *ExecInContainerResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExecInContainerResponse.Unmarshal(m, b)
+}
+func (m *ExecInContainerResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExecInContainerResponse.Marshal(b, m, deterministic)
+}
+func (dst *ExecInContainerResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExecInContainerResponse.Merge(dst, src)
+}
+func (m *ExecInContainerResponse) XXX_Size() int {
+	return xxx_messageInfo_ExecInContainerResponse.Size(m)
+}
+func (m *ExecInContainerResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExecInContainerResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExecInContainerResponse proto.InternalMessageInfo
+
+func (m *ExecInContainerResponse) GetStdout() []byte {
+	if m != nil {
+		return m.Stdout
+	}
+	return nil
+}
+
+func (m *ExecInContainerResponse) GetStderr() []byte {
+	if m != nil {
+		return m.Stderr
+	}
+	return nil
+}
+
+func (m *ExecInContainerResponse) GetExitCode() int32 {
+	if m != nil {
+		return m.ExitCode
+	}
+	return 0
+}
+
+type CapacityRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CapacityRequest) Reset()         { *m = CapacityRequest{} }
+func (m *CapacityRequest) String() string { return proto.CompactTextString(m) }
+func (*CapacityRequest) ProtoMessage()    {}
+func (*CapacityRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{18}
+}
+func (m *CapacityRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CapacityRequest.Unmarshal(m, b)
+}
+func (m *CapacityRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CapacityRequest.Marshal(b, m, deterministic)
+}
+func (dst *CapacityRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CapacityRequest.Merge(dst, src)
+}
+func (m *CapacityRequest) XXX_Size() int {
+	return xxx_messageInfo_CapacityRequest.Size(m)
+}
+func (m *CapacityRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CapacityRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CapacityRequest proto.InternalMessageInfo
+
+type CapacityResponse struct {
+	// capacity maps resource names to quantities, such as "cpu" to "20".
+	Capacity             map[string]string `protobuf:"bytes,1,rep,name=capacity" json:"capacity,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *CapacityResponse) Reset()         { *m = CapacityResponse{} }
+func (m *CapacityResponse) String() string { return proto.CompactTextString(m) }
+func (*CapacityResponse) ProtoMessage()    {}
+func (*CapacityResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{19}
+}
+func (m *CapacityResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CapacityResponse.Unmarshal(m, b)
+}
+func (m *CapacityResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CapacityResponse.Marshal(b, m, deterministic)
+}
+func (dst *CapacityResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CapacityResponse.Merge(dst, src)
+}
+func (m *CapacityResponse) XXX_Size() int {
+	return xxx_messageInfo_CapacityResponse.Size(m)
+}
+func (m *CapacityResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CapacityResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CapacityResponse proto.InternalMessageInfo
+
+func (m *CapacityResponse) GetCapacity() map[string]string {
+	if m != nil {
+		return m.Capacity
+	}
+	return nil
+}
+
+type NodeConditionsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeConditionsRequest) Reset()         { *m = NodeConditionsRequest{} }
+func (m *NodeConditionsRequest) String() string { return proto.CompactTextString(m) }
+func (*NodeConditionsRequest) ProtoMessage()    {}
+func (*NodeConditionsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{20}
+}
+func (m *NodeConditionsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeConditionsRequest.Unmarshal(m, b)
+}
+func (m *NodeConditionsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeConditionsRequest.Marshal(b, m, deterministic)
+}
+func (dst *NodeConditionsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeConditionsRequest.Merge(dst, src)
+}
+func (m *NodeConditionsRequest) XXX_Size() int {
+	return xxx_messageInfo_NodeConditionsRequest.Size(m)
+}
+func (m *NodeConditionsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeConditionsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeConditionsRequest proto.InternalMessageInfo
+
+type NodeConditionsResponse struct {
+	// conditions are k8s.io.api.core.v1.NodeCondition.
+	Conditions           [][]byte `protobuf:"bytes,1,rep,name=conditions,proto3" json:"conditions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeConditionsResponse) Reset()         { *m = NodeConditionsResponse{} }
+func (m *NodeConditionsResponse) String() string { return proto.CompactTextString(m) }
+func (*NodeConditionsResponse) ProtoMessage()    {}
+func (*NodeConditionsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{21}
+}
+func (m *NodeConditionsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeConditionsResponse.Unmarshal(m, b)
+}
+func (m *NodeConditionsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeConditionsResponse.Marshal(b, m, deterministic)
+}
+func (dst *NodeConditionsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeConditionsResponse.Merge(dst, src)
+}
+func (m *NodeConditionsResponse) XXX_Size() int {
+	return xxx_messageInfo_NodeConditionsResponse.Size(m)
+}
+func (m *NodeConditionsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeConditionsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeConditionsResponse proto.InternalMessageInfo
+
+func (m *NodeConditionsResponse) GetConditions() [][]byte {
+	if m != nil {
+		return m.Conditions
+	}
+	return nil
+}
+
+type NodeAddressesRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeAddressesRequest) Reset()         { *m = NodeAddressesRequest{} }
+func (m *NodeAddressesRequest) String() string { return proto.CompactTextString(m) }
+func (*NodeAddressesRequest) ProtoMessage()    {}
+func (*NodeAddressesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{22}
+}
+func (m *NodeAddressesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeAddressesRequest.Unmarshal(m, b)
+}
+func (m *NodeAddressesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeAddressesRequest.Marshal(b, m, deterministic)
+}
+func (dst *NodeAddressesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeAddressesRequest.Merge(dst, src)
+}
+func (m *NodeAddressesRequest) XXX_Size() int {
+	return xxx_messageInfo_NodeAddressesRequest.Size(m)
+}
+func (m *NodeAddressesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeAddressesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeAddressesRequest proto.InternalMessageInfo
+
+type NodeAddressesResponse struct {
+	// addresses are k8s.io.api.core.v1.NodeAddress.
+	Addresses            [][]byte `protobuf:"bytes,1,rep,name=addresses,proto3" json:"addresses,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeAddressesResponse) Reset()         { *m = NodeAddressesResponse{} }
+func (m *NodeAddressesResponse) String() string { return proto.CompactTextString(m) }
+func (*NodeAddressesResponse) ProtoMessage()    {}
+func (*NodeAddressesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{23}
+}
+func (m *NodeAddressesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeAddressesResponse.Unmarshal(m, b)
+}
+func (m *NodeAddressesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeAddressesResponse.Marshal(b, m, deterministic)
+}
+func (dst *NodeAddressesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeAddressesResponse.Merge(dst, src)
+}
+func (m *NodeAddressesResponse) XXX_Size() int {
+	return xxx_messageInfo_NodeAddressesResponse.Size(m)
+}
+func (m *NodeAddressesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeAddressesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeAddressesResponse proto.InternalMessageInfo
+
+func (m *NodeAddressesResponse) GetAddresses() [][]byte {
+	if m != nil {
+		return m.Addresses
+	}
+	return nil
+}
+
+type NodeDaemonEndpointsRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeDaemonEndpointsRequest) Reset()         { *m = NodeDaemonEndpointsRequest{} }
+func (m *NodeDaemonEndpointsRequest) String() string { return proto.CompactTextString(m) }
+func (*NodeDaemonEndpointsRequest) ProtoMessage()    {}
+func (*NodeDaemonEndpointsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{24}
+}
+func (m *NodeDaemonEndpointsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeDaemonEndpointsRequest.Unmarshal(m, b)
+}
+func (m *NodeDaemonEndpointsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeDaemonEndpointsRequest.Marshal(b, m, deterministic)
+}
+func (dst *NodeDaemonEndpointsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeDaemonEndpointsRequest.Merge(dst, src)
+}
+func (m *NodeDaemonEndpointsRequest) XXX_Size() int {
+	return xxx_messageInfo_NodeDaemonEndpointsRequest.Size(m)
+}
+func (m *NodeDaemonEndpointsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeDaemonEndpointsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeDaemonEndpointsRequest proto.InternalMessageInfo
+
+type NodeDaemonEndpointsResponse struct {
+	// endpoints is a k8s.io.api.core.v1.NodeDaemonEndpoints.
+	Endpoints            []byte   `protobuf:"bytes,1,opt,name=endpoints,proto3" json:"endpoints,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *NodeDaemonEndpointsResponse) Reset()         { *m = NodeDaemonEndpointsResponse{} }
+func (m *NodeDaemonEndpointsResponse) String() string { return proto.CompactTextString(m) }
+func (*NodeDaemonEndpointsResponse) ProtoMessage()    {}
+func (*NodeDaemonEndpointsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{25}
+}
+func (m *NodeDaemonEndpointsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_NodeDaemonEndpointsResponse.Unmarshal(m, b)
+}
+func (m *NodeDaemonEndpointsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_NodeDaemonEndpointsResponse.Marshal(b, m, deterministic)
+}
+func (dst *NodeDaemonEndpointsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeDaemonEndpointsResponse.Merge(dst, src)
+}
+func (m *NodeDaemonEndpointsResponse) XXX_Size() int {
+	return xxx_messageInfo_NodeDaemonEndpointsResponse.Size(m)
+}
+func (m *NodeDaemonEndpointsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeDaemonEndpointsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_NodeDaemonEndpointsResponse proto.InternalMessageInfo
+
+func (m *NodeDaemonEndpointsResponse) GetEndpoints() []byte {
+	if m != nil {
+		return m.Endpoints
+	}
+	return nil
+}
+
+type OperatingSystemRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OperatingSystemRequest) Reset()         { *m = OperatingSystemRequest{} }
+func (m *OperatingSystemRequest) String() string { return proto.CompactTextString(m) }
+func (*OperatingSystemRequest) ProtoMessage()    {}
+func (*OperatingSystemRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{26}
+}
+func (m *OperatingSystemRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OperatingSystemRequest.Unmarshal(m, b)
+}
+func (m *OperatingSystemRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OperatingSystemRequest.Marshal(b, m, deterministic)
+}
+func (dst *OperatingSystemRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OperatingSystemRequest.Merge(dst, src)
+}
+func (m *OperatingSystemRequest) XXX_Size() int {
+	return xxx_messageInfo_OperatingSystemRequest.Size(m)
+}
+func (m *OperatingSystemRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_OperatingSystemRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OperatingSystemRequest proto.InternalMessageInfo
+
+type OperatingSystemResponse struct {
+	OperatingSystem      string   `protobuf:"bytes,1,opt,name=operating_system,json=operatingSystem" json:"operating_system,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OperatingSystemResponse) Reset()         { *m = OperatingSystemResponse{} }
+func (m *OperatingSystemResponse) String() string { return proto.CompactTextString(m) }
+func (*OperatingSystemResponse) ProtoMessage()    {}
+func (*OperatingSystemResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{27}
+}
+func (m *OperatingSystemResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OperatingSystemResponse.Unmarshal(m, b)
+}
+func (m *OperatingSystemResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OperatingSystemResponse.Marshal(b, m, deterministic)
+}
+func (dst *OperatingSystemResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OperatingSystemResponse.Merge(dst, src)
+}
+func (m *OperatingSystemResponse) XXX_Size() int {
+	return xxx_messageInfo_OperatingSystemResponse.Size(m)
+}
+func (m *OperatingSystemResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_OperatingSystemResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OperatingSystemResponse proto.InternalMessageInfo
+
+func (m *OperatingSystemResponse) GetOperatingSystem() string {
+	if m != nil {
+		return m.OperatingSystem
+	}
+	return ""
+}
+
+type GetStatsSummaryRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetStatsSummaryRequest) Reset()         { *m = GetStatsSummaryRequest{} }
+func (m *GetStatsSummaryRequest) String() string { return proto.CompactTextString(m) }
+func (*GetStatsSummaryRequest) ProtoMessage()    {}
+func (*GetStatsSummaryRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{28}
+}
+func (m *GetStatsSummaryRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetStatsSummaryRequest.Unmarshal(m, b)
+}
+func (m *GetStatsSummaryRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetStatsSummaryRequest.Marshal(b, m, deterministic)
+}
+func (dst *GetStatsSummaryRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetStatsSummaryRequest.Merge(dst, src)
+}
+func (m *GetStatsSummaryRequest) XXX_Size() int {
+	return xxx_messageInfo_GetStatsSummaryRequest.Size(m)
+}
+func (m *GetStatsSummaryRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetStatsSummaryRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetStatsSummaryRequest proto.InternalMessageInfo
+
+type GetStatsSummaryResponse struct {
+	// summary is a JSON encoded k8s.io.kubernetes.pkg.kubelet.apis.stats.v1alpha1.Summary.
+	Summary              []byte   `protobuf:"bytes,1,opt,name=summary,proto3" json:"summary,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetStatsSummaryResponse) Reset()         { *m = GetStatsSummaryResponse{} }
+func (m *GetStatsSummaryResponse) String() string { return proto.CompactTextString(m) }
+func (*GetStatsSummaryResponse) ProtoMessage()    {}
+func (*GetStatsSummaryResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_provider_2550ac5ebcb6629e, []int{29}
+}
+func (m *GetStatsSummaryResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetStatsSummaryResponse.Unmarshal(m, b)
+}
+func (m *GetStatsSummaryResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetStatsSummaryResponse.Marshal(b, m, deterministic)
+}
+func (dst *GetStatsSummaryResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetStatsSummaryResponse.Merge(dst, src)
+}
+func (m *GetStatsSummaryResponse) XXX_Size() int {
+	return xxx_messageInfo_GetStatsSummaryResponse.Size(m)
+}
+func (m *GetStatsSummaryResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetStatsSummaryResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetStatsSummaryResponse proto.InternalMessageInfo
+
+func (m *GetStatsSummaryResponse) GetSummary() []byte {
+	if m != nil {
+		return m.Summary
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*CreatePodRequest)(nil), "virtualkubelet.plugin.v1.CreatePodRequest")
+	proto.RegisterType((*CreatePodResponse)(nil), "virtualkubelet.plugin.v1.CreatePodResponse")
+	proto.RegisterType((*UpdatePodRequest)(nil), "virtualkubelet.plugin.v1.UpdatePodRequest")
+	proto.RegisterType((*UpdatePodResponse)(nil), "virtualkubelet.plugin.v1.UpdatePodResponse")
+	proto.RegisterType((*DeletePodRequest)(nil), "virtualkubelet.plugin.v1.DeletePodRequest")
+	proto.RegisterType((*DeletePodResponse)(nil), "virtualkubelet.plugin.v1.DeletePodResponse")
+	proto.RegisterType((*GetPodRequest)(nil), "virtualkubelet.plugin.v1.GetPodRequest")
+	proto.RegisterType((*GetPodResponse)(nil), "virtualkubelet.plugin.v1.GetPodResponse")
+	proto.RegisterType((*GetPodStatusRequest)(nil), "virtualkubelet.plugin.v1.GetPodStatusRequest")
+	proto.RegisterType((*GetPodStatusResponse)(nil), "virtualkubelet.plugin.v1.GetPodStatusResponse")
+	proto.RegisterType((*GetPodsRequest)(nil), "virtualkubelet.plugin.v1.GetPodsRequest")
+	proto.RegisterType((*GetPodsResponse)(nil), "virtualkubelet.plugin.v1.GetPodsResponse")
+	proto.RegisterType((*GetContainerLogsRequest)(nil), "virtualkubelet.plugin.v1.GetContainerLogsRequest")
+	proto.RegisterType((*GetContainerLogsResponse)(nil), "virtualkubelet.plugin.v1.GetContainerLogsResponse")
+	proto.RegisterType((*ExecStart)(nil), "virtualkubelet.plugin.v1.ExecStart")
+	proto.RegisterType((*TerminalSize)(nil), "virtualkubelet.plugin.v1.TerminalSize")
+	proto.RegisterType((*ExecInContainerRequest)(nil), "virtualkubelet.plugin.v1.ExecInContainerRequest")
+	proto.RegisterType((*ExecInContainerResponse)(nil), "virtualkubelet.plugin.v1.ExecInContainerResponse")
+	proto.RegisterType((*CapacityRequest)(nil), "virtualkubelet.plugin.v1.CapacityRequest")
+	proto.RegisterType((*CapacityResponse)(nil), "virtualkubelet.plugin.v1.CapacityResponse")
+	proto.RegisterMapType((map[string]string)(nil), "virtualkubelet.plugin.v1.CapacityResponse.CapacityEntry")
+	proto.RegisterType((*NodeConditionsRequest)(nil), "virtualkubelet.plugin.v1.NodeConditionsRequest")
+	proto.RegisterType((*NodeConditionsResponse)(nil), "virtualkubelet.plugin.v1.NodeConditionsResponse")
+	proto.RegisterType((*NodeAddressesRequest)(nil), "virtualkubelet.plugin.v1.NodeAddressesRequest")
+	proto.RegisterType((*NodeAddressesResponse)(nil), "virtualkubelet.plugin.v1.NodeAddressesResponse")
+	proto.RegisterType((*NodeDaemonEndpointsRequest)(nil), "virtualkubelet.plugin.v1.NodeDaemonEndpointsRequest")
+	proto.RegisterType((*NodeDaemonEndpointsResponse)(nil), "virtualkubelet.plugin.v1.NodeDaemonEndpointsResponse")
+	proto.RegisterType((*OperatingSystemRequest)(nil), "virtualkubelet.plugin.v1.OperatingSystemRequest")
+	proto.RegisterType((*OperatingSystemResponse)(nil), "virtualkubelet.plugin.v1.OperatingSystemResponse")
+	proto.RegisterType((*GetStatsSummaryRequest)(nil), "virtualkubelet.plugin.v1.GetStatsSummaryRequest")
+	proto.RegisterType((*GetStatsSummaryResponse)(nil), "virtualkubelet.plugin.v1.GetStatsSummaryResponse")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for Provider service
+
+type ProviderClient interface {
+	CreatePod(ctx context.Context, in *CreatePodRequest, opts ...grpc.CallOption) (*CreatePodResponse, error)
+	UpdatePod(ctx context.Context, in *UpdatePodRequest, opts ...grpc.CallOption) (*UpdatePodResponse, error)
+	DeletePod(ctx context.Context, in *DeletePodRequest, opts ...grpc.CallOption) (*DeletePodResponse, error)
+	GetPod(ctx context.Context, in *GetPodRequest, opts ...grpc.CallOption) (*GetPodResponse, error)
+	GetPodStatus(ctx context.Context, in *GetPodStatusRequest, opts ...grpc.CallOption) (*GetPodStatusResponse, error)
+	GetPods(ctx context.Context, in *GetPodsRequest, opts ...grpc.CallOption) (*GetPodsResponse, error)
+	// GetContainerLogs streams the logs of a container, until they end or, when following them, until the call is cancelled.
+	GetContainerLogs(ctx context.Context, in *GetContainerLogsRequest, opts ...grpc.CallOption) (Provider_GetContainerLogsClient, error)
+	// ExecInContainer runs a command in a container. The first request must hold the command to run, and the following
+	// ones its input and terminal size changes. The call ends once the command exits.
+	ExecInContainer(ctx context.Context, opts ...grpc.CallOption) (Provider_ExecInContainerClient, error)
+	Capacity(ctx context.Context, in *CapacityRequest, opts ...grpc.CallOption) (*CapacityResponse, error)
+	NodeConditions(ctx context.Context, in *NodeConditionsRequest, opts ...grpc.CallOption) (*NodeConditionsResponse, error)
+	NodeAddresses(ctx context.Context, in *NodeAddressesRequest, opts ...grpc.CallOption) (*NodeAddressesResponse, error)
+	NodeDaemonEndpoints(ctx context.Context, in *NodeDaemonEndpointsRequest, opts ...grpc.CallOption) (*NodeDaemonEndpointsResponse, error)
+	OperatingSystem(ctx context.Context, in *OperatingSystemRequest, opts ...grpc.CallOption) (*OperatingSystemResponse, error)
+	GetStatsSummary(ctx context.Context, in *GetStatsSummaryRequest, opts ...grpc.CallOption) (*GetStatsSummaryResponse, error)
+}
+
+type providerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewProviderClient(cc *grpc.ClientConn) ProviderClient {
+	return &providerClient{cc}
+}
+
+func (c *providerClient) CreatePod(ctx context.Context, in *CreatePodRequest, opts ...grpc.CallOption) (*CreatePodResponse, error) {
+	out := new(CreatePodResponse)
+	err := grpc.Invoke(ctx, "/virtualkubelet.plugin.v1.Provider/CreatePod", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) UpdatePod(ctx context.Context, in *UpdatePodRequest, opts ...grpc.CallOption) (*UpdatePodResponse, error) {
+	out := new(UpdatePodResponse)
+	err := grpc.Invoke(ctx, "/virtualkubelet.plugin.v1.Provider/UpdatePod", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) DeletePod(ctx context.Context, in *DeletePodRequest, opts ...grpc.CallOption) (*DeletePodResponse, error) {
+	out := new(DeletePodResponse)
+	err := grpc.Invoke(ctx, "/virtualkubelet.plugin.v1.Provider/DeletePod", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) GetPod(ctx context.Context, in *GetPodRequest, opts ...grpc.CallOption) (*GetPodResponse, error) {
+	out := new(GetPodResponse)
+	err := grpc.Invoke(ctx, "/virtualkubelet.plugin.v1.Provider/GetPod", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) GetPodStatus(ctx context.Context, in *GetPodStatusRequest, opts ...grpc.CallOption) (*GetPodStatusResponse, error) {
+	out := new(GetPodStatusResponse)
+	err := grpc.Invoke(ctx, "/virtualkubelet.plugin.v1.Provider/GetPodStatus", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) GetPods(ctx context.Context, in *GetPodsRequest, opts ...grpc.CallOption) (*GetPodsResponse, error) {
+	out := new(GetPodsResponse)
+	err := grpc.Invoke(ctx, "/virtualkubelet.plugin.v1.Provider/GetPods", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) GetContainerLogs(ctx context.Context, in *GetContainerLogsRequest, opts ...grpc.CallOption) (Provider_GetContainerLogsClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Provider_serviceDesc.Streams[0], c.cc, "/virtualkubelet.plugin.v1.Provider/GetContainerLogs", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &providerGetContainerLogsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Provider_GetContainerLogsClient interface {
+	Recv() (*GetContainerLogsResponse, error)
+	grpc.ClientStream
+}
+
+type providerGetContainerLogsClient struct {
+	grpc.ClientStream
+}
+
+func (x *providerGetContainerLogsClient) Recv() (*GetContainerLogsResponse, error) {
+	m := new(GetContainerLogsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *providerClient) ExecInContainer(ctx context.Context, opts ...grpc.CallOption) (Provider_ExecInContainerClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Provider_serviceDesc.Streams[1], c.cc, "/virtualkubelet.plugin.v1.Provider/ExecInContainer", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &providerExecInContainerClient{stream}
+	return x, nil
+}
+
+type Provider_ExecInContainerClient interface {
+	Send(*ExecInContainerRequest) error
+	Recv() (*ExecInContainerResponse, error)
+	grpc.ClientStream
+}
+
+type providerExecInContainerClient struct {
+	grpc.ClientStream
+}
+
+func (x *providerExecInContainerClient) Send(m *ExecInContainerRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *providerExecInContainerClient) Recv() (*ExecInContainerResponse, error) {
+	m := new(ExecInContainerResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *providerClient) Capacity(ctx context.Context, in *CapacityRequest, opts ...grpc.CallOption) (*CapacityResponse, error) {
+	out := new(CapacityResponse)
+	err := grpc.Invoke(ctx, "/virtualkubelet.plugin.v1.Provider/Capacity", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) NodeConditions(ctx context.Context, in *NodeConditionsRequest, opts ...grpc.CallOption) (*NodeConditionsResponse, error) {
+	out := new(NodeConditionsResponse)
+	err := grpc.Invoke(ctx, "/virtualkubelet.plugin.v1.Provider/NodeConditions", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) NodeAddresses(ctx context.Context, in *NodeAddressesRequest, opts ...grpc.CallOption) (*NodeAddressesResponse, error) {
+	out := new(NodeAddressesResponse)
+	err := grpc.Invoke(ctx, "/virtualkubelet.plugin.v1.Provider/NodeAddresses", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) NodeDaemonEndpoints(ctx context.Context, in *NodeDaemonEndpointsRequest, opts ...grpc.CallOption) (*NodeDaemonEndpointsResponse, error) {
+	out := new(NodeDaemonEndpointsResponse)
+	err := grpc.Invoke(ctx, "/virtualkubelet.plugin.v1.Provider/NodeDaemonEndpoints", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) OperatingSystem(ctx context.Context, in *OperatingSystemRequest, opts ...grpc.CallOption) (*OperatingSystemResponse, error) {
+	out := new(OperatingSystemResponse)
+	err := grpc.Invoke(ctx, "/virtualkubelet.plugin.v1.Provider/OperatingSystem", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) GetStatsSummary(ctx context.Context, in *GetStatsSummaryRequest, opts ...grpc.CallOption) (*GetStatsSummaryResponse, error) {
+	out := new(GetStatsSummaryResponse)
+	err := grpc.Invoke(ctx, "/virtualkubelet.plugin.v1.Provider/GetStatsSummary", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Provider service
+
+type ProviderServer interface {
+	CreatePod(context.Context, *CreatePodRequest) (*CreatePodResponse, error)
+	UpdatePod(context.Context, *UpdatePodRequest) (*UpdatePodResponse, error)
+	DeletePod(context.Context, *DeletePodRequest) (*DeletePodResponse, error)
+	GetPod(context.Context, *GetPodRequest) (*GetPodResponse, error)
+	GetPodStatus(context.Context, *GetPodStatusRequest) (*GetPodStatusResponse, error)
+	GetPods(context.Context, *GetPodsRequest) (*GetPodsResponse, error)
+	// GetContainerLogs streams the logs of a container, until they end or, when following them, until the call is cancelled.
+	GetContainerLogs(*GetContainerLogsRequest, Provider_GetContainerLogsServer) error
+	// ExecInContainer runs a command in a container. The first request must hold the command to run, and the following
+	// ones its input and terminal size changes. The call ends once the command exits.
+	ExecInContainer(Provider_ExecInContainerServer) error
+	Capacity(context.Context, *CapacityRequest) (*CapacityResponse, error)
+	NodeConditions(context.Context, *NodeConditionsRequest) (*NodeConditionsResponse, error)
+	NodeAddresses(context.Context, *NodeAddressesRequest) (*NodeAddressesResponse, error)
+	NodeDaemonEndpoints(context.Context, *NodeDaemonEndpointsRequest) (*NodeDaemonEndpointsResponse, error)
+	OperatingSystem(context.Context, *OperatingSystemRequest) (*OperatingSystemResponse, error)
+	GetStatsSummary(context.Context, *GetStatsSummaryRequest) (*GetStatsSummaryResponse, error)
+}
+
+func RegisterProviderServer(s *grpc.Server, srv ProviderServer) {
+	s.RegisterService(&_Provider_serviceDesc, srv)
+}
+
+func _Provider_CreatePod_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderServer).CreatePod(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/virtualkubelet.plugin.v1.Provider/CreatePod",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderServer).CreatePod(ctx, req.(*CreatePodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Provider_UpdatePod_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderServer).UpdatePod(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/virtualkubelet.plugin.v1.Provider/UpdatePod",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderServer).UpdatePod(ctx, req.(*UpdatePodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Provider_DeletePod_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeletePodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderServer).DeletePod(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/virtualkubelet.plugin.v1.Provider/DeletePod",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderServer).DeletePod(ctx, req.(*DeletePodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Provider_GetPod_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPodRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderServer).GetPod(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/virtualkubelet.plugin.v1.Provider/GetPod",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderServer).GetPod(ctx, req.(*GetPodRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Provider_GetPodStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPodStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderServer).GetPodStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/virtualkubelet.plugin.v1.Provider/GetPodStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderServer).GetPodStatus(ctx, req.(*GetPodStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Provider_GetPods_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetPodsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderServer).GetPods(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/virtualkubelet.plugin.v1.Provider/GetPods",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderServer).GetPods(ctx, req.(*GetPodsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Provider_GetContainerLogs_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetContainerLogsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ProviderServer).GetContainerLogs(m, &providerGetContainerLogsServer{stream})
+}
+
+type Provider_GetContainerLogsServer interface {
+	Send(*GetContainerLogsResponse) error
+	grpc.ServerStream
+}
+
+type providerGetContainerLogsServer struct {
+	grpc.ServerStream
+}
+
+func (x *providerGetContainerLogsServer) Send(m *GetContainerLogsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Provider_ExecInContainer_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ProviderServer).ExecInContainer(&providerExecInContainerServer{stream})
+}
+
+type Provider_ExecInContainerServer interface {
+	Send(*ExecInContainerResponse) error
+	Recv() (*ExecInContainerRequest, error)
+	grpc.ServerStream
+}
+
+type providerExecInContainerServer struct {
+	grpc.ServerStream
+}
+
+func (x *providerExecInContainerServer) Send(m *ExecInContainerResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *providerExecInContainerServer) Recv() (*ExecInContainerRequest, error) {
+	m := new(ExecInContainerRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Provider_Capacity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CapacityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderServer).Capacity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/virtualkubelet.plugin.v1.Provider/Capacity",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderServer).Capacity(ctx, req.(*CapacityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Provider_NodeConditions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeConditionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderServer).NodeConditions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/virtualkubelet.plugin.v1.Provider/NodeConditions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderServer).NodeConditions(ctx, req.(*NodeConditionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Provider_NodeAddresses_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeAddressesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderServer).NodeAddresses(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/virtualkubelet.plugin.v1.Provider/NodeAddresses",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderServer).NodeAddresses(ctx, req.(*NodeAddressesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Provider_NodeDaemonEndpoints_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(NodeDaemonEndpointsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderServer).NodeDaemonEndpoints(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/virtualkubelet.plugin.v1.Provider/NodeDaemonEndpoints",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderServer).NodeDaemonEndpoints(ctx, req.(*NodeDaemonEndpointsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Provider_OperatingSystem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OperatingSystemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderServer).OperatingSystem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/virtualkubelet.plugin.v1.Provider/OperatingSystem",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderServer).OperatingSystem(ctx, req.(*OperatingSystemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Provider_GetStatsSummary_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatsSummaryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderServer).GetStatsSummary(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/virtualkubelet.plugin.v1.Provider/GetStatsSummary",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderServer).GetStatsSummary(ctx, req.(*GetStatsSummaryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Provider_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "virtualkubelet.plugin.v1.Provider",
+	HandlerType: (*ProviderServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreatePod",
+			Handler:    _Provider_CreatePod_Handler,
+		},
+		{
+			MethodName: "UpdatePod",
+			Handler:    _Provider_UpdatePod_Handler,
+		},
+		{
+			MethodName: "DeletePod",
+			Handler:    _Provider_DeletePod_Handler,
+		},
+		{
+			MethodName: "GetPod",
+			Handler:    _Provider_GetPod_Handler,
+		},
+		{
+			MethodName: "GetPodStatus",
+			Handler:    _Provider_GetPodStatus_Handler,
+		},
+		{
+			MethodName: "GetPods",
+			Handler:    _Provider_GetPods_Handler,
+		},
+		{
+			MethodName: "Capacity",
+			Handler:    _Provider_Capacity_Handler,
+		},
+		{
+			MethodName: "NodeConditions",
+			Handler:    _Provider_NodeConditions_Handler,
+		},
+		{
+			MethodName: "NodeAddresses",
+			Handler:    _Provider_NodeAddresses_Handler,
+		},
+		{
+			MethodName: "NodeDaemonEndpoints",
+			Handler:    _Provider_NodeDaemonEndpoints_Handler,
+		},
+		{
+			MethodName: "OperatingSystem",
+			Handler:    _Provider_OperatingSystem_Handler,
+		},
+		{
+			MethodName: "GetStatsSummary",
+			Handler:    _Provider_GetStatsSummary_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GetContainerLogs",
+			Handler:       _Provider_GetContainerLogs_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ExecInContainer",
+			Handler:       _Provider_ExecInContainer_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "provider.proto",
+}
+
+func init() { proto.RegisterFile("provider.proto", fileDescriptor_provider_2550ac5ebcb6629e) }
+
+var fileDescriptor_provider_2550ac5ebcb6629e = []byte{
+	// 1151 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x57, 0x51, 0x6f, 0xdb, 0x36,
+	0x10, 0xae, 0xe2, 0x34, 0xb6, 0x2f, 0x76, 0xec, 0x32, 0x69, 0xa2, 0xa9, 0x59, 0x1b, 0xa8, 0xeb,
+	0xea, 0x34, 0x98, 0x1b, 0xa7, 0x2b, 0x90, 0xad, 0xc3, 0x80, 0x36, 0x09, 0x82, 0x01, 0x43, 0x57,
+	0xc8, 0xd9, 0xcb, 0x80, 0xc1, 0x63, 0x24, 0x26, 0x21, 0x6a, 0x89, 0x9a, 0x48, 0xb9, 0x71, 0xb1,
+	0xbd, 0xed, 0xaf, 0xec, 0x4f, 0x0c, 0x7b, 0xde, 0xc3, 0x7e, 0xd5, 0x40, 0x8a, 0xa2, 0x65, 0xc7,
+	0x71, 0x9c, 0xbd, 0xf1, 0xbe, 0xbb, 0xef, 0x3e, 0xf2, 0x48, 0x1d, 0x29, 0x58, 0x89, 0x13, 0x36,
+	0xa0, 0x01, 0x49, 0xda, 0x71, 0xc2, 0x04, 0x43, 0xf6, 0x80, 0x26, 0x22, 0xc5, 0xfd, 0xf7, 0xe9,
+	0x29, 0xe9, 0x13, 0xd1, 0x8e, 0xfb, 0xe9, 0x39, 0x8d, 0xda, 0x83, 0x8e, 0xfb, 0x19, 0x34, 0x0f,
+	0x12, 0x82, 0x05, 0x79, 0xc7, 0x02, 0x8f, 0xfc, 0x9a, 0x12, 0x2e, 0x50, 0x13, 0x4a, 0x31, 0x0b,
+	0x6c, 0x6b, 0xcb, 0x6a, 0xd5, 0x3c, 0x39, 0x74, 0x57, 0xe1, 0x5e, 0x21, 0x8a, 0xc7, 0x2c, 0xe2,
+	0x44, 0x52, 0x7f, 0x8c, 0x83, 0x39, 0xa8, 0x85, 0xa8, 0x11, 0xf5, 0x50, 0x4e, 0xe4, 0x46, 0x6a,
+	0x21, 0x4a, 0x53, 0x5f, 0x43, 0xfd, 0x98, 0x88, 0x02, 0x6f, 0x13, 0xaa, 0x11, 0x0e, 0x09, 0x8f,
+	0xb1, 0x4f, 0x14, 0xbb, 0xea, 0x8d, 0x00, 0x84, 0x60, 0x51, 0x1a, 0xf6, 0x82, 0x72, 0xa8, 0xb1,
+	0xeb, 0xc2, 0x4a, 0x9e, 0x22, 0x4b, 0x3a, 0x45, 0xfb, 0x18, 0x56, 0xb3, 0x98, 0xae, 0xc0, 0x22,
+	0xe5, 0xff, 0x5f, 0xac, 0x0d, 0x6b, 0xe3, 0x89, 0xb4, 0xe4, 0x3a, 0x2c, 0x71, 0x85, 0x68, 0x55,
+	0x6d, 0xb9, 0xcd, 0x7c, 0x72, 0xb9, 0xa6, 0xfb, 0x04, 0x1a, 0x06, 0xd1, 0x64, 0x04, 0x8b, 0x31,
+	0x0b, 0x24, 0xb5, 0xd4, 0xaa, 0x79, 0x6a, 0xec, 0xfe, 0xb3, 0x00, 0x1b, 0xc7, 0x44, 0x1c, 0xb0,
+	0x48, 0x60, 0x1a, 0x91, 0xe4, 0x7b, 0x76, 0x3e, 0xe7, 0xb4, 0x3f, 0x81, 0x4a, 0xcc, 0x82, 0x5e,
+	0x61, 0xea, 0xe5, 0x98, 0x05, 0x6f, 0x71, 0x48, 0xd0, 0x13, 0x58, 0xf1, 0xf3, 0x84, 0x59, 0x40,
+	0x49, 0x05, 0xd4, 0x0d, 0xaa, 0xc2, 0x10, 0x2c, 0x0a, 0x4c, 0xfb, 0xf6, 0xe2, 0x96, 0xd5, 0x2a,
+	0x79, 0x6a, 0x8c, 0x1e, 0xc1, 0x72, 0x9f, 0x86, 0x54, 0xf4, 0x4e, 0x87, 0x82, 0x70, 0xfb, 0xae,
+	0x72, 0x81, 0x82, 0xde, 0x48, 0x04, 0x3d, 0x04, 0x10, 0x34, 0x24, 0x5c, 0xe0, 0x30, 0xe6, 0xf6,
+	0xd2, 0x96, 0xd5, 0xaa, 0x78, 0x05, 0x44, 0x56, 0xe8, 0x8c, 0xf5, 0xfb, 0xec, 0x83, 0x5d, 0x56,
+	0x3e, 0x6d, 0x21, 0x07, 0x2a, 0x71, 0x42, 0x06, 0x94, 0xa5, 0xdc, 0xae, 0x28, 0x8f, 0xb1, 0xd1,
+	0x63, 0xa8, 0x73, 0x1a, 0xf9, 0xa4, 0xc7, 0x89, 0xcf, 0xa2, 0x80, 0xdb, 0x55, 0x25, 0x5b, 0x53,
+	0x60, 0x37, 0xc3, 0xd0, 0xa7, 0x00, 0x59, 0x90, 0x14, 0xb3, 0x41, 0x45, 0x54, 0x15, 0x72, 0x42,
+	0xd5, 0x8e, 0xd9, 0x57, 0xeb, 0x38, 0x2a, 0x7c, 0x80, 0x05, 0xd6, 0x7b, 0xa6, 0xc6, 0xee, 0x5f,
+	0x16, 0x54, 0x8f, 0x2e, 0x89, 0xdf, 0x15, 0x38, 0x11, 0xe6, 0x0c, 0x58, 0xa3, 0x33, 0x20, 0x8f,
+	0x57, 0x4a, 0x03, 0x5d, 0x5b, 0x39, 0x94, 0x1b, 0x62, 0x2a, 0xa8, 0x4b, 0x3a, 0x02, 0x90, 0x0d,
+	0x65, 0x9f, 0x85, 0x21, 0x8e, 0x02, 0x7b, 0x71, 0xab, 0x24, 0xf7, 0x43, 0x9b, 0x32, 0x93, 0x10,
+	0x43, 0x55, 0xcc, 0x8a, 0x27, 0x87, 0x68, 0x0d, 0xee, 0x72, 0x11, 0xd0, 0x48, 0x17, 0x30, 0x33,
+	0xd0, 0x53, 0x68, 0xc8, 0xc5, 0xb1, 0x54, 0x98, 0x4a, 0x94, 0xd5, 0x3a, 0x57, 0x34, 0xac, 0x6b,
+	0xe1, 0x7e, 0x03, 0xb5, 0x13, 0x92, 0x84, 0x34, 0xc2, 0xfd, 0x2e, 0xfd, 0x48, 0x64, 0xba, 0x0f,
+	0x34, 0x10, 0x17, 0x6a, 0xfe, 0x75, 0x2f, 0x33, 0xe4, 0x56, 0x5c, 0x10, 0x7a, 0x7e, 0x21, 0xd4,
+	0x1a, 0xea, 0x9e, 0xb6, 0xdc, 0x7f, 0x2d, 0x58, 0x97, 0x4b, 0xff, 0x2e, 0x32, 0xe5, 0xca, 0x8f,
+	0xdc, 0x57, 0x72, 0x5e, 0x38, 0x11, 0x2a, 0xd1, 0xf2, 0xde, 0xe3, 0xf6, 0x75, 0x2d, 0xa8, 0x6d,
+	0x6a, 0xe7, 0x65, 0x8c, 0xd1, 0x92, 0x16, 0x54, 0x95, 0xf5, 0x92, 0x1e, 0xc1, 0xb2, 0xdf, 0x67,
+	0x9c, 0xf4, 0x32, 0x5f, 0x29, 0x3b, 0x2f, 0x0a, 0xea, 0xaa, 0x80, 0x6f, 0x61, 0x29, 0x21, 0x9c,
+	0x7e, 0x24, 0xea, 0x18, 0x2e, 0xef, 0x7d, 0x7e, 0xbd, 0x64, 0x71, 0xc9, 0x9e, 0x66, 0xb9, 0x67,
+	0xb0, 0x71, 0x65, 0x2d, 0xc5, 0x8f, 0x35, 0x60, 0xa9, 0x18, 0x7d, 0xac, 0xd2, 0xd2, 0x38, 0x49,
+	0x12, 0x3d, 0x55, 0x6d, 0xa1, 0x07, 0x50, 0x25, 0x97, 0x54, 0xf4, 0x7c, 0x16, 0x64, 0x5f, 0xcc,
+	0x5d, 0xaf, 0x22, 0x81, 0x03, 0x16, 0x10, 0xf7, 0x1e, 0x34, 0x0e, 0x70, 0x8c, 0x7d, 0x2a, 0x86,
+	0xf9, 0x27, 0xfe, 0xa7, 0x05, 0xcd, 0x11, 0xa6, 0x45, 0x4f, 0xa0, 0xe2, 0x6b, 0x4c, 0x7d, 0xe8,
+	0xcb, 0x7b, 0xfb, 0xd7, 0xaf, 0x68, 0x92, 0x6d, 0x80, 0xa3, 0x48, 0x24, 0x43, 0xcf, 0x64, 0x72,
+	0x5e, 0x41, 0x7d, 0xcc, 0x25, 0x8f, 0xd4, 0x7b, 0x32, 0xd4, 0xe7, 0x55, 0x0e, 0x65, 0xfd, 0x07,
+	0xb8, 0x9f, 0xe6, 0xcd, 0x20, 0x33, 0xbe, 0x5e, 0xd8, 0xb7, 0xdc, 0x0d, 0xb8, 0xff, 0x96, 0x05,
+	0xe4, 0x80, 0x45, 0x01, 0x15, 0x94, 0x45, 0xa6, 0x47, 0xed, 0xc3, 0xfa, 0xa4, 0x43, 0xaf, 0xe2,
+	0x21, 0x80, 0x6f, 0x50, 0xdd, 0xb0, 0x0a, 0x88, 0xbb, 0x0e, 0x6b, 0x92, 0xf9, 0x3a, 0x08, 0x12,
+	0xc2, 0x39, 0x31, 0x19, 0x5f, 0xc2, 0xfd, 0x09, 0x5c, 0x27, 0xdc, 0x84, 0x2a, 0xce, 0x41, 0x9d,
+	0x6f, 0x04, 0xb8, 0x9b, 0xe0, 0x48, 0xda, 0x21, 0x26, 0x21, 0x8b, 0x8e, 0xa2, 0x20, 0x66, 0x34,
+	0x12, 0x26, 0xe9, 0x2b, 0x78, 0x30, 0xd5, 0x3b, 0x4a, 0x4d, 0x72, 0x50, 0xef, 0xf4, 0x08, 0x70,
+	0x6d, 0x58, 0xff, 0x21, 0x26, 0x09, 0x16, 0x34, 0x3a, 0xef, 0x0e, 0xb9, 0x20, 0x61, 0x9e, 0xf6,
+	0x10, 0x36, 0xae, 0x78, 0x74, 0xca, 0x6d, 0x68, 0xb2, 0xdc, 0xd5, 0xe3, 0xca, 0xa7, 0x4b, 0xdd,
+	0x60, 0xe3, 0x14, 0x99, 0xff, 0x98, 0x08, 0x79, 0x4d, 0xf0, 0x6e, 0x1a, 0x86, 0x38, 0x31, 0xc7,
+	0xe3, 0x05, 0x6c, 0x5c, 0xf1, 0xe8, 0xfc, 0x36, 0x94, 0x79, 0x06, 0xe9, 0x09, 0xe7, 0xe6, 0xde,
+	0xdf, 0x35, 0xa8, 0xbc, 0xd3, 0xcf, 0x00, 0x74, 0x06, 0x55, 0x73, 0x81, 0xa3, 0x67, 0x33, 0x8e,
+	0xd1, 0xc4, 0x5b, 0xc0, 0xd9, 0x99, 0x2b, 0x56, 0xdf, 0xcd, 0x77, 0xa4, 0x8e, 0xb9, 0xed, 0x67,
+	0xe9, 0x4c, 0x3e, 0x1c, 0x9c, 0x9d, 0xb9, 0x62, 0x8b, 0x3a, 0xe6, 0x69, 0x30, 0x4b, 0x67, 0xf2,
+	0x95, 0xe1, 0xec, 0xcc, 0x15, 0x6b, 0x74, 0x7e, 0x86, 0xa5, 0xec, 0xee, 0x45, 0x4f, 0xaf, 0x27,
+	0x8e, 0xbd, 0x47, 0x9c, 0xd6, 0xcd, 0x81, 0x26, 0x3d, 0x83, 0x5a, 0xf1, 0x71, 0x80, 0xbe, 0xb8,
+	0x89, 0x3b, 0xf6, 0x1a, 0x71, 0xda, 0xf3, 0x86, 0x1b, 0xc1, 0x5f, 0xa0, 0x9c, 0x79, 0x38, 0xba,
+	0x71, 0x9e, 0x46, 0x66, 0x7b, 0x8e, 0x48, 0xa3, 0xf0, 0x3b, 0x34, 0x27, 0x6f, 0x4f, 0xd4, 0x99,
+	0x99, 0x60, 0xda, 0x8b, 0xc5, 0xd9, 0xbb, 0x0d, 0x25, 0x17, 0xdf, 0xb5, 0xd0, 0x6f, 0xd0, 0x98,
+	0x68, 0xe2, 0x68, 0x77, 0xf6, 0xd5, 0x73, 0xf5, 0xee, 0x72, 0x3a, 0xb7, 0x60, 0xe4, 0xda, 0x2d,
+	0x6b, 0xd7, 0x42, 0x3e, 0x54, 0xf2, 0xe6, 0x8a, 0xb6, 0xe7, 0x69, 0xd6, 0x99, 0xde, 0xb3, 0xf9,
+	0xfb, 0xba, 0x7b, 0x07, 0xa5, 0xb0, 0x32, 0xde, 0x6b, 0xd1, 0xf3, 0xeb, 0xf9, 0x53, 0xdb, 0xb5,
+	0xb3, 0x3b, 0x3f, 0xc1, 0xc8, 0x26, 0x50, 0x1f, 0x6b, 0xc8, 0xa8, 0x3d, 0x3b, 0xc9, 0x64, 0x47,
+	0x77, 0x9e, 0xcf, 0x1d, 0x6f, 0x34, 0xff, 0xb0, 0x60, 0x75, 0x4a, 0xc3, 0x46, 0x5f, 0xce, 0x4e,
+	0x35, 0xbd, 0xfb, 0x3b, 0x2f, 0x6f, 0xc9, 0x32, 0xd3, 0xb8, 0x84, 0xc6, 0x44, 0x7f, 0x9f, 0x75,
+	0xa8, 0xa6, 0x5f, 0x12, 0x4e, 0xe7, 0x16, 0x8c, 0xa2, 0xf2, 0x44, 0xe7, 0x9f, 0xa5, 0x3c, 0xfd,
+	0xfa, 0x70, 0x3a, 0xb7, 0x60, 0xe4, 0xca, 0x6f, 0xe0, 0xa7, 0x4a, 0x16, 0x36, 0xe8, 0x9c, 0x2e,
+	0xa9, 0xbf, 0xc8, 0x17, 0xff, 0x0d, 0x00, 0x62, 0xa6, 0x17, 0xf4, 0x57, 0x0e, 0x00, 0x00,
+}
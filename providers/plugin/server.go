@@ -0,0 +1,358 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cpuguy83/strongerrors"
+	grpcstatus "github.com/cpuguy83/strongerrors/status"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/remotecommand"
+	utilexec "k8s.io/utils/exec"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+	"github.com/virtual-kubelet/virtual-kubelet/providers/plugin/pluginv1"
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
+)
+
+// Serve serves the specified provider over the plugin protocol on the specified listener, until it is closed.
+//
+// This is meant to be called from the main function of plugins, such as:
+//
+//	lis, err := net.Listen("unix", "/run/provider.sock")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	log.Fatal(plugin.Serve(lis, myProvider))
+func Serve(lis net.Listener, p providers.Provider, opts ...grpc.ServerOption) error {
+	s := grpc.NewServer(opts...)
+	pluginv1.RegisterProviderServer(s, NewServer(p))
+	return s.Serve(lis)
+}
+
+// NewServer returns an implementation of the server API of the plugin protocol which forwards all calls to the specified provider.
+// The optional PodMetricsProvider and ContainerLogsStreamer interfaces are used when the provider implements them.
+func NewServer(p providers.Provider) pluginv1.ProviderServer {
+	return &server{p: p}
+}
+
+type server struct {
+	p providers.Provider
+}
+
+func (s *server) CreatePod(ctx context.Context, req *pluginv1.CreatePodRequest) (*pluginv1.CreatePodResponse, error) {
+	pod := &v1.Pod{}
+	if err := pod.Unmarshal(req.Pod); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "error decoding pod: %v", err)
+	}
+	if err := s.p.CreatePod(ctx, pod); err != nil {
+		return nil, toGRPC(err)
+	}
+	return &pluginv1.CreatePodResponse{}, nil
+}
+
+func (s *server) UpdatePod(ctx context.Context, req *pluginv1.UpdatePodRequest) (*pluginv1.UpdatePodResponse, error) {
+	pod := &v1.Pod{}
+	if err := pod.Unmarshal(req.Pod); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "error decoding pod: %v", err)
+	}
+	if err := s.p.UpdatePod(ctx, pod); err != nil {
+		return nil, toGRPC(err)
+	}
+	return &pluginv1.UpdatePodResponse{}, nil
+}
+
+func (s *server) DeletePod(ctx context.Context, req *pluginv1.DeletePodRequest) (*pluginv1.DeletePodResponse, error) {
+	pod := &v1.Pod{}
+	if err := pod.Unmarshal(req.Pod); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "error decoding pod: %v", err)
+	}
+	if err := s.p.DeletePod(ctx, pod); err != nil {
+		return nil, toGRPC(err)
+	}
+	return &pluginv1.DeletePodResponse{}, nil
+}
+
+func (s *server) GetPod(ctx context.Context, req *pluginv1.GetPodRequest) (*pluginv1.GetPodResponse, error) {
+	pod, err := s.p.GetPod(ctx, req.Namespace, req.Name)
+	if err != nil {
+		return nil, toGRPC(err)
+	}
+	if pod == nil {
+		return &pluginv1.GetPodResponse{}, nil
+	}
+	b, err := pod.Marshal()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error encoding pod: %v", err)
+	}
+	return &pluginv1.GetPodResponse{Pod: b}, nil
+}
+
+func (s *server) GetPodStatus(ctx context.Context, req *pluginv1.GetPodStatusRequest) (*pluginv1.GetPodStatusResponse, error) {
+	podStatus, err := s.p.GetPodStatus(ctx, req.Namespace, req.Name)
+	if err != nil {
+		return nil, toGRPC(err)
+	}
+	if podStatus == nil {
+		return &pluginv1.GetPodStatusResponse{}, nil
+	}
+	b, err := podStatus.Marshal()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error encoding pod status: %v", err)
+	}
+	return &pluginv1.GetPodStatusResponse{Status: b}, nil
+}
+
+func (s *server) GetPods(ctx context.Context, req *pluginv1.GetPodsRequest) (*pluginv1.GetPodsResponse, error) {
+	pods, err := s.p.GetPods(ctx)
+	if err != nil {
+		return nil, toGRPC(err)
+	}
+	res := &pluginv1.GetPodsResponse{Pods: make([][]byte, 0, len(pods))}
+	for _, pod := range pods {
+		b, err := pod.Marshal()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "error encoding pod: %v", err)
+		}
+		res.Pods = append(res.Pods, b)
+	}
+	return res, nil
+}
+
+func (s *server) GetContainerLogs(req *pluginv1.GetContainerLogsRequest, stream pluginv1.Provider_GetContainerLogsServer) error {
+	ctx := stream.Context()
+
+	var r io.Reader
+	if _, ok := providers.Unwrap(s.p).(providers.ContainerLogsStreamer); ok {
+		ls := s.p.(providers.ContainerLogsStreamer)
+		opts := api.ContainerLogOpts{
+			Tail:         int(req.Tail),
+			LimitBytes:   int(req.LimitBytes),
+			Timestamps:   req.Timestamps,
+			Follow:       req.Follow,
+			Previous:     req.Previous,
+			SinceSeconds: int(req.SinceSeconds),
+		}
+		if req.SinceTime != 0 {
+			opts.SinceTime = time.Unix(0, req.SinceTime)
+		}
+		rc, err := ls.GetContainerLogStream(ctx, req.Namespace, req.PodName, req.ContainerName, opts)
+		if err != nil {
+			return toGRPC(err)
+		}
+		defer rc.Close()
+		r = rc
+	} else {
+		// Only the tail option is supported by providers which don't stream logs.
+		logs, err := s.p.GetContainerLogs(ctx, req.Namespace, req.PodName, req.ContainerName, int(req.Tail))
+		if err != nil {
+			return toGRPC(err)
+		}
+		r = strings.NewReader(logs)
+	}
+
+	b := make([]byte, streamChunkSize)
+	for {
+		n, err := r.Read(b)
+		if n > 0 {
+			if err := stream.Send(&pluginv1.GetContainerLogsResponse{Data: b[:n]}); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return toGRPC(err)
+		}
+	}
+}
+
+func (s *server) ExecInContainer(stream pluginv1.Provider_ExecInContainerServer) error {
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	start := req.Start
+	if start == nil {
+		return status.Error(codes.InvalidArgument, "the first request must hold the command to run")
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	var (
+		in     io.Reader
+		stdinW *io.PipeWriter
+		resize = make(chan remotecommand.TerminalSize, 1)
+		sendMu sync.Mutex
+	)
+	if start.Stdin {
+		var stdinR *io.PipeReader
+		stdinR, stdinW = io.Pipe()
+		defer stdinR.Close()
+		in = stdinR
+	}
+
+	// Input and terminal size changes are received until the client is done sending them, or the command exits.
+	go func() {
+		defer close(resize)
+		for {
+			req, err := stream.Recv()
+			if err != nil {
+				if stdinW != nil {
+					if err == io.EOF {
+						err = nil
+					}
+					stdinW.CloseWithError(err)
+				}
+				return
+			}
+			if len(req.Stdin) > 0 && stdinW != nil {
+				if _, err := stdinW.Write(req.Stdin); err != nil {
+					stdinW = nil
+				}
+			}
+			if req.CloseStdin && stdinW != nil {
+				stdinW.Close()
+				stdinW = nil
+			}
+			if req.Resize != nil {
+				select {
+				case resize <- remotecommand.TerminalSize{Width: uint16(req.Resize.Width), Height: uint16(req.Resize.Height)}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	timeout := time.Duration(start.TimeoutSeconds) * time.Second
+	err = s.p.ExecInContainer(start.Name, types.UID(start.Uid), start.Container, start.Command, in,
+		&execOutput{stream: stream, mu: &sendMu}, &execOutput{stream: stream, mu: &sendMu, stderr: true}, start.Tty, resize, timeout)
+	if exitErr, ok := err.(utilexec.ExitError); ok && exitErr.Exited() {
+		// The exit code is sent in a last response rather than as an error, which gRPC statuses can't carry.
+		sendMu.Lock()
+		defer sendMu.Unlock()
+		return stream.Send(&pluginv1.ExecInContainerResponse{ExitCode: int32(exitErr.ExitStatus())})
+	}
+	return toGRPC(err)
+}
+
+// execOutput sends the output of a command run by ExecInContainer on its stream.
+type execOutput struct {
+	stream pluginv1.Provider_ExecInContainerServer
+	// mu serializes the sends of the stdout and stderr outputs, which gRPC doesn't allow concurrently on a single stream.
+	mu     *sync.Mutex
+	stderr bool
+}
+
+func (o *execOutput) Write(b []byte) (int, error) {
+	res := &pluginv1.ExecInContainerResponse{}
+	if o.stderr {
+		res.Stderr = b
+	} else {
+		res.Stdout = b
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err := o.stream.Send(res); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (o *execOutput) Close() error {
+	return nil
+}
+
+func (s *server) Capacity(ctx context.Context, req *pluginv1.CapacityRequest) (*pluginv1.CapacityResponse, error) {
+	capacity := s.p.Capacity(ctx)
+	res := &pluginv1.CapacityResponse{Capacity: make(map[string]string, len(capacity))}
+	for name, q := range capacity {
+		res.Capacity[string(name)] = q.String()
+	}
+	return res, nil
+}
+
+func (s *server) NodeConditions(ctx context.Context, req *pluginv1.NodeConditionsRequest) (*pluginv1.NodeConditionsResponse, error) {
+	conditions := s.p.NodeConditions(ctx)
+	res := &pluginv1.NodeConditionsResponse{Conditions: make([][]byte, 0, len(conditions))}
+	for i := range conditions {
+		b, err := conditions[i].Marshal()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "error encoding node condition: %v", err)
+		}
+		res.Conditions = append(res.Conditions, b)
+	}
+	return res, nil
+}
+
+func (s *server) NodeAddresses(ctx context.Context, req *pluginv1.NodeAddressesRequest) (*pluginv1.NodeAddressesResponse, error) {
+	addresses := s.p.NodeAddresses(ctx)
+	res := &pluginv1.NodeAddressesResponse{Addresses: make([][]byte, 0, len(addresses))}
+	for i := range addresses {
+		b, err := addresses[i].Marshal()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "error encoding node address: %v", err)
+		}
+		res.Addresses = append(res.Addresses, b)
+	}
+	return res, nil
+}
+
+func (s *server) NodeDaemonEndpoints(ctx context.Context, req *pluginv1.NodeDaemonEndpointsRequest) (*pluginv1.NodeDaemonEndpointsResponse, error) {
+	endpoints := s.p.NodeDaemonEndpoints(ctx)
+	if endpoints == nil {
+		return &pluginv1.NodeDaemonEndpointsResponse{}, nil
+	}
+	b, err := endpoints.Marshal()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error encoding node daemon endpoints: %v", err)
+	}
+	return &pluginv1.NodeDaemonEndpointsResponse{Endpoints: b}, nil
+}
+
+func (s *server) OperatingSystem(ctx context.Context, req *pluginv1.OperatingSystemRequest) (*pluginv1.OperatingSystemResponse, error) {
+	return &pluginv1.OperatingSystemResponse{OperatingSystem: s.p.OperatingSystem()}, nil
+}
+
+func (s *server) GetStatsSummary(ctx context.Context, req *pluginv1.GetStatsSummaryRequest) (*pluginv1.GetStatsSummaryResponse, error) {
+	if _, ok := providers.Unwrap(s.p).(providers.PodMetricsProvider); !ok {
+		return nil, toGRPC(strongerrors.NotImplemented(errors.New("provider does not support metrics")))
+	}
+	mp := s.p.(providers.PodMetricsProvider)
+	summary, err := mp.GetStatsSummary(ctx)
+	if err != nil {
+		return nil, toGRPC(err)
+	}
+	b, err := json.Marshal(summary)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error encoding stats summary: %v", err)
+	}
+	return &pluginv1.GetStatsSummaryResponse{Summary: b}, nil
+}
+
+// toGRPC converts the specified error returned by the provider into a gRPC status error.
+// Kubernetes API errors are converted as well, as some providers return them when pods are not found.
+func toGRPC(err error) error {
+	if err == nil {
+		return nil
+	}
+	if apierrors.IsNotFound(err) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return grpcstatus.ToGRPC(err)
+}
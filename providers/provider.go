@@ -9,6 +9,8 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/remotecommand"
 	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
+
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
 )
 
 // Provider contains the methods required to implement a virtual-kubelet provider.
@@ -26,6 +28,7 @@ type Provider interface {
 	GetPod(ctx context.Context, namespace, name string) (*v1.Pod, error)
 
 	// GetContainerLogs retrieves the logs of a container by name from the provider.
+	// All lines are returned when tail is zero.
 	GetContainerLogs(ctx context.Context, namespace, podName, containerName string, tail int) (string, error)
 
 	// ExecInContainer executes a command in a container in the pod, copying data
@@ -61,3 +64,92 @@ type Provider interface {
 type PodMetricsProvider interface {
 	GetStatsSummary(context.Context) (*stats.Summary, error)
 }
+
+// ContainerLogsStreamer is an optional interface that providers can implement to stream container logs.
+// Providers implementing this interface support following logs and all the other options of "kubectl logs".
+type ContainerLogsStreamer interface {
+	// GetContainerLogStream returns a stream of the logs of a container, according to the specified options.
+	// When following logs, the stream should be closed when the passed in context is cancelled.
+	GetContainerLogStream(ctx context.Context, namespace, podName, containerName string, opts api.ContainerLogOpts) (io.ReadCloser, error)
+}
+
+// ContainerAttacher is an optional interface that providers can implement to attach to the running process of a container.
+type ContainerAttacher interface {
+	// AttachToContainer attaches to the running process of a container in the pod, copying data
+	// between in/out/err and the container's stdin/stdout/stderr.
+	AttachToContainer(name string, uid types.UID, container string, in io.Reader, out, err io.WriteCloser, tty bool, resize <-chan remotecommand.TerminalSize) error
+}
+
+// PortForwarder is an optional interface that providers can implement to forward connections to the ports of a pod.
+type PortForwarder interface {
+	// PortForward copies data between the passed in stream and a port of the pod.
+	PortForward(name string, uid types.UID, port int32, stream io.ReadWriteCloser) error
+}
+
+// PodTerminator is an optional interface that providers can implement to stop pods gracefully before they are deleted.
+// Providers implementing this interface are given the pod's grace period to stop its containers before DeletePod is called.
+type PodTerminator interface {
+	// StopPod starts the graceful termination of the containers of the pod, such as running their preStop hooks and
+	// signalling them to exit, allowing them to run for at most the specified grace period.
+	// StopPod must not block until the containers have exited. Instead, containers which have exited must be reported as
+	// terminated in the status of the pod. DeletePod is called once all the containers are terminated or the grace period expires.
+	StopPod(ctx context.Context, pod *v1.Pod, gracePeriod time.Duration) error
+}
+
+// PodAdmitResult is the result of the admission of a pod.
+type PodAdmitResult struct {
+	// Admit is whether the pod can be created.
+	Admit bool
+	// Reason is a brief CamelCase string explaining why the pod was rejected, such as "OutOfcpu".
+	Reason string
+	// Message is a human-readable message explaining why the pod was rejected.
+	Message string
+}
+
+// PodAdmitHandler is an optional interface that providers can implement to reject pods before they are created.
+// Rejected pods are marked as failed with the reason and message of the result instead of being passed to CreatePod.
+type PodAdmitHandler interface {
+	// AdmitPod decides whether the specified pod can be created, given the other active pods admitted on the node.
+	AdmitPod(ctx context.Context, pod *v1.Pod, otherPods []*v1.Pod) PodAdmitResult
+}
+
+// RestartDelegator is an optional interface that providers whose backend doesn't restart containers according to the
+// restart policy of pods can implement, in order to have the virtual-kubelet enforce it instead.
+type RestartDelegator interface {
+	// DelegateRestarts returns whether the virtual-kubelet must enforce the restart policy of pods.
+	// When it does, pods whose containers exit, or which are lost by the provider, are deleted and created again in the
+	// provider with an exponential back-off, as allowed by their restart policy. The restart counts and back-offs of
+	// pods are kept in memory, so they start over when the virtual-kubelet restarts.
+	DelegateRestarts() bool
+}
+
+// PodNotifier is an optional interface that providers can implement to notify the virtual-kubelet of pod status changes.
+// Providers implementing this interface are not polled for the status of every pod they run.
+type PodNotifier interface {
+	// NotifyPods instructs the notifier to call the passed in function whenever the status of a pod changes.
+	// The pod passed to the function must at least have its namespace and name set.
+	//
+	// NotifyPods must not block the caller, and should stop notifying when the passed in context is cancelled.
+	NotifyPods(context.Context, func(*v1.Pod))
+}
+
+// Wrapper is implemented by providers which decorate another provider, such as the middlewares of the
+// providers/middleware package. Wrappers must implement all the optional interfaces above, forwarding them to the
+// decorated provider, for the calls made through them to be decorated too. Whether an optional interface is supported
+// must be checked on the provider returned by Unwrap, while its methods are called on the wrapper.
+type Wrapper interface {
+	// Unwrap returns the decorated provider.
+	Unwrap() Provider
+}
+
+// Unwrap returns the innermost provider decorated by the passed in provider, or the provider itself when it doesn't
+// decorate another one.
+func Unwrap(p Provider) Provider {
+	for {
+		w, ok := p.(Wrapper)
+		if !ok {
+			return p
+		}
+		p = w.Unwrap()
+	}
+}
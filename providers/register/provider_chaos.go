@@ -0,0 +1,27 @@
+// +build !no_chaos_provider
+
+package register
+
+import (
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+	"github.com/virtual-kubelet/virtual-kubelet/providers/chaos"
+)
+
+func init() {
+	register("chaos", initChaos)
+}
+
+// initChaos initializes the provider named in the chaos provider configuration file, with its own configuration file,
+// and wraps it to inject faults in it.
+func initChaos(cfg InitConfig) (providers.Provider, error) {
+	config, err := chaos.LoadConfig(cfg.ConfigPath)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ConfigPath = config.ProviderConfig
+	p, err := GetProvider(config.Provider, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return chaos.NewProvider(p, config)
+}
@@ -0,0 +1,27 @@
+// +build !no_grpc_provider
+
+package register
+
+import (
+	"os"
+
+	"github.com/cpuguy83/strongerrors"
+	"github.com/pkg/errors"
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+	"github.com/virtual-kubelet/virtual-kubelet/providers/plugin"
+)
+
+// grpcEndpointEnv is the environment variable holding the endpoint of the plugin used by the grpc provider.
+const grpcEndpointEnv = "GRPC_PROVIDER_ENDPOINT"
+
+func init() {
+	register("grpc", initGRPC)
+}
+
+func initGRPC(cfg InitConfig) (providers.Provider, error) {
+	endpoint := os.Getenv(grpcEndpointEnv)
+	if endpoint == "" {
+		return nil, strongerrors.InvalidArgument(errors.Errorf("%s must be set to the endpoint of the plugin", grpcEndpointEnv))
+	}
+	return plugin.NewProvider(endpoint)
+}
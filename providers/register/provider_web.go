@@ -12,5 +12,5 @@ func init() {
 }
 
 func initWeb(cfg InitConfig) (providers.Provider, error) {
-	return web.NewBrokerProvider(cfg.NodeName, cfg.OperatingSystem, cfg.DaemonPort)
+	return web.NewBrokerProvider(cfg.ConfigPath, cfg.NodeName, cfg.OperatingSystem, cfg.DaemonPort)
 }
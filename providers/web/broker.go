@@ -1,40 +1,68 @@
 // Package web provides an implementation of the virtual kubelet provider interface
 // by forwarding all calls to a web endpoint. The web endpoint to which requests
-// must be forwarded must be specified through an environment variable called
-// `WEB_ENDPOINT_URL`. This endpoint must implement the following HTTP APIs:
+// must be forwarded is specified in the provider configuration file, or through
+// an environment variable called `WEB_ENDPOINT_URL`. This endpoint must implement
+// the following HTTP APIs:
 //  - POST /createPod
 //  - PUT /updatePod
 //  - DELETE /deletePod
 //  - GET /getPod?namespace=[namespace]&name=[pod name]
-//  - GE /getContainerLogs?namespace=[namespace]&podName=[pod name]&containerName=[container name]&tail=[tail value]
+//  - GET /getContainerLogs?namespace=[namespace]&podName=[pod name]&containerName=[container name]&tail=[tail value]
 //  - GET /getPodStatus?namespace=[namespace]&name=[pod name]
 //  - GET /getPods
 //  - GET /capacity
 //  - GET /nodeConditions
 //  - GET /nodeAddresses
+//  - GET /getStatsSummary
+//  - GET /execInContainer?name=[pod name]&uid=[pod uid]&container=[container name]&command=[argument]...&tty=[tty]&stdin=[stdin]&timeout=[seconds]
+//
+// The tail value of the logs API is the number of lines to return from the end of
+// the logs, all of them being requested when it is 0.
+//
+// The exec API is upgraded to a websocket connection, over which binary messages
+// prefixed with the channel of their data are exchanged. See the README for details.
 package web
 
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"net/url"
-	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/cenkalti/backoff"
 	"github.com/cpuguy83/strongerrors"
+	"github.com/gorilla/websocket"
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/remotecommand"
+	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
+
+	"github.com/virtual-kubelet/virtual-kubelet/log"
+)
+
+// Channels of the data exchanged over the websocket connections of the exec API.
+const (
+	execStdinChannel  = 0
+	execStdoutChannel = 1
+	execStderrChannel = 2
+	execErrorChannel  = 3
+	execResizeChannel = 4
 )
 
+// maxErrorMessageLength is the maximum length of the response body included in errors returned by the endpoint.
+const maxErrorMessageLength = 1024
+
 // BrokerProvider implements the virtual-kubelet provider interface by forwarding kubelet calls to a web endpoint.
 type BrokerProvider struct {
 	nodeName           string
@@ -42,23 +70,33 @@ type BrokerProvider struct {
 	endpoint           *url.URL
 	client             *http.Client
 	daemonEndpointPort int32
+
+	requestTimeout  time.Duration
+	retryTimeout    time.Duration
+	bearerToken     string
+	bearerTokenFile string
+	tlsConfig       *tls.Config
 }
 
-// NewBrokerProvider creates a new BrokerProvider
-func NewBrokerProvider(nodeName, operatingSystem string, daemonEndpointPort int32) (*BrokerProvider, error) {
+// NewBrokerProvider creates a new BrokerProvider, configured from the specified configuration file if any.
+func NewBrokerProvider(config, nodeName, operatingSystem string, daemonEndpointPort int32) (*BrokerProvider, error) {
 	var provider BrokerProvider
 
 	provider.nodeName = nodeName
 	provider.operatingSystem = operatingSystem
-	provider.client = &http.Client{}
 	provider.daemonEndpointPort = daemonEndpointPort
 
-	if ep := os.Getenv("WEB_ENDPOINT_URL"); ep != "" {
-		epurl, err := url.Parse(ep)
-		if err != nil {
-			return nil, err
-		}
-		provider.endpoint = epurl
+	if err := provider.loadConfigFile(config); err != nil {
+		return nil, err
+	}
+
+	provider.client = &http.Client{
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			TLSClientConfig:     provider.tlsConfig,
+			TLSHandshakeTimeout: provider.requestTimeout,
+			IdleConnTimeout:     90 * time.Second,
+		},
 	}
 
 	return &provider, nil
@@ -66,60 +104,45 @@ func NewBrokerProvider(nodeName, operatingSystem string, daemonEndpointPort int3
 
 // CreatePod accepts a Pod definition and forwards the call to the web endpoint
 func (p *BrokerProvider) CreatePod(ctx context.Context, pod *v1.Pod) error {
-	return p.createUpdatePod(pod, "POST", "/createPod")
+	return p.sendPod(ctx, pod, http.MethodPost, "/createPod")
 }
 
 // UpdatePod accepts a Pod definition and forwards the call to the web endpoint
 func (p *BrokerProvider) UpdatePod(ctx context.Context, pod *v1.Pod) error {
-	return p.createUpdatePod(pod, "PUT", "/updatePod")
+	return p.sendPod(ctx, pod, http.MethodPut, "/updatePod")
 }
 
 // DeletePod accepts a Pod definition and forwards the call to the web endpoint
 func (p *BrokerProvider) DeletePod(ctx context.Context, pod *v1.Pod) error {
-	urlPath, err := url.Parse("/deletePod")
-	if err != nil {
-		return err
-	}
-
-	// encode pod definition as JSON and post request
-	podJSON, err := json.Marshal(pod)
-	if err != nil {
-		return err
-	}
-
-	_, err = p.doRequest("DELETE", urlPath, podJSON, false)
-	return err
+	return p.sendPod(ctx, pod, http.MethodDelete, "/deletePod")
 }
 
 // GetPod returns a pod by name that is being managed by the web server
 func (p *BrokerProvider) GetPod(ctx context.Context, namespace, name string) (*v1.Pod, error) {
-	urlPathStr := fmt.Sprintf(
-		"/getPod?namespace=%s&name=%s",
-		url.QueryEscape(namespace),
-		url.QueryEscape(name))
-
 	var pod v1.Pod
-	err := p.doGetRequest(urlPathStr, &pod)
+	err := p.doGetRequest(ctx, "/getPod", url.Values{"namespace": {namespace}, "name": {name}}, &pod)
 
 	// if we get a "404 Not Found" then we return nil to indicate that no pod
 	// with this name was found
-	if err != nil && err.Error() == "404 Not Found" {
+	if strongerrors.IsNotFound(err) {
 		return nil, nil
 	}
+	if err != nil {
+		return nil, err
+	}
 
-	return &pod, err
+	return &pod, nil
 }
 
 // GetContainerLogs returns the logs of a container running in a pod by name.
 func (p *BrokerProvider) GetContainerLogs(ctx context.Context, namespace, podName, containerName string, tail int) (string, error) {
-	urlPathStr := fmt.Sprintf(
-		"/getContainerLogs?namespace=%s&podName=%s&containerName=%s&tail=%d",
-		url.QueryEscape(namespace),
-		url.QueryEscape(podName),
-		url.QueryEscape(containerName),
-		tail)
-
-	response, err := p.doGetRequestBytes(urlPathStr)
+	query := url.Values{
+		"namespace":     {namespace},
+		"podName":       {podName},
+		"containerName": {containerName},
+		"tail":          {strconv.Itoa(tail)},
+	}
+	response, err := p.doRequest(ctx, http.MethodGet, "/getContainerLogs", query, nil)
 	if err != nil {
 		return "", err
 	}
@@ -135,60 +158,193 @@ func (p *BrokerProvider) GetPodFullName(namespace string, pod string) string {
 
 // ExecInContainer executes a command in a container in the pod, copying data
 // between in/out/err and the container's stdin/stdout/stderr.
-// TODO: Implementation
-func (p *BrokerProvider) ExecInContainer(name string, uid types.UID, container string, cmd []string, in io.Reader, out, err io.WriteCloser, tty bool, resize <-chan remotecommand.TerminalSize, timeout time.Duration) error {
-	log.Printf("receive ExecInContainer %q\n", container)
-	return nil
+// The command is run by the web endpoint over a websocket connection.
+func (p *BrokerProvider) ExecInContainer(name string, uid types.UID, container string, cmd []string, in io.Reader, out, errstream io.WriteCloser, tty bool, resize <-chan remotecommand.TerminalSize, timeout time.Duration) error {
+	if out != nil {
+		defer out.Close()
+	}
+	if errstream != nil {
+		defer errstream.Close()
+	}
+
+	query := url.Values{
+		"name":      {name},
+		"uid":       {string(uid)},
+		"container": {container},
+		"command":   cmd,
+		"tty":       {strconv.FormatBool(tty)},
+		"stdin":     {strconv.FormatBool(in != nil)},
+		"timeout":   {strconv.Itoa(int(timeout / time.Second))},
+	}
+	execURL := p.url("/execInContainer", query)
+	if execURL.Scheme == "https" {
+		execURL.Scheme = "wss"
+	} else {
+		execURL.Scheme = "ws"
+	}
+
+	header, err := p.header()
+	if err != nil {
+		return err
+	}
+	dialer := &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		TLSClientConfig:  p.tlsConfig,
+		HandshakeTimeout: p.requestTimeout,
+	}
+	conn, response, err := dialer.Dial(execURL.String(), header)
+	if err != nil {
+		if response != nil {
+			defer response.Body.Close()
+			body, _ := ioutil.ReadAll(io.LimitReader(response.Body, maxErrorMessageLength))
+			return unwrapPermanent(errorFromResponse(response, body))
+		}
+		return err
+	}
+	defer conn.Close()
+
+	// Messages are written concurrently from the input and resize goroutines, which websocket connections don't allow.
+	var mu sync.Mutex
+	write := func(channel byte, data []byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return conn.WriteMessage(websocket.BinaryMessage, append([]byte{channel}, data...))
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	if in != nil {
+		go func() {
+			b := make([]byte, 32*1024)
+			for {
+				n, err := in.Read(b)
+				if n > 0 {
+					if write(execStdinChannel, b[:n]) != nil {
+						return
+					}
+				}
+				if err != nil {
+					// An empty message on the stdin channel signals the end of the input.
+					write(execStdinChannel, nil)
+					return
+				}
+			}
+		}()
+	}
+
+	if resize != nil {
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				case size, ok := <-resize:
+					if !ok {
+						return
+					}
+					b, err := json.Marshal(size)
+					if err != nil || write(execResizeChannel, b) != nil {
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure) || err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if len(msg) == 0 {
+			continue
+		}
+		data := msg[1:]
+		switch msg[0] {
+		case execStdoutChannel:
+			if out != nil {
+				if _, err := out.Write(data); err != nil {
+					return err
+				}
+			}
+		case execStderrChannel:
+			if errstream != nil {
+				if _, err := errstream.Write(data); err != nil {
+					return err
+				}
+			}
+		case execErrorChannel:
+			return fmt.Errorf("error executing command in container: %s", data)
+		}
+	}
 }
 
 // GetPodStatus retrieves the status of a given pod by name.
 func (p *BrokerProvider) GetPodStatus(ctx context.Context, namespace, name string) (*v1.PodStatus, error) {
-	urlPathStr := fmt.Sprintf(
-		"/getPodStatus?namespace=%s&name=%s",
-		url.QueryEscape(namespace),
-		url.QueryEscape(name))
-
 	var podStatus v1.PodStatus
-	err := p.doGetRequest(urlPathStr, &podStatus)
+	err := p.doGetRequest(ctx, "/getPodStatus", url.Values{"namespace": {namespace}, "name": {name}}, &podStatus)
 
 	// if we get a "404 Not Found" then we return nil to indicate that no pod
 	// with this name was found
-	if err != nil && err.Error() == "404 Not Found" {
+	if strongerrors.IsNotFound(err) {
 		return nil, nil
 	}
+	if err != nil {
+		return nil, err
+	}
 
-	return &podStatus, err
+	return &podStatus, nil
 }
 
 // GetPods retrieves a list of all pods scheduled to run.
 func (p *BrokerProvider) GetPods(ctx context.Context) ([]*v1.Pod, error) {
 	var pods []*v1.Pod
-	err := p.doGetRequest("/getPods", &pods)
+	err := p.doGetRequest(ctx, "/getPods", nil, &pods)
 
 	return pods, err
 }
 
+// GetStatsSummary returns the stats of the pods running on the web endpoint.
+func (p *BrokerProvider) GetStatsSummary(ctx context.Context) (*stats.Summary, error) {
+	var summary stats.Summary
+	if err := p.doGetRequest(ctx, "/getStatsSummary", nil, &summary); err != nil {
+		return nil, err
+	}
+
+	return &summary, nil
+}
+
 // Capacity returns a resource list containing the capacity limits
+// No capacity is reported when the endpoint can't be reached.
 func (p *BrokerProvider) Capacity(ctx context.Context) v1.ResourceList {
 	var resourceList v1.ResourceList
-	err := p.doGetRequest("/capacity", &resourceList)
-
-	// TODO: This API should support reporting an error.
-	if err != nil {
-		panic(err)
+	if err := p.doGetRequest(ctx, "/capacity", nil, &resourceList); err != nil {
+		log.G(ctx).WithError(err).Error("Error getting the capacity of the web endpoint")
+		return nil
 	}
 
 	return resourceList
 }
 
 // NodeConditions returns a list of conditions (Ready, OutOfDisk, etc), for updates to the node status
+// The node is reported as not ready when the endpoint can't be reached.
 func (p *BrokerProvider) NodeConditions(ctx context.Context) []v1.NodeCondition {
 	var nodeConditions []v1.NodeCondition
-	err := p.doGetRequest("/nodeConditions", &nodeConditions)
-
-	// TODO: This API should support reporting an error.
-	if err != nil {
-		panic(err)
+	if err := p.doGetRequest(ctx, "/nodeConditions", nil, &nodeConditions); err != nil {
+		log.G(ctx).WithError(err).Error("Error getting the node conditions of the web endpoint")
+		now := metav1.Now()
+		return []v1.NodeCondition{{
+			Type:               v1.NodeReady,
+			Status:             v1.ConditionFalse,
+			LastHeartbeatTime:  now,
+			LastTransitionTime: now,
+			Reason:             "EndpointUnavailable",
+			Message:            err.Error(),
+		}}
 	}
 
 	return nodeConditions
@@ -196,13 +352,12 @@ func (p *BrokerProvider) NodeConditions(ctx context.Context) []v1.NodeCondition
 
 // NodeAddresses returns a list of addresses for the node status
 // within Kubernetes.
+// No addresses are reported when the endpoint can't be reached.
 func (p *BrokerProvider) NodeAddresses(ctx context.Context) []v1.NodeAddress {
 	var nodeAddresses []v1.NodeAddress
-	err := p.doGetRequest("/nodeAddresses", &nodeAddresses)
-
-	// TODO: This API should support reporting an error.
-	if err != nil {
-		panic(err)
+	if err := p.doGetRequest(ctx, "/nodeAddresses", nil, &nodeAddresses); err != nil {
+		log.G(ctx).WithError(err).Error("Error getting the node addresses of the web endpoint")
+		return nil
 	}
 
 	return nodeAddresses
@@ -223,79 +378,190 @@ func (p *BrokerProvider) OperatingSystem() string {
 	return p.operatingSystem
 }
 
-func (p *BrokerProvider) doGetRequest(urlPathStr string, v interface{}) error {
-	response, err := p.doGetRequestBytes(urlPathStr)
+func (p *BrokerProvider) doGetRequest(ctx context.Context, urlPath string, query url.Values, v interface{}) error {
+	response, err := p.doRequest(ctx, http.MethodGet, urlPath, query, nil)
 	if err != nil {
 		return err
 	}
 
-	return json.Unmarshal(response, &v)
+	return json.Unmarshal(response, v)
 }
 
-func (p *BrokerProvider) doGetRequestBytes(urlPathStr string) ([]byte, error) {
-	urlPath, err := url.Parse(urlPathStr)
+func (p *BrokerProvider) sendPod(ctx context.Context, pod *v1.Pod, method, urlPath string) error {
+	// encode pod definition as JSON and send request
+	podJSON, err := json.Marshal(pod)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return p.doRequest("GET", urlPath, nil, true)
+	_, err = p.doRequest(ctx, method, urlPath, nil, podJSON)
+	return err
 }
 
-func (p *BrokerProvider) createUpdatePod(pod *v1.Pod, method, postPath string) error {
-	// build the post url
-	postPathURL, err := url.Parse(postPath)
-	if err != nil {
+// url returns the URL of the specified path of the endpoint.
+func (p *BrokerProvider) url(urlPath string, query url.Values) *url.URL {
+	return p.endpoint.ResolveReference(&url.URL{Path: urlPath, RawQuery: query.Encode()})
+}
+
+// header returns the headers sent with every request, such as the bearer token.
+func (p *BrokerProvider) header() (http.Header, error) {
+	header := http.Header{}
+	token := p.bearerToken
+	if p.bearerTokenFile != "" {
+		b, err := ioutil.ReadFile(p.bearerTokenFile)
+		if err != nil {
+			return nil, err
+		}
+		token = strings.TrimSpace(string(b))
+	}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return header, nil
+}
+
+// doRequest sends a request to the endpoint and returns the body of its response.
+// Idempotent requests are retried with an exponential back-off when they fail because of the network or a server error.
+func (p *BrokerProvider) doRequest(ctx context.Context, method, urlPath string, query url.Values, body []byte) ([]byte, error) {
+	requestURL := p.url(urlPath, query).String()
+
+	var response []byte
+	operation := func() error {
+		var err error
+		response, err = p.doRequestOnce(ctx, method, requestURL, body)
+		if err != nil && !isRetryable(err) {
+			return backoff.Permanent(err)
+		}
 		return err
 	}
 
-	// encode pod definition as JSON and post request
-	podJSON, err := json.Marshal(pod)
+	if !isIdempotent(method) {
+		if err := operation(); err != nil {
+			return nil, unwrapPermanent(err)
+		}
+		return response, nil
+	}
+
+	retry := backoff.NewExponentialBackOff()
+	retry.MaxElapsedTime = p.retryTimeout
+	err := backoff.RetryNotify(operation, backoff.WithContext(retry, ctx), func(err error, next time.Duration) {
+		log.G(ctx).WithError(err).WithField("method", method).WithField("path", urlPath).Debugf("Retrying request in %v", next)
+	})
 	if err != nil {
-		return err
+		return nil, unwrapPermanent(err)
 	}
-	_, err = p.doRequest(method, postPathURL, podJSON, false)
-	return err
+	return response, nil
 }
 
-func (p *BrokerProvider) doRequest(method string, urlPath *url.URL, body []byte, readResponse bool) ([]byte, error) {
-	// build full URL
-	requestURL := p.endpoint.ResolveReference(urlPath)
+// doRequestOnce sends a single request to the endpoint and returns the body of its response.
+func (p *BrokerProvider) doRequestOnce(ctx context.Context, method, requestURL string, body []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.requestTimeout)
+	defer cancel()
 
-	// build the request
+	// The body is read anew for each attempt, as it is consumed by the previous ones.
 	var bodyReader io.Reader
 	if body != nil {
 		bodyReader = bytes.NewReader(body)
 	}
-	request, err := http.NewRequest(method, requestURL.String(), bodyReader)
-	request.Header.Add("Content-Type", "application/json")
-
-	// issue request
-	retry := backoff.NewExponentialBackOff()
-	retry.MaxElapsedTime = 5 * time.Minute
-
-	var response *http.Response
-	err = backoff.Retry(func() error {
-		response, err = p.client.Do(request)
-		return err
-	}, retry)
+	request, err := http.NewRequest(method, requestURL, bodyReader)
 	if err != nil {
 		return nil, err
 	}
+	request = request.WithContext(ctx)
+	if request.Header, err = p.header(); err != nil {
+		return nil, err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
 
+	response, err := p.client.Do(request)
+	if err != nil {
+		return nil, &networkError{err}
+	}
 	defer response.Body.Close()
+
 	if response.StatusCode < 200 || response.StatusCode > 299 {
-		switch response.StatusCode {
-		case http.StatusNotFound:
-			return nil, strongerrors.NotFound(errors.New(response.Status))
-		default:
-			return nil, errors.New(response.Status)
-		}
+		b, _ := ioutil.ReadAll(io.LimitReader(response.Body, maxErrorMessageLength))
+		return nil, errorFromResponse(response, b)
 	}
 
-	// read response body if asked to
-	if readResponse {
-		return ioutil.ReadAll(response.Body)
+	b, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, &networkError{err}
 	}
+	return b, nil
+}
+
+// networkError is an error which occurred while sending a request or receiving its response.
+type networkError struct {
+	error
+}
 
-	return nil, nil
+// serverError is an error response of the endpoint which may succeed if the request is retried.
+type serverError struct {
+	error
+}
+
+// isRetryable returns whether a request which failed with the specified error may succeed if it is retried.
+func isRetryable(err error) bool {
+	switch err.(type) {
+	case *networkError, *serverError:
+		return true
+	}
+	return false
+}
+
+// isIdempotent returns whether requests with the specified method can be sent several times with the same effect.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// unwrapPermanent returns the error wrapped by the specified error if it is a permanent back-off, network or server error.
+func unwrapPermanent(err error) error {
+	if pe, ok := err.(*backoff.PermanentError); ok {
+		err = pe.Err
+	}
+	if ne, ok := err.(*networkError); ok {
+		err = ne.error
+	}
+	if se, ok := err.(*serverError); ok {
+		err = se.error
+	}
+	return err
+}
+
+// errorFromResponse returns the error corresponding to the specified response of the endpoint.
+func errorFromResponse(response *http.Response, body []byte) error {
+	err := errors.New(response.Status)
+	if msg := strings.TrimSpace(string(body)); msg != "" {
+		err = fmt.Errorf("%s: %s", response.Status, msg)
+	}
+
+	switch response.StatusCode {
+	case http.StatusBadRequest:
+		return strongerrors.InvalidArgument(err)
+	case http.StatusUnauthorized:
+		return strongerrors.Unauthenticated(err)
+	case http.StatusForbidden:
+		return strongerrors.Forbidden(err)
+	case http.StatusNotFound:
+		return strongerrors.NotFound(err)
+	case http.StatusConflict:
+		return strongerrors.Conflict(err)
+	case http.StatusNotImplemented:
+		return strongerrors.NotImplemented(err)
+	case http.StatusTooManyRequests:
+		return &serverError{strongerrors.Exhausted(err)}
+	case http.StatusServiceUnavailable:
+		return &serverError{strongerrors.Unavailable(err)}
+	}
+	if response.StatusCode >= 500 {
+		return &serverError{err}
+	}
+	return err
 }
@@ -0,0 +1,160 @@
+package web_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/cpuguy83/strongerrors"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers/web"
+)
+
+const token = "secret"
+
+// newProvider returns a provider forwarding calls to the specified server, configured with a bearer token.
+func newProvider(t *testing.T, srv *httptest.Server) *web.BrokerProvider {
+	f, err := ioutil.TempFile("", "web")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	fmt.Fprintf(f, "Endpoint = %q\nBearerToken = %q\nRequestTimeout = \"5s\"\nRetryTimeout = \"5s\"\n", srv.URL, token)
+	require.NoError(t, f.Close())
+
+	p, err := web.NewBrokerProvider(f.Name(), "vk", "Linux", 10250)
+	require.NoError(t, err)
+	return p
+}
+
+// authorized wraps the specified handler to reject requests without the bearer token.
+func authorized(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			http.Error(w, "missing token", http.StatusUnauthorized)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// TestRequests verifies that errors of the endpoint are propagated, and that only idempotent requests are retried.
+func TestRequests(t *testing.T) {
+	var getPodsCalls, createPodCalls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/getPods", authorized(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&getPodsCalls, 1) == 1 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`[{"metadata":{"namespace":"default","name":"pod-0"}}]`))
+	}))
+	mux.HandleFunc("/createPod", authorized(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&createPodCalls, 1)
+		http.Error(w, "try again", http.StatusServiceUnavailable)
+	}))
+	mux.HandleFunc("/getPod", authorized(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	mux.HandleFunc("/updatePod", authorized(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid pod", http.StatusBadRequest)
+	}))
+	mux.HandleFunc("/nodeConditions", authorized(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "broken", http.StatusNotImplemented)
+	}))
+	mux.HandleFunc("/getStatsSummary", authorized(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"node":{"nodeName":"vk"}}`))
+	}))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	p := newProvider(t, srv)
+	ctx := context.Background()
+
+	pods, err := p.GetPods(ctx)
+	require.NoError(t, err)
+	require.Len(t, pods, 1)
+	assert.Equal(t, "pod-0", pods[0].Name)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&getPodsCalls))
+
+	pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod-0"}}
+	err = p.CreatePod(ctx, pod)
+	assert.True(t, strongerrors.IsUnavailable(err), "unexpected error: %v", err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&createPodCalls))
+
+	err = p.UpdatePod(ctx, pod)
+	assert.True(t, strongerrors.IsInvalidArgument(err), "unexpected error: %v", err)
+	assert.Contains(t, err.Error(), "invalid pod")
+
+	got, err := p.GetPod(ctx, "default", "pod-0")
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	// Methods which can't return errors report them without panicking.
+	assert.Nil(t, p.Capacity(ctx))
+	conditions := p.NodeConditions(ctx)
+	require.Len(t, conditions, 1)
+	assert.Equal(t, v1.ConditionFalse, conditions[0].Status)
+
+	summary, err := p.GetStatsSummary(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "vk", summary.Node.NodeName)
+}
+
+// TestExecInContainer verifies that commands are run by the endpoint over a websocket connection.
+func TestExecInContainer(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(authorized(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		q := r.URL.Query()
+		if q.Get("container") != "container-0" {
+			conn.WriteMessage(websocket.BinaryMessage, append([]byte{3}, "container not found"...))
+			return
+		}
+		conn.WriteMessage(websocket.BinaryMessage, append([]byte{2}, strings.Join(q["command"], " ")...))
+
+		// Echo the input of the command until it ends.
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil || msg[0] != 0 || len(msg) == 1 {
+				break
+			}
+			conn.WriteMessage(websocket.BinaryMessage, append([]byte{1}, msg[1:]...))
+		}
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	}))
+	defer srv.Close()
+	p := newProvider(t, srv)
+
+	var stdout, stderr nopWriteCloser
+	err := p.ExecInContainer("default-pod-0", "pod-0", "container-0", []string{"cat", "-"}, strings.NewReader("hello"), &stdout, &stderr, false, nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "hello", stdout.String())
+	assert.Equal(t, "cat -", stderr.String())
+
+	err = p.ExecInContainer("default-pod-0", "pod-0", "missing", []string{"cat"}, nil, &stdout, &stderr, false, nil, 0)
+	assert.EqualError(t, err, "error executing command in container: container not found")
+}
+
+// nopWriteCloser is a buffer implementing io.WriteCloser.
+type nopWriteCloser struct {
+	bytes.Buffer
+}
+
+func (*nopWriteCloser) Close() error {
+	return nil
+}
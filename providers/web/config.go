@@ -0,0 +1,159 @@
+package web
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	// endpointEnv is the environment variable holding the URL of the web endpoint when it isn't set in the config file.
+	endpointEnv = "WEB_ENDPOINT_URL"
+
+	// Provider configuration defaults.
+	defaultRequestTimeout = 30 * time.Second
+	defaultRetryTimeout   = time.Minute
+)
+
+// providerConfig represents the contents of the provider configuration file.
+type providerConfig struct {
+	// Endpoint is the URL of the web endpoint, such as "https://localhost:3000".
+	// It defaults to the value of the WEB_ENDPOINT_URL environment variable.
+	Endpoint string
+	// RequestTimeout is the maximum duration of each request to the endpoint, such as "30s".
+	RequestTimeout string
+	// RetryTimeout is the maximum amount of time spent retrying failed idempotent requests, such as "1m".
+	// Non-idempotent requests, such as the creation of pods, are never retried.
+	RetryTimeout string
+
+	// BearerToken is sent in the Authorization header of all the requests to the endpoint.
+	BearerToken string
+	// BearerTokenFile is a file holding the bearer token, which is read for every request so that rotated tokens are used.
+	BearerTokenFile string
+
+	// CACertFile is a PEM encoded bundle of the certificate authorities used to verify the certificate of the endpoint.
+	// The certificate authorities of the host are used when it is empty.
+	CACertFile string
+	// ClientCertFile and ClientKeyFile are the PEM encoded certificate and key used to authenticate to the endpoint over mutual TLS.
+	ClientCertFile string
+	ClientKeyFile  string
+}
+
+// loadConfigFile loads the given web provider configuration file.
+// The endpoint is read from the environment when no configuration file is given.
+func (p *BrokerProvider) loadConfigFile(filePath string) error {
+	if filePath == "" {
+		return p.loadConfig(nil)
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return p.loadConfig(f)
+}
+
+// loadConfig loads the given web provider TOML configuration stream, which may be nil.
+func (p *BrokerProvider) loadConfig(r io.Reader) error {
+	var config providerConfig
+	if r != nil {
+		if _, err := toml.DecodeReader(r, &config); err != nil {
+			return err
+		}
+	}
+
+	if config.Endpoint == "" {
+		config.Endpoint = os.Getenv(endpointEnv)
+	}
+	if config.Endpoint == "" {
+		return fmt.Errorf("Endpoint is a required field, unless %s is set", endpointEnv)
+	}
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return fmt.Errorf("Invalid endpoint %v: %v", config.Endpoint, err)
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" {
+		return fmt.Errorf("Endpoint %v must be an http or https URL", config.Endpoint)
+	}
+
+	requestTimeout, err := parseDuration(config.RequestTimeout, defaultRequestTimeout)
+	if err != nil {
+		return fmt.Errorf("Invalid request timeout %v", config.RequestTimeout)
+	}
+	retryTimeout, err := parseDuration(config.RetryTimeout, defaultRetryTimeout)
+	if err != nil {
+		return fmt.Errorf("Invalid retry timeout %v", config.RetryTimeout)
+	}
+
+	if config.BearerToken != "" && config.BearerTokenFile != "" {
+		return fmt.Errorf("BearerToken and BearerTokenFile are mutually exclusive")
+	}
+	if (config.ClientCertFile == "") != (config.ClientKeyFile == "") {
+		return fmt.Errorf("ClientCertFile and ClientKeyFile must be set together")
+	}
+	tlsConfig, err := newTLSConfig(config)
+	if err != nil {
+		return err
+	}
+
+	// Populate provider fields.
+	p.endpoint = endpoint
+	p.requestTimeout = requestTimeout
+	p.retryTimeout = retryTimeout
+	p.bearerToken = config.BearerToken
+	p.bearerTokenFile = config.BearerTokenFile
+	p.tlsConfig = tlsConfig
+
+	return nil
+}
+
+// parseDuration parses the specified duration, returning the default value when it is empty.
+func parseDuration(s string, defaultValue time.Duration) (time.Duration, error) {
+	if s == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("duration must be positive")
+	}
+	return d, nil
+}
+
+// newTLSConfig returns the TLS configuration used to connect to the endpoint, or nil when the defaults must be used.
+func newTLSConfig(config providerConfig) (*tls.Config, error) {
+	if config.CACertFile == "" && config.ClientCertFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if config.CACertFile != "" {
+		pem, err := ioutil.ReadFile(config.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("No valid certificate found in %v", config.CACertFile)
+		}
+	}
+	if config.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.ClientCertFile, config.ClientKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
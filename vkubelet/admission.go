@@ -0,0 +1,186 @@
+package vkubelet
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	pkgerrors "github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/virtual-kubelet/virtual-kubelet/log"
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+)
+
+const (
+	// reasonOutOfResourcePrefix is prepended to the name of the exhausted resource in the reason of rejected pods.
+	// This results in the same "OutOfcpu", "OutOfmemory" and "OutOfpods" reasons as used by the Kubelet.
+	reasonOutOfResourcePrefix = "OutOf"
+)
+
+// standardResources are the resources which are only checked when reported in the capacity of the provider.
+// Providers which do not report one of them are considered not to limit it.
+var standardResources = map[corev1.ResourceName]bool{
+	corev1.ResourceCPU:              true,
+	corev1.ResourceMemory:           true,
+	corev1.ResourceEphemeralStorage: true,
+	corev1.ResourcePods:             true,
+}
+
+// capacityAdmitHandler rejects pods whose resource requests don't fit in the capacity reported by the provider,
+// after accounting for the requests of the other pods on the node.
+type capacityAdmitHandler struct {
+	provider providers.Provider
+}
+
+// AdmitPod implements providers.PodAdmitHandler.
+// https://github.com/kubernetes/kubernetes/blob/v1.13.1/pkg/scheduler/algorithm/predicates/predicates.go#L719-L800
+func (h *capacityAdmitHandler) AdmitPod(ctx context.Context, pod *corev1.Pod, otherPods []*corev1.Pod) providers.PodAdmitResult {
+	capacity := h.provider.Capacity(ctx)
+
+	requested := podRequests(pod)
+	requested[corev1.ResourcePods] = *resource.NewQuantity(1, resource.DecimalSI)
+
+	used := corev1.ResourceList{
+		corev1.ResourcePods: *resource.NewQuantity(int64(len(otherPods)), resource.DecimalSI),
+	}
+	for _, p := range otherPods {
+		for name, q := range podRequests(p) {
+			addQuantity(used, name, q)
+		}
+	}
+
+	// Check the number of pods first, then the other resources by name, so that the reason is deterministic.
+	names := make([]string, 0, len(requested))
+	for name := range requested {
+		if name != corev1.ResourcePods {
+			names = append(names, string(name))
+		}
+	}
+	sort.Strings(names)
+	names = append([]string{string(corev1.ResourcePods)}, names...)
+
+	for _, n := range names {
+		name := corev1.ResourceName(n)
+		req := requested[name]
+		if req.IsZero() {
+			continue
+		}
+		avail, ok := capacity[name]
+		if !ok && standardResources[name] {
+			continue
+		}
+
+		total := used[name]
+		total.Add(req)
+		if total.Cmp(avail) > 0 {
+			u := used[name]
+			return providers.PodAdmitResult{
+				Reason: reasonOutOfResourcePrefix + string(name),
+				Message: fmt.Sprintf("Node didn't have enough resource: %s, requested: %s, used: %s, capacity: %s",
+					name, req.String(), u.String(), avail.String()),
+			}
+		}
+	}
+	return providers.PodAdmitResult{Admit: true}
+}
+
+// podRequests returns the resources requested by the specified pod.
+// As init containers run one at a time before the other containers, this is the largest of the sum of the requests of the
+// containers and of the requests of every init container.
+// https://github.com/kubernetes/kubernetes/blob/v1.13.1/pkg/scheduler/algorithm/predicates/predicates.go#L683-L717
+func podRequests(pod *corev1.Pod) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, c := range pod.Spec.Containers {
+		for name, q := range c.Resources.Requests {
+			addQuantity(requests, name, q)
+		}
+	}
+	for _, c := range pod.Spec.InitContainers {
+		for name, q := range c.Resources.Requests {
+			if current, ok := requests[name]; !ok || q.Cmp(current) > 0 {
+				requests[name] = q.DeepCopy()
+			}
+		}
+	}
+	return requests
+}
+
+// addQuantity adds the specified quantity to the named resource of the list.
+func addQuantity(list corev1.ResourceList, name corev1.ResourceName, q resource.Quantity) {
+	if current, ok := list[name]; ok {
+		current.Add(q)
+		list[name] = current
+		return
+	}
+	list[name] = q.DeepCopy()
+}
+
+// admitPod runs the specified pod through the admit handlers of the server, returning the result of the first one which
+// rejects it.
+func (s *Server) admitPod(ctx context.Context, pod *corev1.Pod) providers.PodAdmitResult {
+	ctx, span := trace.StartSpan(ctx, "admitPod")
+	defer span.End()
+	addPodAttributes(span, pod)
+
+	otherPods := s.activePodsBefore(pod)
+	for _, h := range s.admitHandlers {
+		if result := h.AdmitPod(ctx, pod, otherPods); !result.Admit {
+			span.Annotate([]trace.Attribute{trace.StringAttribute("reason", result.Reason)}, "Pod rejected")
+			return result
+		}
+	}
+	return providers.PodAdmitResult{Admit: true}
+}
+
+// activePodsBefore returns the non-terminated pods on the node which were created before the specified pod.
+// As done by the Kubelet, pods are admitted in creation order, so that a newer pod can't take the resources of an older
+// one which has not been created in the provider yet.
+func (s *Server) activePodsBefore(pod *corev1.Pod) []*corev1.Pod {
+	var pods []*corev1.Pod
+	for _, p := range s.resourceManager.GetPods() {
+		if p.UID == pod.UID || p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if createdBefore(p, pod) {
+			pods = append(pods, p)
+		}
+	}
+	return pods
+}
+
+// createdBefore returns whether p1 was created before p2, breaking ties by namespace and name.
+func createdBefore(p1, p2 *corev1.Pod) bool {
+	if !p1.CreationTimestamp.Equal(&p2.CreationTimestamp) {
+		return p1.CreationTimestamp.Before(&p2.CreationTimestamp)
+	}
+	if p1.Namespace != p2.Namespace {
+		return p1.Namespace < p2.Namespace
+	}
+	return p1.Name < p2.Name
+}
+
+// rejectPod marks the specified pod as failed with the reason and message of the admission result.
+// The pod is copied before its status is updated, as it is owned by the informer cache.
+func (s *Server) rejectPod(ctx context.Context, pod *corev1.Pod, result providers.PodAdmitResult, recorder record.EventRecorder) error {
+	logger := log.G(ctx).WithField("pod", pod.GetName()).WithField("namespace", pod.GetNamespace())
+
+	recorder.Event(pod, corev1.EventTypeWarning, result.Reason, result.Message)
+
+	pod = pod.DeepCopy()
+	pod.ResourceVersion = "" // Blank out resource version to prevent object has been modified error
+	pod.Status.Phase = corev1.PodFailed
+	pod.Status.Reason = result.Reason
+	pod.Status.Message = result.Message
+
+	logger.WithField("reason", result.Reason).Warn("Pod rejected")
+
+	if _, err := s.k8sClient.CoreV1().Pods(pod.Namespace).UpdateStatus(pod); err != nil {
+		s.recordUpdateConflict(ctx, "pod", err)
+		return pkgerrors.Wrap(err, "error updating status of rejected pod")
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package vkubelet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+	testutil "github.com/virtual-kubelet/virtual-kubelet/test/util"
+)
+
+// admissionPod returns a pod created at the specified time, with a single container requesting the specified resources.
+func admissionPod(name string, created time.Time, requests corev1.ResourceList) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              name,
+			UID:               types.UID(name),
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name:      "container-0",
+					Resources: corev1.ResourceRequirements{Requests: requests},
+				},
+			},
+		},
+	}
+}
+
+// rejectingAdmitHandler is an admit handler which rejects every pod.
+type rejectingAdmitHandler struct{}
+
+func (rejectingAdmitHandler) AdmitPod(context.Context, *corev1.Pod, []*corev1.Pod) providers.PodAdmitResult {
+	return providers.PodAdmitResult{Reason: "TaskTooLarge", Message: "pod is too large"}
+}
+
+// TestAdmitPod verifies that pods are admitted in creation order until the capacity of the provider is exhausted.
+func TestAdmitPod(t *testing.T) {
+	now := time.Now()
+	oneCPU := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")}
+
+	running := admissionPod("running", now, oneCPU)
+	completed := admissionPod("completed", now, oneCPU)
+	completed.Status.Phase = corev1.PodSucceeded
+	newer := admissionPod("newer", now.Add(2*time.Minute), oneCPU)
+	pod := admissionPod("pod", now.Add(time.Minute), corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("1"),
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+	})
+
+	p := &capacityProvider{
+		capacity: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("2"),
+			corev1.ResourceMemory: resource.MustParse("2Gi"),
+			corev1.ResourcePods:   resource.MustParse("2"),
+		},
+	}
+	s := New(Config{
+		Provider:        p,
+		ResourceManager: testutil.FakeResourceManager(running, completed, newer, pod),
+	})
+
+	result := s.admitPod(context.Background(), pod)
+	assert.True(t, result.Admit, result.Message)
+
+	// The newer pod doesn't fit, as the older pods use up both the pods and the cpu of the node.
+	result = s.admitPod(context.Background(), newer)
+	assert.False(t, result.Admit)
+	assert.Equal(t, "OutOfpods", result.Reason)
+
+	p.capacity[corev1.ResourcePods] = resource.MustParse("10")
+	result = s.admitPod(context.Background(), newer)
+	assert.False(t, result.Admit)
+	assert.Equal(t, "OutOfcpu", result.Reason)
+	assert.Equal(t, "Node didn't have enough resource: cpu, requested: 1, used: 2, capacity: 2", result.Message)
+
+	p.capacity[corev1.ResourceCPU] = resource.MustParse("4")
+	p.capacity[corev1.ResourceMemory] = resource.MustParse("512Mi")
+	result = s.admitPod(context.Background(), pod)
+	assert.False(t, result.Admit)
+	assert.Equal(t, "OutOfmemory", result.Reason)
+
+	s = New(Config{
+		Provider:                 p,
+		ResourceManager:          testutil.FakeResourceManager(running, completed, newer, pod),
+		DisableCapacityAdmission: true,
+	})
+	result = s.admitPod(context.Background(), pod)
+	assert.True(t, result.Admit, result.Message)
+}
+
+// TestAdmitPodResources verifies how init containers, extended resources and resources the provider doesn't report are
+// accounted for.
+func TestAdmitPodResources(t *testing.T) {
+	pod := admissionPod("pod", time.Now(), corev1.ResourceList{
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+	})
+	pod.Spec.InitContainers = []corev1.Container{
+		{
+			Name: "init-0",
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")},
+			},
+		},
+	}
+	assert.Equal(t, corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("3"),
+		corev1.ResourceMemory: resource.MustParse("1Gi"),
+	}, podRequests(pod))
+
+	p := &capacityProvider{
+		capacity: corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse("4"),
+		},
+	}
+	s := New(Config{
+		Provider:        p,
+		ResourceManager: testutil.FakeResourceManager(pod),
+	})
+	result := s.admitPod(context.Background(), pod)
+	assert.True(t, result.Admit, result.Message)
+
+	pod.Spec.Containers[0].Resources.Requests["example.com/gpu"] = resource.MustParse("1")
+	result = s.admitPod(context.Background(), pod)
+	assert.False(t, result.Admit)
+	assert.Equal(t, "OutOfexample.com/gpu", result.Reason)
+
+	p.capacity["example.com/gpu"] = resource.MustParse("1")
+	result = s.admitPod(context.Background(), pod)
+	assert.True(t, result.Admit, result.Message)
+
+	s = New(Config{
+		Provider:         p,
+		ResourceManager:  testutil.FakeResourceManager(pod),
+		PodAdmitHandlers: []providers.PodAdmitHandler{rejectingAdmitHandler{}},
+	})
+	result = s.admitPod(context.Background(), pod)
+	assert.False(t, result.Admit)
+	assert.Equal(t, "TaskTooLarge", result.Reason)
+}
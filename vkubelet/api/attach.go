@@ -0,0 +1,54 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/remotecommand"
+	remoteserver "k8s.io/kubernetes/pkg/kubelet/server/remotecommand"
+)
+
+// ContainerAttachBackend is used in place of backend implementations for attaching to the running process of a container
+type ContainerAttachBackend interface {
+	AttachToContainer(name string, uid types.UID, container string, in io.Reader, out, err io.WriteCloser, tty bool, resize <-chan remotecommand.TerminalSize) error
+}
+
+// PodAttachHandlerFunc makes an http handler func from a Provider which attaches to the running process of a pod's container
+// Note that this handler currently depends on gorrilla/mux to get url parts as variables.
+func PodAttachHandlerFunc(backend ContainerAttachBackend, opts ...StreamOption) http.HandlerFunc {
+	cfg := newStreamConfig(opts)
+	attacher := &attacher{b: backend}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+
+		namespace := vars["namespace"]
+		pod := vars["pod"]
+		container := vars["container"]
+
+		supportedStreamProtocols := strings.Split(req.Header.Get("X-Stream-Protocol-Version"), ",")
+
+		// Parse the "input", "output", "error" and "tty" query parameters, to which the API server maps the stdin,
+		// stdout, stderr and tty options of the attach request.
+		streamOpts, err := remoteserver.NewOptions(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		remoteserver.ServeAttach(w, req, attacher, fmt.Sprintf("%s-%s", namespace, pod), "", container, streamOpts, cfg.IdleTimeout, cfg.CreationTimeout, supportedStreamProtocols)
+	}
+}
+
+// attacher adapts a ContainerAttachBackend to the interface expected by the kubelet's remote command server.
+type attacher struct {
+	b ContainerAttachBackend
+}
+
+func (a *attacher) AttachContainer(name string, uid types.UID, container string, in io.Reader, out, err io.WriteCloser, tty bool, resize <-chan remotecommand.TerminalSize) error {
+	return a.b.AttachToContainer(name, uid, container, in, out, err, tty, resize)
+}
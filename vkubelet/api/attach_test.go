@@ -0,0 +1,68 @@
+package api_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
+)
+
+// fakeAttachBackend serves the attachments to containers with a streamSession.
+type fakeAttachBackend struct {
+	streamSession
+}
+
+func (b *fakeAttachBackend) AttachToContainer(name string, uid types.UID, container string, in io.Reader, out, err io.WriteCloser, tty bool, resize <-chan remotecommand.TerminalSize) error {
+	return b.serve(name, container, in, out, err, tty, resize)
+}
+
+func TestPodAttachHandlerFunc(t *testing.T) {
+	b := &fakeAttachBackend{}
+	srv := serveStream("/attach/{namespace}/{pod}/{container}", api.PodAttachHandlerFunc(b))
+	defer srv.Close()
+
+	var stdout, stderr bytes.Buffer
+	err := stream(t, srv, "/attach/default/pod-0/app?input=1&output=1&error=1", remotecommand.StreamOptions{
+		Stdin:  strings.NewReader("input"),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	require.NoError(t, err)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	assert.Equal(t, "input", stdout.String())
+	assert.Equal(t, "error", stderr.String())
+	assert.Equal(t, "default-pod-0", b.name)
+	assert.Equal(t, "app", b.container)
+	assert.False(t, b.tty)
+}
+
+func TestPodAttachHandlerFuncTTY(t *testing.T) {
+	b := &fakeAttachBackend{}
+	srv := serveStream("/attach/{namespace}/{pod}/{container}", api.PodAttachHandlerFunc(b))
+	defer srv.Close()
+
+	var stdout bytes.Buffer
+	size := remotecommand.TerminalSize{Width: 120, Height: 40}
+	err := stream(t, srv, "/attach/default/pod-0/app?input=1&output=1&tty=1", remotecommand.StreamOptions{
+		Stdin:             strings.NewReader("ls\n"),
+		Stdout:            &stdout,
+		Tty:               true,
+		TerminalSizeQueue: &fixedSizeQueue{size: &size},
+	})
+	require.NoError(t, err)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	assert.Equal(t, "ls\n", stdout.String())
+	assert.True(t, b.tty)
+	assert.False(t, b.hasStderr)
+	require.NotNil(t, b.size)
+	assert.Equal(t, size, *b.size)
+}
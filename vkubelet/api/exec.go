@@ -10,10 +10,55 @@ import (
 	"k8s.io/kubernetes/pkg/kubelet/server/remotecommand"
 )
 
+const (
+	// DefaultStreamIdleTimeout is the default amount of time a streaming connection may be idle before it is closed.
+	DefaultStreamIdleTimeout = 30 * time.Second
+	// DefaultStreamCreationTimeout is the default amount of time to wait for the client to create the streams of a streaming connection.
+	DefaultStreamCreationTimeout = 30 * time.Second
+)
+
+// StreamConfig holds the configuration of the handlers serving streaming connections, such as exec.
+type StreamConfig struct {
+	// IdleTimeout is the amount of time a streaming connection may be idle before it is closed.
+	IdleTimeout time.Duration
+	// CreationTimeout is the amount of time to wait for the client to create the streams of a streaming connection.
+	CreationTimeout time.Duration
+}
+
+// StreamOption is used to change the configuration of the handlers serving streaming connections.
+type StreamOption func(*StreamConfig)
+
+// WithStreamIdleTimeout sets the amount of time a streaming connection may be idle before it is closed.
+func WithStreamIdleTimeout(d time.Duration) StreamOption {
+	return func(cfg *StreamConfig) {
+		cfg.IdleTimeout = d
+	}
+}
+
+// WithStreamCreationTimeout sets the amount of time to wait for the client to create the streams of a streaming connection.
+func WithStreamCreationTimeout(d time.Duration) StreamOption {
+	return func(cfg *StreamConfig) {
+		cfg.CreationTimeout = d
+	}
+}
+
+func newStreamConfig(opts []StreamOption) StreamConfig {
+	cfg := StreamConfig{
+		IdleTimeout:     DefaultStreamIdleTimeout,
+		CreationTimeout: DefaultStreamCreationTimeout,
+	}
+	for _, o := range opts {
+		o(&cfg)
+	}
+	return cfg
+}
+
 // PodExecHandlerFunc makes an http handler func from a Provider which execs a command in a pod's container
 // Note that this handler currently depends on gorrilla/mux to get url parts as variables.
 // TODO(@cpuguy83): don't force gorilla/mux on consumers of this function
-func PodExecHandlerFunc(backend remotecommand.Executor) http.HandlerFunc {
+func PodExecHandlerFunc(backend remotecommand.Executor, opts ...StreamOption) http.HandlerFunc {
+	cfg := newStreamConfig(opts)
+
 	return func(w http.ResponseWriter, req *http.Request) {
 		vars := mux.Vars(req)
 
@@ -26,17 +71,15 @@ func PodExecHandlerFunc(backend remotecommand.Executor) http.HandlerFunc {
 		q := req.URL.Query()
 		command := q["command"]
 
-		// TODO: tty flag causes remotecommand.createStreams to wait for the wrong number of streams
-		streamOpts := &remotecommand.Options{
-			Stdin:  true,
-			Stdout: true,
-			Stderr: true,
-			TTY:    false,
+		// Parse the "input", "output", "error" and "tty" query parameters, to which the API server maps the stdin,
+		// stdout, stderr and tty options of the exec request.
+		// Stderr is disabled when a TTY is requested, as the client then only creates a single output stream.
+		streamOpts, err := remotecommand.NewOptions(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
 
-		idleTimeout := time.Second * 30
-		streamCreationTimeout := time.Second * 30
-
-		remotecommand.ServeExec(w, req, backend, fmt.Sprintf("%s-%s", namespace, pod), "", container, command, streamOpts, idleTimeout, streamCreationTimeout, supportedStreamProtocols)
+		remotecommand.ServeExec(w, req, backend, fmt.Sprintf("%s-%s", namespace, pod), "", container, command, streamOpts, cfg.IdleTimeout, cfg.CreationTimeout, supportedStreamProtocols)
 	}
 }
@@ -0,0 +1,155 @@
+package api_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
+)
+
+// streamSession records the arguments a streaming backend was called with.
+type streamSession struct {
+	mu        sync.Mutex
+	name      string
+	container string
+	tty       bool
+	hasStderr bool
+	size      *remotecommand.TerminalSize
+}
+
+// serve writes the input received on stdin to stdout and "error" to stderr, after waiting for the size of the
+// terminal when a TTY is requested.
+func (s *streamSession) serve(name, container string, in io.Reader, out, errStream io.WriteCloser, tty bool, resize <-chan remotecommand.TerminalSize) error {
+	s.mu.Lock()
+	s.name, s.container, s.tty, s.hasStderr = name, container, tty, errStream != nil
+	s.mu.Unlock()
+
+	if tty {
+		select {
+		case size := <-resize:
+			s.mu.Lock()
+			s.size = &size
+			s.mu.Unlock()
+		case <-time.After(time.Second):
+		}
+	}
+	if in != nil && out != nil {
+		if _, err := io.Copy(out, in); err != nil {
+			return err
+		}
+	}
+	if errStream != nil {
+		io.WriteString(errStream, "error")
+	}
+	return nil
+}
+
+// fakeExecBackend serves the commands executed in containers with a streamSession.
+type fakeExecBackend struct {
+	streamSession
+	cmd []string
+}
+
+func (b *fakeExecBackend) ExecInContainer(name string, uid types.UID, container string, cmd []string, in io.Reader, out, err io.WriteCloser, tty bool, resize <-chan remotecommand.TerminalSize, timeout time.Duration) error {
+	b.mu.Lock()
+	b.cmd = cmd
+	b.mu.Unlock()
+	return b.serve(name, container, in, out, err, tty, resize)
+}
+
+// fixedSizeQueue reports a single terminal size.
+type fixedSizeQueue struct {
+	size *remotecommand.TerminalSize
+}
+
+func (q *fixedSizeQueue) Next() *remotecommand.TerminalSize {
+	size := q.size
+	q.size = nil
+	return size
+}
+
+// serveStream starts an HTTP server serving the specified handler on the specified route.
+func serveStream(route string, h http.HandlerFunc) *httptest.Server {
+	r := mux.NewRouter()
+	r.HandleFunc(route, h)
+	return httptest.NewServer(r)
+}
+
+// stream opens a remote command session with the specified server on the specified path.
+func stream(t *testing.T, srv *httptest.Server, path string, opts remotecommand.StreamOptions) error {
+	u, err := url.Parse(srv.URL + path)
+	require.NoError(t, err)
+	exec, err := remotecommand.NewSPDYExecutor(&rest.Config{Host: srv.URL}, http.MethodPost, u)
+	require.NoError(t, err)
+	return exec.Stream(opts)
+}
+
+func TestPodExecHandlerFunc(t *testing.T) {
+	b := &fakeExecBackend{}
+	srv := serveStream("/exec/{namespace}/{pod}/{container}", api.PodExecHandlerFunc(b))
+	defer srv.Close()
+
+	var stdout, stderr bytes.Buffer
+	err := stream(t, srv, "/exec/default/pod-0/app?command=echo&command=hi&input=1&output=1&error=1", remotecommand.StreamOptions{
+		Stdin:  strings.NewReader("input"),
+		Stdout: &stdout,
+		Stderr: &stderr,
+	})
+	require.NoError(t, err)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	assert.Equal(t, "input", stdout.String())
+	assert.Equal(t, "error", stderr.String())
+	assert.Equal(t, []string{"echo", "hi"}, b.cmd)
+	assert.Equal(t, "default-pod-0", b.name)
+	assert.Equal(t, "app", b.container)
+	assert.False(t, b.tty)
+}
+
+func TestPodExecHandlerFuncTTY(t *testing.T) {
+	b := &fakeExecBackend{}
+	srv := serveStream("/exec/{namespace}/{pod}/{container}", api.PodExecHandlerFunc(b))
+	defer srv.Close()
+
+	// Stderr isn't used along with a TTY, whose size is sent to the backend.
+	var stdout bytes.Buffer
+	size := remotecommand.TerminalSize{Width: 80, Height: 24}
+	err := stream(t, srv, "/exec/default/pod-0/app?command=sh&input=1&output=1&tty=1", remotecommand.StreamOptions{
+		Stdin:             strings.NewReader("ls\n"),
+		Stdout:            &stdout,
+		Tty:               true,
+		TerminalSizeQueue: &fixedSizeQueue{size: &size},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "ls\n", stdout.String())
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	assert.True(t, b.tty)
+	assert.False(t, b.hasStderr)
+	require.NotNil(t, b.size)
+	assert.Equal(t, size, *b.size)
+}
+
+func TestPodExecHandlerFuncInvalidOptions(t *testing.T) {
+	r := mux.NewRouter()
+	r.HandleFunc("/exec/{namespace}/{pod}/{container}", api.PodExecHandlerFunc(&fakeExecBackend{}))
+
+	// At least one stream must be requested.
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/exec/default/pod-0/app?command=sh&input=0&output=0&error=0", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code, rec.Body.String())
+}
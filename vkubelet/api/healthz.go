@@ -0,0 +1,98 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cpuguy83/strongerrors"
+	"github.com/pkg/errors"
+	"k8s.io/client-go/tools/cache"
+)
+
+// HealthCheck checks the health of a single component of the virtual-kubelet.
+type HealthCheck struct {
+	// Name identifies the component in the output of the health endpoint.
+	Name string
+	// Check returns an error when the component is not healthy.
+	Check func(context.Context) error
+}
+
+// ProviderHealthCheckTTL is how long the result of the provider health check is reused, so that frequent probes
+// don't list the pods of the provider every time.
+const ProviderHealthCheckTTL = 10 * time.Second
+
+// ProviderHealthCheck creates a health check which fails when the pods of the passed in backend cannot be listed.
+// Its result is cached for ProviderHealthCheckTTL.
+func ProviderHealthCheck(b PodListerBackend) HealthCheck {
+	return CachedHealthCheck(HealthCheck{
+		Name: "provider",
+		Check: func(ctx context.Context) error {
+			_, err := b.GetPods(ctx)
+			return err
+		},
+	}, ProviderHealthCheckTTL)
+}
+
+// CachedHealthCheck creates a health check which reuses the result of the passed in check for the specified duration.
+// Concurrent checks wait for the one in progress, and the results of the checks whose context is done aren't cached.
+func CachedHealthCheck(c HealthCheck, ttl time.Duration) HealthCheck {
+	var (
+		mu      sync.Mutex
+		err     error
+		checked time.Time
+	)
+	return HealthCheck{
+		Name: c.Name,
+		Check: func(ctx context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			if !checked.IsZero() && time.Since(checked) < ttl {
+				return err
+			}
+			checkErr := c.Check(ctx)
+			if ctx.Err() == nil {
+				err, checked = checkErr, time.Now()
+			}
+			return checkErr
+		},
+	}
+}
+
+// InformerSyncHealthCheck creates a health check which fails until the passed in informer has synced.
+func InformerSyncHealthCheck(name string, hasSynced cache.InformerSynced) HealthCheck {
+	return HealthCheck{
+		Name: name,
+		Check: func(context.Context) error {
+			if !hasSynced() {
+				return errors.New("informer has not synced")
+			}
+			return nil
+		},
+	}
+}
+
+// HealthzHandlerFunc makes an HTTP handler for implementing the kubelet health endpoint.
+// It serves http.StatusServiceUnavailable, along with the reason for each failing check, when any of the passed in checks fails.
+func HealthzHandlerFunc(checks ...HealthCheck) http.HandlerFunc {
+	return handleError(func(w http.ResponseWriter, req *http.Request) error {
+		var failed bytes.Buffer
+		for _, c := range checks {
+			if err := c.Check(req.Context()); err != nil {
+				fmt.Fprintf(&failed, "%s check failed: %v\n", c.Name, err)
+			}
+		}
+		if failed.Len() > 0 {
+			return strongerrors.Unavailable(errors.New(failed.String()))
+		}
+
+		w.Header().Set("Content-Type", "text/plain")
+		if _, err := w.Write([]byte("ok")); err != nil {
+			return strongerrors.Unknown(errors.Wrap(err, "could not write to client"))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,85 @@
+package api_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
+)
+
+func serveHealthz(checks ...api.HealthCheck) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	api.HealthzHandlerFunc(checks...)(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	return rec
+}
+
+func TestHealthzHandlerFunc(t *testing.T) {
+	synced := false
+	informer := api.InformerSyncHealthCheck("pod-informer", func() bool { return synced })
+	var providerErr error = errors.New("unreachable")
+	provider := api.HealthCheck{Name: "provider", Check: func(context.Context) error { return providerErr }}
+
+	rec := serveHealthz(provider, informer)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "provider check failed: unreachable")
+	assert.Contains(t, rec.Body.String(), "pod-informer check failed")
+
+	synced = true
+	rec = serveHealthz(provider, informer)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "pod-informer")
+
+	providerErr = nil
+	rec = serveHealthz(provider, informer)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "ok", rec.Body.String())
+}
+
+func TestProviderHealthCheck(t *testing.T) {
+	b := &fakePodListerBackend{}
+	c := api.ProviderHealthCheck(b)
+	ctx := context.Background()
+
+	// The provider is only asked for its pods once within the TTL of the check.
+	assert.NoError(t, c.Check(ctx))
+	b.err = errors.New("unreachable")
+	assert.NoError(t, c.Check(ctx))
+	assert.Equal(t, 1, b.calls)
+}
+
+func TestCachedHealthCheck(t *testing.T) {
+	var err error
+	calls := 0
+	check := api.HealthCheck{Name: "test", Check: func(ctx context.Context) error {
+		calls++
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}}
+
+	c := api.CachedHealthCheck(check, time.Hour)
+	assert.Equal(t, "test", c.Name)
+
+	// The results of the checks whose context is done aren't cached.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, c.Check(ctx))
+	assert.NoError(t, c.Check(context.Background()))
+	err = errors.New("failed")
+	assert.NoError(t, c.Check(context.Background()))
+	assert.Equal(t, 2, calls)
+
+	// The check is run again once its result expires.
+	c = api.CachedHealthCheck(check, 0)
+	assert.Error(t, c.Check(context.Background()))
+	err = nil
+	assert.NoError(t, c.Check(context.Background()))
+	assert.Equal(t, 4, calls)
+}
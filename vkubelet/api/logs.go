@@ -3,12 +3,17 @@ package api
 import (
 	"context"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cpuguy83/strongerrors"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+
+	"github.com/virtual-kubelet/virtual-kubelet/log"
 )
 
 // ContainerLogsBackend is used in place of backend implementations for getting container logs
@@ -16,8 +21,49 @@ type ContainerLogsBackend interface {
 	GetContainerLogs(ctx context.Context, namespace, podName, containerName string, tail int) (string, error)
 }
 
+// ContainerLogsStreamBackend is used in place of backend implementations for streaming container logs.
+// Backends implementing this interface support all of the options of the kubelet logs API.
+type ContainerLogsStreamBackend interface {
+	GetContainerLogStream(ctx context.Context, namespace, podName, containerName string, opts ContainerLogOpts) (io.ReadCloser, error)
+}
+
+// ContainerLogOpts are the options used when retrieving the logs of a container.
+// They mirror the query parameters accepted by the kubelet logs API.
+type ContainerLogOpts struct {
+	// Tail is the number of lines from the end of the logs to return.
+	// All lines are returned when it is zero.
+	Tail int
+	// LimitBytes is the maximum number of bytes to return.
+	// There is no limit when it is zero.
+	LimitBytes int
+	// Timestamps indicates whether each line should be prefixed with its RFC3339 timestamp.
+	Timestamps bool
+	// Follow indicates whether the stream should be kept open, returning new lines as they are written.
+	Follow bool
+	// Previous indicates whether the logs of the previous instance of the container should be returned.
+	Previous bool
+	// SinceSeconds is the number of seconds before now from which logs should be returned.
+	// It is mutually exclusive with SinceTime, and ignored when it is zero.
+	SinceSeconds int
+	// SinceTime is the time from which logs should be returned.
+	// It is mutually exclusive with SinceSeconds, and ignored when it is the zero value.
+	SinceTime time.Time
+}
+
 // PodLogsHandlerFunc creates an http handler function from a provider to serve logs from a pod
+//
+// If the passed in backend also implements ContainerLogsStreamBackend, logs are streamed from it honouring all
+// the request options. Otherwise, the logs are retrieved as a whole using the tail option only.
 func PodLogsHandlerFunc(p ContainerLogsBackend) http.HandlerFunc {
+	sb, ok := p.(ContainerLogsStreamBackend)
+	if !ok {
+		sb = &containerLogsBackendAdapter{b: p}
+	}
+	return PodLogsStreamHandlerFunc(sb)
+}
+
+// PodLogsStreamHandlerFunc creates an http handler function from a provider to stream logs from a pod
+func PodLogsStreamHandlerFunc(p ContainerLogsStreamBackend) http.HandlerFunc {
 	return handleError(func(w http.ResponseWriter, req *http.Request) error {
 		vars := mux.Vars(req)
 		if len(vars) != 3 {
@@ -29,25 +75,132 @@ func PodLogsHandlerFunc(p ContainerLogsBackend) http.HandlerFunc {
 		namespace := vars["namespace"]
 		pod := vars["pod"]
 		container := vars["container"]
-		tail := 10
-		q := req.URL.Query()
 
-		if queryTail := q.Get("tailLines"); queryTail != "" {
-			t, err := strconv.Atoi(queryTail)
-			if err != nil {
-				return strongerrors.InvalidArgument(errors.Wrap(err, "could not parse \"tailLines\""))
-			}
-			tail = t
+		opts, err := parseContainerLogOpts(req)
+		if err != nil {
+			return err
 		}
 
-		podsLogs, err := p.GetContainerLogs(ctx, namespace, pod, container, tail)
+		logs, err := p.GetContainerLogStream(ctx, namespace, pod, container, opts)
 		if err != nil {
 			return errors.Wrap(err, "error getting container logs?)")
 		}
+		defer logs.Close()
+
+		var r io.Reader = logs
+		if opts.LimitBytes > 0 {
+			r = io.LimitReader(r, int64(opts.LimitBytes))
+		}
 
-		if _, err := io.WriteString(w, podsLogs); err != nil {
+		var out io.Writer = w
+		if opts.Follow {
+			if f, ok := w.(http.Flusher); ok {
+				out = &flushWriter{w: w, f: f}
+			}
+		}
+
+		w.Header().Set("Content-Type", "text/plain")
+		if _, err := io.Copy(out, r); err != nil {
 			return strongerrors.Unknown(errors.Wrap(err, "error writing response to client"))
 		}
 		return nil
 	})
 }
+
+// parseContainerLogOpts parses the query parameters of a kubelet logs API request.
+func parseContainerLogOpts(req *http.Request) (ContainerLogOpts, error) {
+	var opts ContainerLogOpts
+	q := req.URL.Query()
+
+	parseInt := func(name string, dst *int) error {
+		v := q.Get(name)
+		if v == "" {
+			return nil
+		}
+		i, err := strconv.Atoi(v)
+		if err != nil {
+			return strongerrors.InvalidArgument(errors.Wrapf(err, "could not parse %q", name))
+		}
+		if i < 0 {
+			return strongerrors.InvalidArgument(errors.Errorf("%q must not be negative", name))
+		}
+		*dst = i
+		return nil
+	}
+	parseBool := func(name string, dst *bool) error {
+		v := q.Get(name)
+		if v == "" {
+			return nil
+		}
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return strongerrors.InvalidArgument(errors.Wrapf(err, "could not parse %q", name))
+		}
+		*dst = b
+		return nil
+	}
+
+	if err := parseInt("tailLines", &opts.Tail); err != nil {
+		return opts, err
+	}
+	if err := parseInt("limitBytes", &opts.LimitBytes); err != nil {
+		return opts, err
+	}
+	if err := parseInt("sinceSeconds", &opts.SinceSeconds); err != nil {
+		return opts, err
+	}
+	if err := parseBool("timestamps", &opts.Timestamps); err != nil {
+		return opts, err
+	}
+	if err := parseBool("follow", &opts.Follow); err != nil {
+		return opts, err
+	}
+	if err := parseBool("previous", &opts.Previous); err != nil {
+		return opts, err
+	}
+	if v := q.Get("sinceTime"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return opts, strongerrors.InvalidArgument(errors.Wrap(err, "could not parse \"sinceTime\""))
+		}
+		if opts.SinceSeconds > 0 {
+			return opts, strongerrors.InvalidArgument(errors.New("\"sinceSeconds\" and \"sinceTime\" are mutually exclusive"))
+		}
+		opts.SinceTime = t
+	}
+
+	return opts, nil
+}
+
+// flushWriter flushes the underlying response after every write, so that followed logs reach the client as they are written.
+type flushWriter struct {
+	w io.Writer
+	f http.Flusher
+}
+
+func (fw *flushWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	fw.f.Flush()
+	return n, err
+}
+
+// containerLogsBackendAdapter adapts a ContainerLogsBackend into a ContainerLogsStreamBackend.
+// Only the tail option can be honoured, as the backend returns the logs as a whole.
+type containerLogsBackendAdapter struct {
+	b ContainerLogsBackend
+}
+
+func (a *containerLogsBackendAdapter) GetContainerLogStream(ctx context.Context, namespace, podName, containerName string, opts ContainerLogOpts) (io.ReadCloser, error) {
+	if opts.Previous {
+		return nil, strongerrors.NotImplemented(errors.New("the provider does not support retrieving the logs of previous containers"))
+	}
+	if opts.Follow || opts.Timestamps || opts.SinceSeconds > 0 || !opts.SinceTime.IsZero() {
+		log.Trace(log.G(ctx), "The provider does not support streaming logs, ignoring the follow, timestamps and since options")
+	}
+
+	logs, err := a.b.GetContainerLogs(ctx, namespace, podName, containerName, opts.Tail)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(strings.NewReader(logs)), nil
+}
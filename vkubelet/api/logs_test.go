@@ -0,0 +1,130 @@
+package api_test
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
+)
+
+// fakeLogsBackend returns the same logs for all containers, recording the tail it is asked for.
+type fakeLogsBackend struct {
+	logs string
+	tail int
+}
+
+func (b *fakeLogsBackend) GetContainerLogs(ctx context.Context, namespace, podName, containerName string, tail int) (string, error) {
+	b.tail = tail
+	return b.logs, nil
+}
+
+// fakeLogsStreamBackend streams the same logs for all containers, recording the options it is asked for.
+type fakeLogsStreamBackend struct {
+	logs string
+	opts api.ContainerLogOpts
+}
+
+func (b *fakeLogsStreamBackend) GetContainerLogStream(ctx context.Context, namespace, podName, containerName string, opts api.ContainerLogOpts) (io.ReadCloser, error) {
+	b.opts = opts
+	return ioutil.NopCloser(strings.NewReader(b.logs)), nil
+}
+
+func serveLogs(h http.HandlerFunc, query string) *httptest.ResponseRecorder {
+	r := mux.NewRouter()
+	r.HandleFunc("/containerLogs/{namespace}/{pod}/{container}", h)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/containerLogs/default/pod-0/app?"+query, nil))
+	return rec
+}
+
+func TestContainerLogOpts(t *testing.T) {
+	b := &fakeLogsStreamBackend{}
+	h := api.PodLogsStreamHandlerFunc(b)
+
+	rec := serveLogs(h, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	// All the lines are requested by default.
+	assert.Equal(t, api.ContainerLogOpts{}, b.opts)
+
+	since := time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC)
+	rec = serveLogs(h, "tailLines=10&limitBytes=100&timestamps=true&follow=1&previous=true&sinceTime="+since.Format(time.RFC3339))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, api.ContainerLogOpts{
+		Tail:       10,
+		LimitBytes: 100,
+		Timestamps: true,
+		Follow:     true,
+		Previous:   true,
+		SinceTime:  since,
+	}, b.opts)
+
+	rec = serveLogs(h, "sinceSeconds=60")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, 60, b.opts.SinceSeconds)
+
+	for _, query := range []string{
+		"tailLines=ten",
+		"tailLines=-1",
+		"limitBytes=-1",
+		"follow=maybe",
+		"sinceTime=yesterday",
+		"sinceSeconds=60&sinceTime=" + since.Format(time.RFC3339),
+	} {
+		rec = serveLogs(h, query)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, "unexpected status for %q: %s", query, rec.Body.String())
+	}
+}
+
+func TestPodLogsStreamHandlerFunc(t *testing.T) {
+	b := &fakeLogsStreamBackend{logs: "line 1\nline 2\n"}
+	h := api.PodLogsStreamHandlerFunc(b)
+
+	rec := serveLogs(h, "limitBytes=4")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "line", rec.Body.String())
+	assert.False(t, rec.Flushed)
+
+	// The response is flushed as the followed logs are written.
+	rec = serveLogs(h, "follow=true")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, b.logs, rec.Body.String())
+	assert.True(t, rec.Flushed)
+}
+
+func TestPodLogsHandlerFunc(t *testing.T) {
+	b := &fakeLogsBackend{logs: "line 1\nline 2\n"}
+	h := api.PodLogsHandlerFunc(b)
+
+	rec := serveLogs(h, "tailLines=2&follow=true&timestamps=true")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, b.logs, rec.Body.String())
+	assert.Equal(t, 2, b.tail)
+
+	rec = serveLogs(h, "")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, 0, b.tail)
+
+	// The logs of previous containers can't be retrieved from backends which don't stream logs.
+	rec = serveLogs(h, "previous=true")
+	assert.Equal(t, http.StatusNotImplemented, rec.Code, rec.Body.String())
+
+	// Backends which stream logs are used as such.
+	sb := &struct {
+		*fakeLogsBackend
+		*fakeLogsStreamBackend
+	}{&fakeLogsBackend{}, &fakeLogsStreamBackend{logs: "streamed\n"}}
+	rec = serveLogs(api.PodLogsHandlerFunc(sb), "previous=true")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "streamed\n", rec.Body.String())
+	assert.True(t, sb.fakeLogsStreamBackend.opts.Previous)
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/cpuguy83/strongerrors"
+	"github.com/pkg/errors"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PodListerBackend is used in place of backend implementations for listing the pods running on the provider.
+type PodListerBackend interface {
+	GetPods(context.Context) ([]*v1.Pod, error)
+}
+
+// PodListHandlerFunc makes an HTTP handler for implementing the kubelet /pods endpoint.
+// The pods returned by the backend are served as a v1.PodList.
+func PodListHandlerFunc(b PodListerBackend) http.HandlerFunc {
+	return podListHandlerFunc(b, func(*v1.Pod) bool { return true })
+}
+
+// RunningPodListHandlerFunc makes an HTTP handler for implementing the kubelet /runningpods/ endpoint.
+// Only the pods returned by the backend which are running are served, as a v1.PodList.
+func RunningPodListHandlerFunc(b PodListerBackend) http.HandlerFunc {
+	return podListHandlerFunc(b, func(pod *v1.Pod) bool { return pod.Status.Phase == v1.PodRunning })
+}
+
+// podListHandlerFunc makes an HTTP handler serving the pods returned by the backend for which include returns true.
+func podListHandlerFunc(b PodListerBackend, include func(*v1.Pod) bool) http.HandlerFunc {
+	return handleError(func(w http.ResponseWriter, req *http.Request) error {
+		pods, err := b.GetPods(req.Context())
+		if err != nil {
+			if errors.Cause(err) == context.Canceled {
+				return strongerrors.Cancelled(err)
+			}
+			return errors.Wrap(err, "error getting pods from provider")
+		}
+
+		list := v1.PodList{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "PodList",
+				APIVersion: "v1",
+			},
+			Items: make([]v1.Pod, 0, len(pods)),
+		}
+		for _, pod := range pods {
+			if include(pod) {
+				list.Items = append(list.Items, *pod)
+			}
+		}
+
+		b, err := json.Marshal(list)
+		if err != nil {
+			return strongerrors.Unknown(errors.Wrap(err, "error marshalling pod list"))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(b); err != nil {
+			return strongerrors.Unknown(errors.Wrap(err, "could not write to client"))
+		}
+		return nil
+	})
+}
@@ -0,0 +1,70 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
+)
+
+// fakePodListerBackend returns the same pods every time, counting how many times it is asked for them.
+type fakePodListerBackend struct {
+	pods  []*v1.Pod
+	err   error
+	calls int
+}
+
+func (b *fakePodListerBackend) GetPods(context.Context) ([]*v1.Pod, error) {
+	b.calls++
+	return b.pods, b.err
+}
+
+func newPhasePod(name string, phase v1.PodPhase) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Status:     v1.PodStatus{Phase: phase},
+	}
+}
+
+func servePodList(t *testing.T, h http.HandlerFunc) []string {
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest(http.MethodGet, "/pods", nil))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var list v1.PodList
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	assert.Equal(t, "PodList", list.Kind)
+	names := []string{}
+	for _, pod := range list.Items {
+		names = append(names, pod.Name)
+	}
+	return names
+}
+
+func TestPodListHandlerFunc(t *testing.T) {
+	b := &fakePodListerBackend{pods: []*v1.Pod{
+		newPhasePod("pending", v1.PodPending),
+		newPhasePod("running", v1.PodRunning),
+		newPhasePod("succeeded", v1.PodSucceeded),
+	}}
+
+	assert.Equal(t, []string{"pending", "running", "succeeded"}, servePodList(t, api.PodListHandlerFunc(b)))
+	assert.Equal(t, []string{"running"}, servePodList(t, api.RunningPodListHandlerFunc(b)))
+
+	b.pods = nil
+	assert.Equal(t, []string{}, servePodList(t, api.RunningPodListHandlerFunc(b)))
+
+	b.err = context.Canceled
+	rec := httptest.NewRecorder()
+	api.PodListHandlerFunc(b)(rec, httptest.NewRequest(http.MethodGet, "/pods", nil))
+	assert.NotEqual(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/kubernetes/pkg/kubelet/server/portforward"
+)
+
+// PortForwardBackend is used in place of backend implementations for forwarding connections to a port of a pod
+type PortForwardBackend interface {
+	PortForward(name string, uid types.UID, port int32, stream io.ReadWriteCloser) error
+}
+
+// PodPortForwardHandlerFunc makes an http handler func from a Provider which forwards connections to the ports of a pod
+// Note that this handler currently depends on gorrilla/mux to get url parts as variables.
+func PodPortForwardHandlerFunc(backend PortForwardBackend, opts ...StreamOption) http.HandlerFunc {
+	cfg := newStreamConfig(opts)
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+
+		namespace := vars["namespace"]
+		pod := vars["pod"]
+
+		supportedStreamProtocols := strings.Split(req.Header.Get("X-Stream-Protocol-Version"), ",")
+
+		// Parse the "port" query parameters, which are only used by websocket clients.
+		portForwardOpts, err := portforward.NewV4Options(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		portforward.ServePortForward(w, req, backend, fmt.Sprintf("%s-%s", namespace, pod), "", portForwardOpts, cfg.IdleTimeout, cfg.CreationTimeout, supportedStreamProtocols)
+	}
+}
@@ -0,0 +1,90 @@
+package api_test
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport/spdy"
+	"k8s.io/kubernetes/pkg/kubelet/server/portforward"
+
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
+)
+
+// fakePortForwardBackend echoes the data sent to port 8080 of pods, and refuses the connections to the other ports.
+type fakePortForwardBackend struct {
+	mu   sync.Mutex
+	name string
+}
+
+func (b *fakePortForwardBackend) PortForward(name string, uid types.UID, port int32, stream io.ReadWriteCloser) error {
+	b.mu.Lock()
+	b.name = name
+	b.mu.Unlock()
+	if port != 8080 {
+		return fmt.Errorf("connection to port %d refused", port)
+	}
+	_, err := io.Copy(stream, stream)
+	return err
+}
+
+// forwardPort sends the specified data to the specified port of the pod through the specified connection, returning
+// the data received back and the error reported by the server.
+func forwardPort(t *testing.T, conn httpstream.Connection, requestID int, port string, data string) (string, string) {
+	headers := http.Header{}
+	headers.Set(v1.PortHeader, port)
+	headers.Set(v1.PortForwardRequestIDHeader, fmt.Sprint(requestID))
+	headers.Set(v1.StreamType, v1.StreamTypeError)
+	errorStream, err := conn.CreateStream(headers)
+	require.NoError(t, err)
+	errorStream.Close()
+	headers.Set(v1.StreamType, v1.StreamTypeData)
+	dataStream, err := conn.CreateStream(headers)
+	require.NoError(t, err)
+
+	_, err = io.WriteString(dataStream, data)
+	require.NoError(t, err)
+	dataStream.Close()
+	received, err := ioutil.ReadAll(dataStream)
+	require.NoError(t, err)
+	message, err := ioutil.ReadAll(errorStream)
+	require.NoError(t, err)
+	return string(received), string(message)
+}
+
+func TestPodPortForwardHandlerFunc(t *testing.T) {
+	b := &fakePortForwardBackend{}
+	srv := serveStream("/portForward/{namespace}/{pod}", api.PodPortForwardHandlerFunc(b))
+	defer srv.Close()
+
+	u, err := url.Parse(srv.URL + "/portForward/default/pod-0")
+	require.NoError(t, err)
+	transport, upgrader, err := spdy.RoundTripperFor(&rest.Config{Host: srv.URL})
+	require.NoError(t, err)
+	conn, protocol, err := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, u).Dial(portforward.ProtocolV1Name)
+	require.NoError(t, err)
+	defer conn.Close()
+	assert.Equal(t, portforward.ProtocolV1Name, protocol)
+
+	received, message := forwardPort(t, conn, 0, "8080", "ping")
+	assert.Equal(t, "ping", received)
+	assert.Empty(t, message)
+	b.mu.Lock()
+	assert.Equal(t, "default-pod-0", b.name)
+	b.mu.Unlock()
+
+	// The errors of the backend are reported on the error stream of the port.
+	received, message = forwardPort(t, conn, 1, "9090", "ping")
+	assert.Empty(t, received)
+	assert.Contains(t, message, "connection to port 9090 refused")
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/cpuguy83/strongerrors"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/virtual-kubelet/virtual-kubelet/log"
+	"k8s.io/api/core/v1"
+	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
+)
+
+// The metrics served on the resource metrics endpoint, with the same names and labels as the kubelet's.
+var (
+	nodeCPUUsageDesc           = prometheus.NewDesc("node_cpu_usage_seconds_total", "Cumulative cpu time consumed by the node in core-seconds", nil, nil)
+	nodeMemoryWorkingSetDesc   = prometheus.NewDesc("node_memory_working_set_bytes", "Current working set of the node in bytes", nil, nil)
+	podCPUUsageDesc            = prometheus.NewDesc("pod_cpu_usage_seconds_total", "Cumulative cpu time consumed by the pod in core-seconds", []string{"namespace", "pod"}, nil)
+	podMemoryWorkingSetDesc    = prometheus.NewDesc("pod_memory_working_set_bytes", "Current working set of the pod in bytes", []string{"namespace", "pod"}, nil)
+	containerCPUUsageDesc      = prometheus.NewDesc("container_cpu_usage_seconds_total", "Cumulative cpu time consumed by the container in core-seconds", []string{"container", "namespace", "pod"}, nil)
+	containerMemoryWorkingDesc = prometheus.NewDesc("container_memory_working_set_bytes", "Current working set of the container in bytes", []string{"container", "namespace", "pod"}, nil)
+
+	resourceMetricsDescs = []*prometheus.Desc{
+		nodeCPUUsageDesc, nodeMemoryWorkingSetDesc,
+		podCPUUsageDesc, podMemoryWorkingSetDesc,
+		containerCPUUsageDesc, containerMemoryWorkingDesc,
+	}
+)
+
+// cadvisorLabels are the labels of the metrics served on the cAdvisor metrics endpoint.
+// Both the current and the deprecated names of the container and pod labels are set, like the kubelet does.
+var cadvisorLabels = []string{"container", "container_name", "id", "image", "name", "namespace", "pod", "pod_name"}
+
+// The metrics served on the cAdvisor metrics endpoint, with the same names and labels as the kubelet's.
+var (
+	cadvisorCPUUsageDesc         = prometheus.NewDesc("container_cpu_usage_seconds_total", "Cumulative cpu time consumed in seconds.", append([]string{"cpu"}, cadvisorLabels...), nil)
+	cadvisorMemoryUsageDesc      = prometheus.NewDesc("container_memory_usage_bytes", "Current memory usage in bytes, including all memory regardless of when it was accessed", cadvisorLabels, nil)
+	cadvisorMemoryWorkingSetDesc = prometheus.NewDesc("container_memory_working_set_bytes", "Current working set in bytes.", cadvisorLabels, nil)
+	cadvisorMemoryRSSDesc        = prometheus.NewDesc("container_memory_rss", "Size of RSS in bytes.", cadvisorLabels, nil)
+	cadvisorNetworkRxBytesDesc   = prometheus.NewDesc("container_network_receive_bytes_total", "Cumulative count of bytes received", append([]string{"interface"}, cadvisorLabels...), nil)
+	cadvisorNetworkRxErrorsDesc  = prometheus.NewDesc("container_network_receive_errors_total", "Cumulative count of errors encountered while receiving", append([]string{"interface"}, cadvisorLabels...), nil)
+	cadvisorNetworkTxBytesDesc   = prometheus.NewDesc("container_network_transmit_bytes_total", "Cumulative count of bytes transmitted", append([]string{"interface"}, cadvisorLabels...), nil)
+	cadvisorNetworkTxErrorsDesc  = prometheus.NewDesc("container_network_transmit_errors_total", "Cumulative count of errors encountered while transmitting", append([]string{"interface"}, cadvisorLabels...), nil)
+
+	cadvisorMetricsDescs = []*prometheus.Desc{
+		cadvisorCPUUsageDesc,
+		cadvisorMemoryUsageDesc, cadvisorMemoryWorkingSetDesc, cadvisorMemoryRSSDesc,
+		cadvisorNetworkRxBytesDesc, cadvisorNetworkRxErrorsDesc, cadvisorNetworkTxBytesDesc, cadvisorNetworkTxErrorsDesc,
+	}
+)
+
+// PodResourceMetricsHandlerFunc makes an HTTP handler for implementing the kubelet resource metrics endpoint.
+// The CPU and memory usage of the node, pods and containers in the summary returned by the backend are served in
+// the Prometheus format.
+func PodResourceMetricsHandlerFunc(b PodMetricsBackend) http.HandlerFunc {
+	return handleError(func(w http.ResponseWriter, req *http.Request) error {
+		summary, err := getStatsSummary(req.Context(), b)
+		if err != nil {
+			return err
+		}
+		serveMetrics(w, req, resourceMetricsDescs, resourceMetrics(summary))
+		return nil
+	})
+}
+
+// PodCadvisorMetricsHandlerFunc makes an HTTP handler for implementing the kubelet cAdvisor metrics endpoint.
+// The usage of the node, pods and containers in the summary returned by the backend are served in the Prometheus
+// format. The images of the containers are looked up in the pods returned by the pod lister.
+func PodCadvisorMetricsHandlerFunc(b PodMetricsBackend, l PodListerBackend) http.HandlerFunc {
+	return handleError(func(w http.ResponseWriter, req *http.Request) error {
+		summary, err := getStatsSummary(req.Context(), b)
+		if err != nil {
+			return err
+		}
+		pods, err := l.GetPods(req.Context())
+		if err != nil {
+			if errors.Cause(err) == context.Canceled {
+				return strongerrors.Cancelled(err)
+			}
+			return errors.Wrap(err, "error getting pods from provider")
+		}
+		serveMetrics(w, req, cadvisorMetricsDescs, cadvisorMetrics(summary, pods))
+		return nil
+	})
+}
+
+func getStatsSummary(ctx context.Context, b PodMetricsBackend) (*stats.Summary, error) {
+	summary, err := b.GetStatsSummary(ctx)
+	if err != nil {
+		if errors.Cause(err) == context.Canceled {
+			return nil, strongerrors.Cancelled(err)
+		}
+		return nil, errors.Wrap(err, "error getting status from provider")
+	}
+	return summary, nil
+}
+
+// serveMetrics serves the passed in metrics in the format negotiated with the client.
+// Inconsistent metrics, such as duplicated series, are logged and left out.
+func serveMetrics(w http.ResponseWriter, req *http.Request, descs []*prometheus.Desc, metrics []prometheus.Metric) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(&constCollector{descs: descs, metrics: metrics})
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+		ErrorLog:      log.G(req.Context()),
+		ErrorHandling: promhttp.ContinueOnError,
+	}).ServeHTTP(w, req)
+}
+
+// constCollector collects metrics computed beforehand.
+type constCollector struct {
+	descs   []*prometheus.Desc
+	metrics []prometheus.Metric
+}
+
+func (c *constCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.descs {
+		ch <- d
+	}
+}
+
+func (c *constCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range c.metrics {
+		ch <- m
+	}
+}
+
+// metricsBuilder accumulates the metrics derived from the optional fields of a stats summary.
+type metricsBuilder []prometheus.Metric
+
+func (mb *metricsBuilder) add(desc *prometheus.Desc, valueType prometheus.ValueType, v *uint64, scale float64, labels ...string) {
+	if v == nil {
+		return
+	}
+	*mb = append(*mb, prometheus.MustNewConstMetric(desc, valueType, float64(*v)*scale, labels...))
+}
+
+func (mb *metricsBuilder) addCPU(desc *prometheus.Desc, cpu *stats.CPUStats, labels ...string) {
+	if cpu != nil {
+		mb.add(desc, prometheus.CounterValue, cpu.UsageCoreNanoSeconds, 1e-9, labels...)
+	}
+}
+
+func (mb *metricsBuilder) addMemoryWorkingSet(desc *prometheus.Desc, memory *stats.MemoryStats, labels ...string) {
+	if memory != nil {
+		mb.add(desc, prometheus.GaugeValue, memory.WorkingSetBytes, 1, labels...)
+	}
+}
+
+func resourceMetrics(summary *stats.Summary) []prometheus.Metric {
+	var mb metricsBuilder
+	mb.addCPU(nodeCPUUsageDesc, summary.Node.CPU)
+	mb.addMemoryWorkingSet(nodeMemoryWorkingSetDesc, summary.Node.Memory)
+	for _, pod := range summary.Pods {
+		mb.addCPU(podCPUUsageDesc, pod.CPU, pod.PodRef.Namespace, pod.PodRef.Name)
+		mb.addMemoryWorkingSet(podMemoryWorkingSetDesc, pod.Memory, pod.PodRef.Namespace, pod.PodRef.Name)
+		for _, c := range pod.Containers {
+			mb.addCPU(containerCPUUsageDesc, c.CPU, c.Name, pod.PodRef.Namespace, pod.PodRef.Name)
+			mb.addMemoryWorkingSet(containerMemoryWorkingDesc, c.Memory, c.Name, pod.PodRef.Namespace, pod.PodRef.Name)
+		}
+	}
+	return mb
+}
+
+// cadvisorContainer holds the values of the cAdvisor labels of a cgroup.
+type cadvisorContainer struct {
+	container, id, image, name, namespace, pod string
+}
+
+func (c cadvisorContainer) labels(extra ...string) []string {
+	return append(extra, c.container, c.container, c.id, c.image, c.name, c.namespace, c.pod, c.pod)
+}
+
+func (mb *metricsBuilder) addCadvisorUsage(c cadvisorContainer, cpu *stats.CPUStats, memory *stats.MemoryStats) {
+	// The kubelet disables per-cpu usage, so cAdvisor only reports the total.
+	mb.addCPU(cadvisorCPUUsageDesc, cpu, c.labels("total")...)
+	if memory != nil {
+		mb.add(cadvisorMemoryUsageDesc, prometheus.GaugeValue, memory.UsageBytes, 1, c.labels()...)
+		mb.add(cadvisorMemoryWorkingSetDesc, prometheus.GaugeValue, memory.WorkingSetBytes, 1, c.labels()...)
+		mb.add(cadvisorMemoryRSSDesc, prometheus.GaugeValue, memory.RSSBytes, 1, c.labels()...)
+	}
+}
+
+func (mb *metricsBuilder) addCadvisorNetwork(c cadvisorContainer, network *stats.NetworkStats) {
+	if network == nil {
+		return
+	}
+	interfaces := network.Interfaces
+	if len(interfaces) == 0 && network.Name != "" {
+		interfaces = []stats.InterfaceStats{network.InterfaceStats}
+	}
+	for _, i := range interfaces {
+		mb.add(cadvisorNetworkRxBytesDesc, prometheus.CounterValue, i.RxBytes, 1, c.labels(i.Name)...)
+		mb.add(cadvisorNetworkRxErrorsDesc, prometheus.CounterValue, i.RxErrors, 1, c.labels(i.Name)...)
+		mb.add(cadvisorNetworkTxBytesDesc, prometheus.CounterValue, i.TxBytes, 1, c.labels(i.Name)...)
+		mb.add(cadvisorNetworkTxErrorsDesc, prometheus.CounterValue, i.TxErrors, 1, c.labels(i.Name)...)
+	}
+}
+
+// cadvisorMetrics derives the cAdvisor metrics from the summary.
+// Like cAdvisor, the node is reported as the root cgroup, and pods as cgroups without a container name nor image,
+// which the network usage of the pods is attributed to.
+func cadvisorMetrics(summary *stats.Summary, pods []*v1.Pod) []prometheus.Metric {
+	var mb metricsBuilder
+	root := cadvisorContainer{id: "/"}
+	mb.addCadvisorUsage(root, summary.Node.CPU, summary.Node.Memory)
+	mb.addCadvisorNetwork(root, summary.Node.Network)
+
+	specs := make(map[string]*v1.Pod, len(pods))
+	for _, pod := range pods {
+		specs[pod.Namespace+"/"+pod.Name] = pod
+	}
+	for _, pod := range summary.Pods {
+		spec := specs[pod.PodRef.Namespace+"/"+pod.PodRef.Name]
+		podCgroup := cadvisorContainer{
+			id:        "/kubepods/pod" + pod.PodRef.UID,
+			namespace: pod.PodRef.Namespace,
+			pod:       pod.PodRef.Name,
+		}
+		mb.addCadvisorUsage(podCgroup, pod.CPU, pod.Memory)
+		mb.addCadvisorNetwork(podCgroup, pod.Network)
+
+		for _, c := range pod.Containers {
+			container := podCgroup
+			container.container = c.Name
+			container.id = podCgroup.id + "/" + c.Name
+			if spec != nil {
+				container.image, container.name = containerImageAndID(spec, c.Name)
+				if container.name != "" {
+					container.id = podCgroup.id + "/" + container.name
+				}
+			}
+			mb.addCadvisorUsage(container, c.CPU, c.Memory)
+		}
+	}
+	return mb
+}
+
+// containerImageAndID returns the image and the runtime ID, without the runtime prefix, of the specified container of
+// the pod.
+func containerImageAndID(pod *v1.Pod, name string) (string, string) {
+	var image, id string
+	for _, c := range pod.Spec.Containers {
+		if c.Name == name {
+			image = c.Image
+		}
+	}
+	for _, s := range pod.Status.ContainerStatuses {
+		if s.Name == name {
+			id = s.ContainerID
+			if i := strings.Index(id, "://"); i >= 0 {
+				id = id[i+len("://"):]
+			}
+		}
+	}
+	return image, id
+}
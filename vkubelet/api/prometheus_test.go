@@ -0,0 +1,94 @@
+package api_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	stats "k8s.io/kubernetes/pkg/kubelet/apis/stats/v1alpha1"
+
+	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
+)
+
+type fakeBackend struct{}
+
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
+
+func (fakeBackend) GetStatsSummary(context.Context) (*stats.Summary, error) {
+	return &stats.Summary{
+		Node: stats.NodeStats{
+			NodeName: "vk",
+			CPU:      &stats.CPUStats{UsageCoreNanoSeconds: uint64Ptr(5e9)},
+		},
+		Pods: []stats.PodStats{{
+			PodRef: stats.PodReference{Namespace: "default", Name: "pod-0", UID: "uid-0"},
+			CPU:    &stats.CPUStats{UsageCoreNanoSeconds: uint64Ptr(3e9)},
+			Memory: &stats.MemoryStats{WorkingSetBytes: uint64Ptr(2048)},
+			Network: &stats.NetworkStats{
+				InterfaceStats: stats.InterfaceStats{Name: "eth0", RxBytes: uint64Ptr(100), TxBytes: uint64Ptr(200)},
+			},
+			Containers: []stats.ContainerStats{{
+				Name:   "container-0",
+				CPU:    &stats.CPUStats{UsageCoreNanoSeconds: uint64Ptr(1.5e9)},
+				Memory: &stats.MemoryStats{WorkingSetBytes: uint64Ptr(1024), UsageBytes: uint64Ptr(4096)},
+			}},
+		}},
+	}, nil
+}
+
+func (fakeBackend) GetPods(context.Context) ([]*v1.Pod, error) {
+	return []*v1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod-0"},
+		Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "container-0", Image: "nginx"}}},
+		Status: v1.PodStatus{ContainerStatuses: []v1.ContainerStatus{{
+			Name:        "container-0",
+			ContainerID: "docker://abc",
+		}}},
+	}}, nil
+}
+
+func getMetrics(t *testing.T, h http.HandlerFunc) string {
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest("GET", "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	b, err := ioutil.ReadAll(rec.Body)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestPodResourceMetricsHandlerFunc(t *testing.T) {
+	out := getMetrics(t, api.PodResourceMetricsHandlerFunc(fakeBackend{}))
+	for _, line := range []string{
+		"node_cpu_usage_seconds_total 5\n",
+		`pod_cpu_usage_seconds_total{namespace="default",pod="pod-0"} 3` + "\n",
+		`pod_memory_working_set_bytes{namespace="default",pod="pod-0"} 2048` + "\n",
+		`container_cpu_usage_seconds_total{container="container-0",namespace="default",pod="pod-0"} 1.5` + "\n",
+		`container_memory_working_set_bytes{container="container-0",namespace="default",pod="pod-0"} 1024` + "\n",
+	} {
+		assert.Contains(t, out, line)
+	}
+	// Missing values are left out rather than reported as zeros.
+	assert.NotContains(t, out, "node_memory_working_set_bytes ")
+}
+
+func TestPodCadvisorMetricsHandlerFunc(t *testing.T) {
+	out := getMetrics(t, api.PodCadvisorMetricsHandlerFunc(fakeBackend{}, fakeBackend{}))
+	for _, line := range []string{
+		`container_cpu_usage_seconds_total{container="",container_name="",cpu="total",id="/",image="",name="",namespace="",pod="",pod_name=""} 5` + "\n",
+		`container_cpu_usage_seconds_total{container="",container_name="",cpu="total",id="/kubepods/poduid-0",image="",name="",namespace="default",pod="pod-0",pod_name="pod-0"} 3` + "\n",
+		`container_cpu_usage_seconds_total{container="container-0",container_name="container-0",cpu="total",id="/kubepods/poduid-0/abc",image="nginx",name="abc",namespace="default",pod="pod-0",pod_name="pod-0"} 1.5` + "\n",
+		`container_memory_usage_bytes{container="container-0",container_name="container-0",id="/kubepods/poduid-0/abc",image="nginx",name="abc",namespace="default",pod="pod-0",pod_name="pod-0"} 4096` + "\n",
+		`container_network_receive_bytes_total{container="",container_name="",id="/kubepods/poduid-0",image="",interface="eth0",name="",namespace="default",pod="pod-0",pod_name="pod-0"} 100` + "\n",
+		`container_network_transmit_bytes_total{container="",container_name="",id="/kubepods/poduid-0",image="",interface="eth0",name="",namespace="default",pod="pod-0",pod_name="pod-0"} 200` + "\n",
+	} {
+		assert.Contains(t, out, line)
+	}
+}
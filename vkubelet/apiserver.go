@@ -10,6 +10,7 @@ import (
 	"github.com/virtual-kubelet/virtual-kubelet/vkubelet/api"
 	"go.opencensus.io/plugin/ochttp"
 	"go.opencensus.io/plugin/ochttp/propagation/b3"
+	corev1listers "k8s.io/client-go/listers/core/v1"
 )
 
 // ServeMux defines an interface used to attach routes to an existing http
@@ -22,45 +23,85 @@ type ServeMux interface {
 }
 
 // PodHandler creates an http handler for interacting with pods/containers.
-func PodHandler(p providers.Provider) http.Handler {
+//
+// Besides the streaming routes, it serves the pods of the provider under /pods, the ones which are running under
+// /runningpods/, and a /healthz route which checks that the provider is reachable.
+// If the passed in provider does not implement providers.ContainerAttacher or providers.PortForwarder,
+// the corresponding routes just serve http.StatusNotImplemented.
+// The passed in options configure the handlers serving streaming connections, such as exec.
+func PodHandler(p providers.Provider, opts ...api.StreamOption) http.Handler {
 	r := mux.NewRouter()
 
-	r.HandleFunc("/containerLogs/{namespace}/{pod}/{container}", api.PodLogsHandlerFunc(p)).Methods("GET")
-	r.HandleFunc("/exec/{namespace}/{pod}/{container}", api.PodExecHandlerFunc(p)).Methods("POST")
+	// The middlewares decorating the provider implement all the optional interfaces, which must be looked up on the
+	// innermost provider.
+	logsHandler := api.PodLogsHandlerFunc(struct{ api.ContainerLogsBackend }{p})
+	if _, ok := providers.Unwrap(p).(providers.ContainerLogsStreamer); ok {
+		logsHandler = api.PodLogsStreamHandlerFunc(p.(providers.ContainerLogsStreamer))
+	}
+	r.HandleFunc("/containerLogs/{namespace}/{pod}/{container}", logsHandler).Methods("GET")
+	r.HandleFunc("/exec/{namespace}/{pod}/{container}", api.PodExecHandlerFunc(p, opts...)).Methods("POST", "GET")
+
+	var attachHandler, portForwardHandler http.HandlerFunc = NotImplemented, NotImplemented
+	if _, ok := providers.Unwrap(p).(providers.ContainerAttacher); ok {
+		attachHandler = api.PodAttachHandlerFunc(p.(providers.ContainerAttacher), opts...)
+	}
+	if _, ok := providers.Unwrap(p).(providers.PortForwarder); ok {
+		portForwardHandler = api.PodPortForwardHandlerFunc(p.(providers.PortForwarder), opts...)
+	}
+	r.HandleFunc("/attach/{namespace}/{pod}/{container}", attachHandler).Methods("POST", "GET")
+	r.HandleFunc("/portForward/{namespace}/{pod}", portForwardHandler).Methods("POST", "GET")
+
+	r.HandleFunc("/pods", api.PodListHandlerFunc(p)).Methods("GET")
+	r.HandleFunc("/runningpods/", api.RunningPodListHandlerFunc(p)).Methods("GET")
+	r.Handle("/healthz", HealthzHandler(p)).Methods("GET")
 	r.NotFoundHandler = http.HandlerFunc(NotFound)
 	return r
 }
 
 // MetricsSummaryHandler creates an http handler for serving pod metrics.
 //
+// Besides the summary stats, the usage of the node, pods and containers are served in the Prometheus format under
+// /metrics/resource and /metrics/cadvisor, like the kubelet does.
 // If the passed in provider does not implement providers.PodMetricsProvider,
 // it will create handlers that just serves http.StatusNotImplemented
 func MetricsSummaryHandler(p providers.Provider) http.Handler {
 	r := mux.NewRouter()
 
 	const summaryRoute = "/stats/summary"
-	var h http.HandlerFunc
+	var h, resourceHandler, cadvisorHandler http.HandlerFunc = NotImplemented, NotImplemented, NotImplemented
 
-	mp, ok := p.(providers.PodMetricsProvider)
-	if !ok {
-		h = NotImplemented
-	} else {
+	if _, ok := providers.Unwrap(p).(providers.PodMetricsProvider); ok {
+		mp := p.(providers.PodMetricsProvider)
 		h = api.PodMetricsHandlerFunc(mp)
+		resourceHandler = api.PodResourceMetricsHandlerFunc(mp)
+		cadvisorHandler = api.PodCadvisorMetricsHandlerFunc(mp, p)
 	}
 
 	r.Handle(summaryRoute, ochttp.WithRouteTag(h, "PodStatsSummaryHandler")).Methods("GET")
 	r.Handle(summaryRoute+"/", ochttp.WithRouteTag(h, "PodStatsSummaryHandler")).Methods("GET")
+	r.Handle("/metrics/resource", ochttp.WithRouteTag(resourceHandler, "PodResourceMetricsHandler")).Methods("GET")
+	r.Handle("/metrics/resource/v1alpha1", ochttp.WithRouteTag(resourceHandler, "PodResourceMetricsHandler")).Methods("GET")
+	r.Handle("/metrics/cadvisor", ochttp.WithRouteTag(cadvisorHandler, "PodCadvisorMetricsHandler")).Methods("GET")
 
 	r.NotFoundHandler = http.HandlerFunc(NotFound)
 	return r
 }
 
+// HealthzHandler creates an http handler for serving the health of the virtual-kubelet.
+//
+// The provider is always checked for reachability, in addition to the passed in checks
+// (e.g. the sync state of the pod informer).
+func HealthzHandler(p providers.Provider, checks ...api.HealthCheck) http.Handler {
+	checks = append([]api.HealthCheck{api.ProviderHealthCheck(p)}, checks...)
+	return ochttp.WithRouteTag(api.HealthzHandlerFunc(checks...), "HealthzHandler")
+}
+
 // AttachPodRoutes adds the http routes for pod stuff to the passed in serve mux.
 //
 // Callers should take care to namespace the serve mux as they see fit, however
 // these routes get called by the Kubernetes API server.
-func AttachPodRoutes(p providers.Provider, mux ServeMux) {
-	mux.Handle("/", InstrumentHandler(PodHandler(p)))
+func AttachPodRoutes(p providers.Provider, mux ServeMux, opts ...api.StreamOption) {
+	mux.Handle("/", InstrumentHandler(PodHandler(p, opts...)))
 }
 
 // AttachMetricsRoutes adds the http routes for pod/node metrics to the passed in serve mux.
@@ -71,6 +112,66 @@ func AttachMetricsRoutes(p providers.Provider, mux ServeMux) {
 	mux.Handle("/", InstrumentHandler(MetricsSummaryHandler(p)))
 }
 
+// AttachHealthzRoutes adds the http route for the health of the virtual-kubelet to the passed in serve mux.
+//
+// It takes precedence over the /healthz route of the pod handler when both are attached to the same
+// *http.ServeMux, so that additional checks can be passed in.
+func AttachHealthzRoutes(p providers.Provider, mux ServeMux, checks ...api.HealthCheck) {
+	mux.Handle("/healthz", InstrumentHandler(HealthzHandler(p, checks...)))
+}
+
+// RoutedNode is a virtual node whose requests are routed to its handler by NodeRouter.
+type RoutedNode struct {
+	// Name is the name of the node.
+	Name string
+	// Handler serves the requests for the node, such as the handler returned by PodHandler.
+	Handler http.Handler
+	// Pods lists the pods bound to the node, so that the requests for a pod are routed to its node.
+	Pods corev1listers.PodLister
+}
+
+// NodeRouter creates an http handler routing requests to the handlers of several virtual nodes served on the same
+// address.
+//
+// Requests for a pod, such as exec or logs, are routed to the node the pod is bound to, and are served
+// http.StatusNotFound when it isn't bound to any of them. Requests under /nodes/{node}/ are routed to the named node,
+// without the prefix, e.g. to list its pods. The other requests are passed to the fallback handler.
+func NodeRouter(nodes []RoutedNode, fallback http.Handler) http.Handler {
+	r := mux.NewRouter()
+
+	handlers := make(map[string]http.Handler, len(nodes))
+	for _, n := range nodes {
+		handlers[n.Name] = n.Handler
+	}
+	r.PathPrefix("/nodes/{node}/").HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		name := mux.Vars(req)["node"]
+		h, ok := handlers[name]
+		if !ok {
+			NotFound(w, req)
+			return
+		}
+		http.StripPrefix("/nodes/"+name, h).ServeHTTP(w, req)
+	})
+
+	podHandler := func(w http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		for _, n := range nodes {
+			if _, err := n.Pods.Pods(vars["namespace"]).Get(vars["pod"]); err == nil {
+				n.Handler.ServeHTTP(w, req)
+				return
+			}
+		}
+		NotFound(w, req)
+	}
+	for _, route := range []string{"/containerLogs", "/exec", "/attach", "/portForward"} {
+		r.HandleFunc(route+"/{namespace}/{pod}", podHandler)
+		r.PathPrefix(route + "/{namespace}/{pod}/").HandlerFunc(podHandler)
+	}
+
+	r.NotFoundHandler = fallback
+	return r
+}
+
 func instrumentRequest(r *http.Request) *http.Request {
 	ctx := r.Context()
 	logger := log.G(ctx).WithFields(logrus.Fields{
@@ -0,0 +1,53 @@
+package vkubelet
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// TestNodeRouter verifies that requests are routed to the node of the pod they are for, or to the node in their path.
+func TestNodeRouter(t *testing.T) {
+	var nodes []RoutedNode
+	for _, name := range []string{"vk-0", "vk-1"} {
+		indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+		require.NoError(t, indexer.Add(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod-" + name}}))
+		name := name
+		nodes = append(nodes, RoutedNode{
+			Name: name,
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Write([]byte(name + " " + req.URL.Path))
+			}),
+			Pods: corev1listers.NewPodLister(indexer),
+		})
+	}
+	h := NodeRouter(nodes, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte("fallback " + req.URL.Path))
+	}))
+
+	for path, expected := range map[string]string{
+		"/containerLogs/default/pod-vk-1/app": "vk-1 /containerLogs/default/pod-vk-1/app",
+		"/exec/default/pod-vk-0/app":          "vk-0 /exec/default/pod-vk-0/app",
+		"/portForward/default/pod-vk-1":       "vk-1 /portForward/default/pod-vk-1",
+		"/nodes/vk-1/pods":                    "vk-1 /pods",
+		"/healthz":                            "fallback /healthz",
+	} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, rr.Code, path)
+		assert.Equal(t, expected, rr.Body.String(), path)
+	}
+
+	for _, path := range []string{"/exec/default/missing/app", "/exec/kube-system/pod-vk-0/app", "/nodes/vk-2/pods"} {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code, path)
+	}
+}
@@ -0,0 +1,289 @@
+package vkubelet
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/util/cache"
+	authenticationv1client "k8s.io/client-go/kubernetes/typed/authentication/v1"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+
+	"github.com/virtual-kubelet/virtual-kubelet/log"
+)
+
+const (
+	// anonymousUser is the name of the user assigned to unauthenticated requests, as done by the Kubelet.
+	anonymousUser = "system:anonymous"
+	// unauthenticatedGroup is the group assigned to unauthenticated requests, as done by the Kubelet.
+	unauthenticatedGroup = "system:unauthenticated"
+	// authenticatedGroup is the group added to every authenticated user, as done by the Kubelet.
+	authenticatedGroup = "system:authenticated"
+
+	// authCacheSize is the maximum number of token and subject access reviews cached, as with the Kubelet.
+	authCacheSize = 1024
+)
+
+// AuthConfig configures the authentication and authorization of the requests served by AuthHandler.
+type AuthConfig struct {
+	// NodeName is the name of the node the requests are authorized against.
+	NodeName string
+	// Anonymous enables serving requests which are not rejected by any authentication method,
+	// as the system:anonymous user.
+	Anonymous bool
+	// TokenReviews, when set, is used to authenticate bearer tokens with the API server.
+	TokenReviews authenticationv1client.TokenReviewInterface
+	// SubjectAccessReviews, when set, is used to authorize requests with the API server.
+	// All the authenticated requests are authorized otherwise.
+	SubjectAccessReviews authorizationv1client.SubjectAccessReviewInterface
+
+	// TokenReviewCacheTTL is how long the results of token reviews are cached.
+	// They aren't cached when it is zero.
+	TokenReviewCacheTTL time.Duration
+	// AuthorizedCacheTTL and UnauthorizedCacheTTL are how long the allowed and denied results of subject access
+	// reviews are cached, respectively. They aren't cached when they are zero.
+	AuthorizedCacheTTL   time.Duration
+	UnauthorizedCacheTTL time.Duration
+}
+
+// userInfo describes the user a request was authenticated as.
+type userInfo struct {
+	Name   string
+	UID    string
+	Groups []string
+	Extra  map[string]authorizationv1.ExtraValue
+}
+
+// tokenReviewResult is the cached result of a token review.
+type tokenReviewResult struct {
+	user          *userInfo
+	authenticated bool
+}
+
+// subjectAccessReviewResult is the cached result of a subject access review.
+type subjectAccessReviewResult struct {
+	allowed bool
+	reason  string
+}
+
+// authenticator authenticates and authorizes requests according to its configuration, caching the results of the
+// reviews made with the API server.
+type authenticator struct {
+	AuthConfig
+	tokenReviews         *cache.LRUExpireCache
+	subjectAccessReviews *cache.LRUExpireCache
+}
+
+// AuthHandler wraps an http.Handler, authenticating and authorizing every request before passing it on.
+//
+// Requests are authenticated in the same way as the Kubelet does: with the verified client certificate
+// presented over TLS first, then with the bearer token of the request, and finally as an anonymous user.
+// As with the Kubelet, requests presenting a bearer token which isn't authenticated are rejected rather than
+// served as the anonymous user.
+// Requests are then authorized against the nodes/proxy, nodes/log, nodes/stats, nodes/metrics or nodes/spec
+// subresource of the node, depending on their path.
+func AuthHandler(h http.Handler, cfg AuthConfig) http.Handler {
+	a := &authenticator{
+		AuthConfig:           cfg,
+		tokenReviews:         cache.NewLRUExpireCache(authCacheSize),
+		subjectAccessReviews: cache.NewLRUExpireCache(authCacheSize),
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		logger := log.G(req.Context())
+
+		user, ok, err := a.authenticate(req)
+		if err != nil {
+			logger.WithError(err).Error("Error authenticating request")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !ok {
+			log.Trace(logger, "401 unauthorized")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		attrs := authorizationAttributes(a.NodeName, req)
+		allowed, reason, err := a.authorize(user, attrs)
+		if err != nil {
+			logger.WithError(err).Error("Error authorizing request")
+			http.Error(w, fmt.Sprintf("Authorization error (user=%s, verb=%s, resource=%s, subresource=%s)", user.Name, attrs.Verb, attrs.Resource, attrs.Subresource), http.StatusInternalServerError)
+			return
+		}
+		if !allowed {
+			log.Trace(logger.WithField("reason", reason), "403 forbidden")
+			http.Error(w, fmt.Sprintf("Forbidden (user=%s, verb=%s, resource=%s, subresource=%s)", user.Name, attrs.Verb, attrs.Resource, attrs.Subresource), http.StatusForbidden)
+			return
+		}
+
+		h.ServeHTTP(w, req)
+	})
+}
+
+// authenticate determines the user the request is made by.
+// It returns false when the request could not be authenticated by any of the enabled methods.
+func (a *authenticator) authenticate(req *http.Request) (*userInfo, bool, error) {
+	// The TLS handshake only succeeds with a client certificate signed by the client CA, if one is presented.
+	if req.TLS != nil && len(req.TLS.VerifiedChains) > 0 && len(req.TLS.VerifiedChains[0]) > 0 {
+		cert := req.TLS.VerifiedChains[0][0]
+		return &userInfo{
+			Name:   cert.Subject.CommonName,
+			Groups: append(append([]string(nil), cert.Subject.Organization...), authenticatedGroup),
+		}, true, nil
+	}
+
+	if token := bearerToken(req); token != "" && a.TokenReviews != nil {
+		return a.reviewToken(token)
+	}
+
+	if a.Anonymous {
+		return &userInfo{
+			Name:   anonymousUser,
+			Groups: []string{unauthenticatedGroup},
+		}, true, nil
+	}
+	return nil, false, nil
+}
+
+// reviewToken authenticates the specified bearer token with a token review, unless its result is cached.
+func (a *authenticator) reviewToken(token string) (*userInfo, bool, error) {
+	// Tokens are cached by their hash, so as not to keep them in memory.
+	key := sha256.Sum256([]byte(token))
+	if cached, ok := a.tokenReviews.Get(key); ok {
+		r := cached.(tokenReviewResult)
+		return r.user, r.authenticated, nil
+	}
+
+	review, err := a.TokenReviews.Create(&authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	})
+	if err != nil {
+		return nil, false, errors.Wrap(err, "error creating token review")
+	}
+	if review.Status.Error != "" {
+		return nil, false, errors.Errorf("error reviewing token: %s", review.Status.Error)
+	}
+
+	var r tokenReviewResult
+	if review.Status.Authenticated {
+		u := review.Status.User
+		extra := make(map[string]authorizationv1.ExtraValue, len(u.Extra))
+		for k, v := range u.Extra {
+			extra[k] = authorizationv1.ExtraValue(v)
+		}
+		r = tokenReviewResult{
+			user: &userInfo{
+				Name:   u.Username,
+				UID:    u.UID,
+				Groups: u.Groups,
+				Extra:  extra,
+			},
+			authenticated: true,
+		}
+	}
+	if a.TokenReviewCacheTTL > 0 {
+		a.tokenReviews.Add(key, r, a.TokenReviewCacheTTL)
+	}
+	return r.user, r.authenticated, nil
+}
+
+// authorize determines whether the user is allowed to perform the request described by attrs.
+func (a *authenticator) authorize(user *userInfo, attrs *authorizationv1.ResourceAttributes) (bool, string, error) {
+	if a.SubjectAccessReviews == nil {
+		return true, "", nil
+	}
+
+	spec := authorizationv1.SubjectAccessReviewSpec{
+		ResourceAttributes: attrs,
+		User:               user.Name,
+		UID:                user.UID,
+		Groups:             user.Groups,
+		Extra:              user.Extra,
+	}
+	// Reviews are cached by their spec, as done by the Kubelet.
+	b, err := json.Marshal(spec)
+	if err != nil {
+		return false, "", errors.Wrap(err, "error encoding subject access review")
+	}
+	key := string(b)
+	if cached, ok := a.subjectAccessReviews.Get(key); ok {
+		r := cached.(subjectAccessReviewResult)
+		return r.allowed, r.reason, nil
+	}
+
+	review, err := a.SubjectAccessReviews.Create(&authorizationv1.SubjectAccessReview{Spec: spec})
+	if err != nil {
+		return false, "", errors.Wrap(err, "error creating subject access review")
+	}
+	if review.Status.EvaluationError != "" && !review.Status.Allowed {
+		return false, "", errors.Errorf("error evaluating subject access review: %s", review.Status.EvaluationError)
+	}
+
+	r := subjectAccessReviewResult{allowed: review.Status.Allowed, reason: review.Status.Reason}
+	ttl := a.UnauthorizedCacheTTL
+	if r.allowed {
+		ttl = a.AuthorizedCacheTTL
+	}
+	if ttl > 0 {
+		a.subjectAccessReviews.Add(key, r, ttl)
+	}
+	return r.allowed, r.reason, nil
+}
+
+// bearerToken returns the bearer token in the Authorization header of the request, if any.
+func bearerToken(req *http.Request) string {
+	parts := strings.SplitN(strings.TrimSpace(req.Header.Get("Authorization")), " ", 2)
+	if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") {
+		return ""
+	}
+	return strings.TrimSpace(parts[1])
+}
+
+// authorizationAttributes returns the attributes a request to the node's API is authorized against.
+// The subresource is determined from the path of the request in the same way as the Kubelet does.
+// https://github.com/kubernetes/kubernetes/blob/v1.13.1/pkg/kubelet/server/auth.go
+func authorizationAttributes(nodeName string, req *http.Request) *authorizationv1.ResourceAttributes {
+	var verb string
+	switch req.Method {
+	case http.MethodPost:
+		verb = "create"
+	case http.MethodGet, http.MethodHead:
+		verb = "get"
+	case http.MethodPut:
+		verb = "update"
+	case http.MethodPatch:
+		verb = "patch"
+	case http.MethodDelete:
+		verb = "delete"
+	}
+
+	subresource := "proxy"
+	switch path := req.URL.Path; {
+	case isSubpath(path, "/stats"):
+		subresource = "stats"
+	case isSubpath(path, "/metrics"):
+		subresource = "metrics"
+	case isSubpath(path, "/logs"):
+		subresource = "log"
+	case isSubpath(path, "/spec"):
+		subresource = "spec"
+	}
+
+	return &authorizationv1.ResourceAttributes{
+		Verb:        verb,
+		Resource:    "nodes",
+		Subresource: subresource,
+		Name:        nodeName,
+	}
+}
+
+// isSubpath returns whether path is equal to, or nested under, the specified prefix.
+func isSubpath(path, prefix string) bool {
+	return path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/")
+}
@@ -0,0 +1,136 @@
+package vkubelet
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// TestAuthorizationAttributes verifies that requests are authorized against the same node subresources as with the Kubelet.
+func TestAuthorizationAttributes(t *testing.T) {
+	cases := []struct {
+		method      string
+		path        string
+		verb        string
+		subresource string
+	}{
+		{http.MethodGet, "/containerLogs/default/pod/container", "get", "proxy"},
+		{http.MethodPost, "/exec/default/pod/container", "create", "proxy"},
+		{http.MethodGet, "/pods", "get", "proxy"},
+		{http.MethodGet, "/stats/summary", "get", "stats"},
+		{http.MethodGet, "/stats", "get", "stats"},
+		{http.MethodGet, "/statsfoo", "get", "proxy"},
+		{http.MethodGet, "/metrics", "get", "metrics"},
+		{http.MethodGet, "/logs/", "get", "log"},
+		{http.MethodGet, "/spec/", "get", "spec"},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.path, nil)
+		attrs := authorizationAttributes("node", req)
+		assert.Equal(t, c.verb, attrs.Verb, c.path)
+		assert.Equal(t, "nodes", attrs.Resource, c.path)
+		assert.Equal(t, c.subresource, attrs.Subresource, c.path)
+		assert.Equal(t, "node", attrs.Name, c.path)
+	}
+}
+
+// TestAuthHandler verifies that requests are served only when authenticated and authorized.
+func TestAuthHandler(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		if review.Spec.Token == "valid" {
+			review.Status.Authenticated = true
+			review.Status.User = authenticationv1.UserInfo{Username: "admin"}
+		}
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		review.Status.Allowed = review.Spec.User == "admin"
+		return true, review, nil
+	})
+
+	h := AuthHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}), AuthConfig{
+		NodeName:             "node",
+		Anonymous:            true,
+		TokenReviews:         client.AuthenticationV1().TokenReviews(),
+		SubjectAccessReviews: client.AuthorizationV1().SubjectAccessReviews(),
+	})
+
+	cases := []struct {
+		token string
+		code  int
+	}{
+		{"valid", http.StatusOK},
+		{"invalid", http.StatusUnauthorized},
+		{"", http.StatusForbidden},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/exec/default/pod/container", nil)
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		assert.Equal(t, c.code, w.Code, c.token)
+	}
+}
+
+// TestAuthHandlerCache verifies that the results of token and subject access reviews are cached for their TTL.
+func TestAuthHandlerCache(t *testing.T) {
+	var tokenReviews, subjectAccessReviews int
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		tokenReviews++
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		review.Status.Authenticated = review.Spec.Token != "invalid"
+		review.Status.User = authenticationv1.UserInfo{Username: review.Spec.Token}
+		return true, review, nil
+	})
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		subjectAccessReviews++
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		review.Status.Allowed = review.Spec.User == "admin"
+		return true, review, nil
+	})
+
+	h := AuthHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}), AuthConfig{
+		NodeName:             "node",
+		TokenReviews:         client.AuthenticationV1().TokenReviews(),
+		SubjectAccessReviews: client.AuthorizationV1().SubjectAccessReviews(),
+		TokenReviewCacheTTL:  time.Minute,
+		AuthorizedCacheTTL:   time.Minute,
+	})
+	serve := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/pods", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusOK, serve("admin"))
+		assert.Equal(t, http.StatusUnauthorized, serve("invalid"))
+	}
+	assert.Equal(t, 2, tokenReviews)
+	assert.Equal(t, 1, subjectAccessReviews)
+
+	// Denied reviews aren't cached without an UnauthorizedCacheTTL.
+	for i := 0; i < 2; i++ {
+		assert.Equal(t, http.StatusForbidden, serve("user"))
+	}
+	assert.Equal(t, 3, tokenReviews)
+	assert.Equal(t, 3, subjectAccessReviews)
+}
@@ -0,0 +1,315 @@
+package vkubelet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	cryptorand "crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cpuguy83/strongerrors/status/ocstatus"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	certificatesv1beta1client "k8s.io/client-go/kubernetes/typed/certificates/v1beta1"
+	certutil "k8s.io/client-go/util/cert"
+
+	"github.com/virtual-kubelet/virtual-kubelet/log"
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+)
+
+const (
+	// certificatePollInterval is the interval at which a certificate signing request is checked for approval.
+	certificatePollInterval = 5 * time.Second
+	// certificateApprovalTimeout is the amount of time to wait for a certificate signing request to be approved and signed.
+	// It is set to the same value used by the Kubelet.
+	certificateApprovalTimeout = 15 * time.Minute
+	// certificateRetryInterval is the amount of time to wait before requesting a new certificate after a failure.
+	certificateRetryInterval = 30 * time.Second
+	// currentCertificateFile is the name of the file holding the current serving certificate and its key, when persisted.
+	currentCertificateFile = "vkubelet-server-current.pem"
+)
+
+// CertificateManagerConfig is used to configure a new certificate manager.
+type CertificateManagerConfig struct {
+	// Client is used to submit certificate signing requests for the serving certificate.
+	Client certificatesv1beta1client.CertificateSigningRequestInterface
+	// NodeName is the name of the node the serving certificate is requested for.
+	NodeName string
+	// Provider supplies the addresses of the node, which are used as the subject alternative names of the certificate.
+	Provider providers.Provider
+	// Dir, when set, is the directory the current certificate is persisted to, so that it is reused across restarts.
+	Dir string
+}
+
+// CertificateManager obtains the serving certificate of the virtual node from the Kubernetes certificates API,
+// and rotates it before it expires.
+//
+// As with the Kubelet, the certificate signing requests must be approved by an administrator or an external controller.
+type CertificateManager struct {
+	csrs     certificatesv1beta1client.CertificateSigningRequestInterface
+	nodeName string
+	provider providers.Provider
+	dir      string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// NewCertificateManager creates a new certificate manager.
+// If a certificate was persisted to the configured directory and has not expired yet, it is used until it is rotated.
+//
+// This creates but does not start the manager.
+// You must call `Run` on the returned object to obtain and rotate the certificate.
+func NewCertificateManager(cfg CertificateManagerConfig) (*CertificateManager, error) {
+	m := &CertificateManager{
+		csrs:     cfg.Client,
+		nodeName: cfg.NodeName,
+		provider: cfg.Provider,
+		dir:      cfg.Dir,
+	}
+
+	if m.dir != "" {
+		cert, err := loadCertificate(filepath.Join(m.dir, currentCertificateFile))
+		if err != nil && !os.IsNotExist(errors.Cause(err)) {
+			return nil, err
+		}
+		if cert != nil && time.Now().Before(cert.Leaf.NotAfter) {
+			m.cert = cert
+		}
+	}
+
+	return m, nil
+}
+
+// GetCertificate returns the current serving certificate.
+// It is meant to be used as the GetCertificate function of a tls.Config, so that rotated certificates are served
+// without restarting the server.
+func (m *CertificateManager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.cert == nil {
+		return nil, errors.New("no serving certificate available yet, waiting for the certificate signing request to be approved")
+	}
+	return m.cert, nil
+}
+
+// Run requests a new serving certificate whenever the current one approaches its expiry, until the specified context is cancelled.
+func (m *CertificateManager) Run(ctx context.Context) {
+	t := time.NewTimer(m.nextRotation())
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := m.rotate(ctx); err != nil {
+				log.G(ctx).WithError(err).Error("Failed to rotate serving certificate")
+				t.Reset(certificateRetryInterval)
+				continue
+			}
+			t.Reset(m.nextRotation())
+		}
+	}
+}
+
+// nextRotation returns the amount of time until the current certificate should be rotated.
+// As done by the Kubelet, this is a random point between 70% and 90% of the certificate's lifetime.
+func (m *CertificateManager) nextRotation() time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.cert == nil {
+		return 0
+	}
+
+	notBefore, notAfter := m.cert.Leaf.NotBefore, m.cert.Leaf.NotAfter
+	lifetime := float64(notAfter.Sub(notBefore))
+	deadline := notBefore.Add(time.Duration(lifetime * (0.7 + 0.2*rand.Float64())))
+	if d := time.Until(deadline); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// rotate requests a new serving certificate with a new key, and waits for it to be issued before using it.
+func (m *CertificateManager) rotate(ctx context.Context) error {
+	ctx, span := trace.StartSpan(ctx, "rotateCertificate")
+	defer span.End()
+
+	cert, err := m.requestCertificate(ctx)
+	if err != nil {
+		span.SetStatus(ocstatus.FromError(err))
+		return err
+	}
+
+	if m.dir != "" {
+		if err := storeCertificate(filepath.Join(m.dir, currentCertificateFile), cert); err != nil {
+			// The certificate can still be served, it just has to be requested again after a restart.
+			log.G(ctx).WithError(err).Warn("Failed to persist serving certificate")
+		}
+	}
+
+	m.mu.Lock()
+	m.cert = cert
+	m.mu.Unlock()
+
+	span.Annotate(nil, "Rotated serving certificate")
+	log.G(ctx).WithField("expiration", cert.Leaf.NotAfter).Info("Rotated serving certificate")
+	return nil
+}
+
+// requestCertificate submits a certificate signing request for the serving certificate of the node, and returns
+// the certificate once the request is approved and signed.
+func (m *CertificateManager) requestCertificate(ctx context.Context) (*tls.Certificate, error) {
+	var (
+		dnsNames []string
+		ips      []net.IP
+	)
+	for _, a := range m.provider.NodeAddresses(ctx) {
+		switch a.Type {
+		case corev1.NodeHostName, corev1.NodeInternalDNS, corev1.NodeExternalDNS:
+			dnsNames = append(dnsNames, a.Address)
+		case corev1.NodeInternalIP, corev1.NodeExternalIP:
+			if ip := net.ParseIP(a.Address); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+	}
+	if len(dnsNames) == 0 && len(ips) == 0 {
+		return nil, errors.New("the provider did not return any node address to request the serving certificate for")
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), cryptorand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "error generating private key")
+	}
+	keyPEM, err := certutil.MarshalPrivateKeyToPEM(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding private key")
+	}
+
+	// Use the same subject as the Kubelet, so that the requests are recognised by the node authorizer and approvers.
+	subject := &pkix.Name{
+		CommonName:   "system:node:" + m.nodeName,
+		Organization: []string{"system:nodes"},
+	}
+	csrPEM, err := certutil.MakeCSR(key, subject, dnsNames, ips)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating certificate signing request")
+	}
+
+	csr, err := m.csrs.Create(&certificatesv1beta1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "csr-",
+		},
+		Spec: certificatesv1beta1.CertificateSigningRequestSpec{
+			Request: csrPEM,
+			Usages: []certificatesv1beta1.KeyUsage{
+				certificatesv1beta1.UsageDigitalSignature,
+				certificatesv1beta1.UsageKeyEncipherment,
+				certificatesv1beta1.UsageServerAuth,
+			},
+		},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "error submitting certificate signing request")
+	}
+	log.G(ctx).WithField("csr", csr.Name).Info("Waiting for the serving certificate signing request to be approved")
+
+	certPEM, err := m.waitForCertificate(ctx, csr.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading issued certificate")
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return nil, errors.Wrap(err, "error parsing issued certificate")
+	}
+	return &cert, nil
+}
+
+// waitForCertificate waits for the named certificate signing request to be approved and signed, and returns the issued certificate.
+func (m *CertificateManager) waitForCertificate(ctx context.Context, name string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, certificateApprovalTimeout)
+	defer cancel()
+
+	var certPEM []byte
+	err := wait.PollImmediateUntil(certificatePollInterval, func() (bool, error) {
+		csr, err := m.csrs.Get(name, metav1.GetOptions{})
+		if err != nil {
+			log.G(ctx).WithError(err).WithField("csr", name).Warn("Error fetching certificate signing request")
+			return false, nil
+		}
+		for _, c := range csr.Status.Conditions {
+			if c.Type == certificatesv1beta1.CertificateDenied {
+				return false, errors.Errorf("certificate signing request %s was denied: %s", name, c.Message)
+			}
+		}
+		if len(csr.Status.Certificate) == 0 {
+			return false, nil
+		}
+		certPEM = csr.Status.Certificate
+		return true, nil
+	}, ctx.Done())
+	if err == wait.ErrWaitTimeout {
+		return nil, errors.Errorf("timed out waiting for certificate signing request %s to be approved", name)
+	}
+	return certPEM, err
+}
+
+// loadCertificate loads a certificate and its key from the specified file.
+func loadCertificate(path string) (*tls.Certificate, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading certificate file")
+	}
+	cert, err := tls.X509KeyPair(b, b)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error loading certificate from %s", path)
+	}
+	if cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0]); err != nil {
+		return nil, errors.Wrapf(err, "error parsing certificate from %s", path)
+	}
+	return &cert, nil
+}
+
+// storeCertificate writes a certificate and its key to the specified file.
+// The file is replaced atomically, so that a partially written certificate is never loaded.
+func storeCertificate(path string, cert *tls.Certificate) error {
+	keyPEM, err := certutil.MarshalPrivateKeyToPEM(cert.PrivateKey)
+	if err != nil {
+		return errors.Wrap(err, "error encoding private key")
+	}
+	var b []byte
+	for _, der := range cert.Certificate {
+		b = append(b, certutil.EncodeCertPEM(&x509.Certificate{Raw: der})...)
+	}
+	b = append(b, keyPEM...)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return errors.Wrap(err, "error creating certificate directory")
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return errors.Wrap(err, "error writing certificate file")
+	}
+	return errors.Wrap(os.Rename(tmp, path), "error replacing certificate file")
+}
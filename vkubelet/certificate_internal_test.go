@@ -0,0 +1,118 @@
+package vkubelet
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	certificatesv1beta1 "k8s.io/api/certificates/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+)
+
+// addressProvider is a provider which only implements NodeAddresses.
+type addressProvider struct {
+	providers.Provider
+	addresses []corev1.NodeAddress
+}
+
+func (p *addressProvider) NodeAddresses(context.Context) []corev1.NodeAddress {
+	return p.addresses
+}
+
+// TestCertificateManagerRotate verifies that the serving certificate is requested for the node's addresses, served once
+// issued, and reused by a new manager when persisted.
+func TestCertificateManagerRotate(t *testing.T) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+
+	// Sign every submitted request, so that the certificate is available the first time it is checked for.
+	var issued *certificatesv1beta1.CertificateSigningRequest
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "certificatesigningrequests", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		csr := action.(k8stesting.CreateAction).GetObject().(*certificatesv1beta1.CertificateSigningRequest)
+		block, _ := pem.Decode(csr.Spec.Request)
+		req, err := x509.ParseCertificateRequest(block.Bytes)
+		require.NoError(t, err)
+
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      req.Subject,
+			DNSNames:     req.DNSNames,
+			IPAddresses:  req.IPAddresses,
+			NotBefore:    time.Now(),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, req.PublicKey, caKey)
+		require.NoError(t, err)
+
+		issued = csr.DeepCopy()
+		issued.Name = "csr-1"
+		issued.Status.Certificate = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+		return true, issued, nil
+	})
+	client.PrependReactor("get", "certificatesigningrequests", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, issued, nil
+	})
+
+	dir, err := ioutil.TempDir("", "vkubelet-certificate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg := CertificateManagerConfig{
+		Client:   client.CertificatesV1beta1().CertificateSigningRequests(),
+		NodeName: "node",
+		Provider: &addressProvider{
+			addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+				{Type: corev1.NodeHostName, Address: "node.example.com"},
+			},
+		},
+		Dir: dir,
+	}
+
+	m, err := NewCertificateManager(cfg)
+	require.NoError(t, err)
+	_, err = m.GetCertificate(nil)
+	assert.Error(t, err)
+	assert.Equal(t, time.Duration(0), m.nextRotation())
+
+	require.NoError(t, m.rotate(context.Background()))
+	cert, err := m.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "system:node:node", cert.Leaf.Subject.CommonName)
+	assert.Equal(t, []string{"node.example.com"}, cert.Leaf.DNSNames)
+	assert.Equal(t, "10.0.0.1", cert.Leaf.IPAddresses[0].String())
+	assert.True(t, m.nextRotation() > 30*time.Minute)
+
+	m, err = NewCertificateManager(cfg)
+	require.NoError(t, err)
+	reloaded, err := m.GetCertificate(nil)
+	require.NoError(t, err)
+	assert.Equal(t, cert.Certificate, reloaded.Certificate)
+}
@@ -11,8 +11,10 @@ import (
 	apivalidation "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/tools/record"
 
+	"github.com/virtual-kubelet/virtual-kubelet/fieldpath"
 	"github.com/virtual-kubelet/virtual-kubelet/log"
 	"github.com/virtual-kubelet/virtual-kubelet/manager"
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
 )
 
 const (
@@ -44,22 +46,28 @@ const (
 	// ReasonFailedToReadMandatorySecret is the reason used in events emitted when an mandatory secret could not be read.
 	ReasonFailedToReadMandatorySecret = "FailedToReadMandatorySecret"
 
+	// ReasonUnsupportedFieldPath is the reason used in events emitted when a ".valueFrom.fieldRef" field references an unsupported field of the pod.
+	ReasonUnsupportedFieldPath = "UnsupportedFieldPath"
+	// ReasonUnsupportedResourceField is the reason used in events emitted when a ".valueFrom.resourceFieldRef" field references an unsupported resource or an unknown container.
+	ReasonUnsupportedResourceField = "UnsupportedResourceField"
+
 	// ReasonInvalidEnvironmentVariableNames is the reason used in events emitted when a configmap/secret referenced in a ".spec.containers[*].envFrom" field contains invalid environment variable names.
 	ReasonInvalidEnvironmentVariableNames = "InvalidEnvironmentVariableNames"
 )
 
 // populateEnvironmentVariables populates the environment of each container (and init container) in the specified pod.
+// The provider supplies the values of the node referenced through the downward API, such as its IP address and allocatable resources.
 // TODO Make this the single exported function of a "pkg/environment" package in the future.
-func populateEnvironmentVariables(ctx context.Context, pod *corev1.Pod, rm *manager.ResourceManager, recorder record.EventRecorder) error {
+func populateEnvironmentVariables(ctx context.Context, pod *corev1.Pod, rm *manager.ResourceManager, p providers.Provider, recorder record.EventRecorder) error {
 	// Populate each init container's environment.
 	for idx := range pod.Spec.InitContainers {
-		if err := populateContainerEnvironment(ctx, pod, &pod.Spec.InitContainers[idx], rm, recorder); err != nil {
+		if err := populateContainerEnvironment(ctx, pod, &pod.Spec.InitContainers[idx], rm, p, recorder); err != nil {
 			return err
 		}
 	}
 	// Populate each container's environment.
 	for idx := range pod.Spec.Containers {
-		if err := populateContainerEnvironment(ctx, pod, &pod.Spec.Containers[idx], rm, recorder); err != nil {
+		if err := populateContainerEnvironment(ctx, pod, &pod.Spec.Containers[idx], rm, p, recorder); err != nil {
 			return err
 		}
 	}
@@ -67,14 +75,14 @@ func populateEnvironmentVariables(ctx context.Context, pod *corev1.Pod, rm *mana
 }
 
 // populateContainerEnvironment populates the environment of a single container in the specified pod.
-func populateContainerEnvironment(ctx context.Context, pod *corev1.Pod, container *corev1.Container, rm *manager.ResourceManager, recorder record.EventRecorder) error {
+func populateContainerEnvironment(ctx context.Context, pod *corev1.Pod, container *corev1.Container, rm *manager.ResourceManager, p providers.Provider, recorder record.EventRecorder) error {
 	// Create an "environment map" based on the value of the specified container's ".envFrom" field.
 	envFrom, err := makeEnvironmentMapBasedOnEnvFrom(ctx, pod, container, rm, recorder)
 	if err != nil {
 		return err
 	}
 	// Create an "environment map" based on the value of the specified container's ".env" field.
-	env, err := makeEnvironmentMapBasedOnEnv(ctx, pod, container, rm, recorder)
+	env, err := makeEnvironmentMapBasedOnEnv(ctx, pod, container, rm, p, recorder)
 	if err != nil {
 		return err
 	}
@@ -210,7 +218,7 @@ loop:
 }
 
 // makeEnvironmentMapBasedOnEnv returns a map representing the resolved environment of the specified container after being populated from the entries in the ".env" field.
-func makeEnvironmentMapBasedOnEnv(ctx context.Context, pod *corev1.Pod, container *corev1.Container, rm *manager.ResourceManager, recorder record.EventRecorder) (map[string]string, error) {
+func makeEnvironmentMapBasedOnEnv(ctx context.Context, pod *corev1.Pod, container *corev1.Container, rm *manager.ResourceManager, p providers.Provider, recorder record.EventRecorder) (map[string]string, error) {
 	// Create a map to hold the resolved environment variables.
 	res := make(map[string]string, len(container.Env))
 	// Iterate over environment variables in order to populate the map.
@@ -328,12 +336,34 @@ loop:
 			continue loop
 		// Handle population from a field (downward API).
 		case env.ValueFrom != nil && env.ValueFrom.FieldRef != nil:
-			// TODO Implement the downward API.
-			// https://github.com/virtual-kubelet/virtual-kubelet/issues/123
+			vf := env.ValueFrom.FieldRef
+			val, ok, err := podFieldSelectorRuntimeValue(ctx, vf, pod, p)
+			if err != nil {
+				// The field is not supported by the downward API.
+				// As with the Kubelet, this is an error.
+				recorder.Eventf(pod, corev1.EventTypeWarning, ReasonUnsupportedFieldPath, "envvar %q references unsupported field %q", env.Name, vf.FieldPath)
+				return nil, fmt.Errorf("failed to resolve envvar %q: %v", env.Name, err)
+			}
+			if !ok {
+				// The field is supported but has no value yet, as is always the case of the pod's IP address, which
+				// is only assigned by the provider when creating the pod. This is expected, so the variable is left
+				// out rather than set to an empty value, for providers to set it when they can.
+				log.G(ctx).Debugf("skipping envvar %q: field %q has no value yet", env.Name, vf.FieldPath)
+				continue loop
+			}
+			// Populate the environment variable and continue on to the next reference.
+			res[env.Name] = val
 			continue loop
 		// Handle population from a resource request/limit.
 		case env.ValueFrom != nil && env.ValueFrom.ResourceFieldRef != nil:
-			// TODO Implement populating resource requests.
+			vf := env.ValueFrom.ResourceFieldRef
+			val, err := fieldpath.ContainerResourceValue(vf, pod, container, p.Capacity(ctx))
+			if err != nil {
+				recorder.Eventf(pod, corev1.EventTypeWarning, ReasonUnsupportedResourceField, "envvar %q references unsupported resource %q of container %q", env.Name, vf.Resource, vf.ContainerName)
+				return nil, fmt.Errorf("failed to resolve envvar %q: %v", env.Name, err)
+			}
+			// Populate the environment variable and continue on to the next reference.
+			res[env.Name] = val
 			continue loop
 		}
 	}
@@ -341,6 +371,34 @@ loop:
 	return res, nil
 }
 
+// podFieldSelectorRuntimeValue returns the value of the field of the pod referenced by the specified selector.
+// The second return value is false when the field is supported but has no value yet.
+// The supported fields are the same as the Kubelet's.
+// https://github.com/kubernetes/kubernetes/blob/v1.13.1/pkg/kubelet/kubelet_pods.go#L735-L751
+func podFieldSelectorRuntimeValue(ctx context.Context, fs *corev1.ObjectFieldSelector, pod *corev1.Pod, p providers.Provider) (string, bool, error) {
+	switch fs.FieldPath {
+	case "spec.nodeName":
+		return pod.Spec.NodeName, pod.Spec.NodeName != "", nil
+	case "spec.serviceAccountName":
+		return pod.Spec.ServiceAccountName, true, nil
+	case "status.hostIP":
+		if pod.Status.HostIP != "" {
+			return pod.Status.HostIP, true, nil
+		}
+		// The host IP is only set once the pod's status is reported, so fall back to the node's address.
+		for _, a := range p.NodeAddresses(ctx) {
+			if a.Type == corev1.NodeInternalIP {
+				return a.Address, true, nil
+			}
+		}
+		return "", false, nil
+	case "status.podIP":
+		return pod.Status.PodIP, pod.Status.PodIP != "", nil
+	}
+	val, err := fieldpath.ExtractFieldPathAsString(pod, fs.FieldPath)
+	return val, err == nil, err
+}
+
 // mergeEnvironments creates the final environment for a container by merging "envFrom" and "env".
 // Values in "env" override any values with the same key defined in "envFrom".
 // This is in accordance with what the Kubelet itself does.
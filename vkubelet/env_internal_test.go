@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	testutil "github.com/virtual-kubelet/virtual-kubelet/test/util"
@@ -149,7 +150,7 @@ func TestPopulatePodWithInitContainers(t *testing.T) {
 	}
 
 	// Populate the pod's environment.
-	err := populateEnvironmentVariables(context.Background(), pod, rm, er)
+	err := populateEnvironmentVariables(context.Background(), pod, rm, nil, er)
 	assert.NoError(t, err)
 
 	// Make sure that all the containers' environments contain all the expected keys and values.
@@ -227,7 +228,7 @@ func TestEnvFromTwoConfigMapsAndOneSecret(t *testing.T) {
 	}
 
 	// Populate the container's environment.
-	err := populateContainerEnvironment(context.Background(), pod, &pod.Spec.Containers[0], rm, er)
+	err := populateContainerEnvironment(context.Background(), pod, &pod.Spec.Containers[0], rm, nil, er)
 	assert.NoError(t, err)
 
 	// Make sure that the container's environment contains all the expected keys and values.
@@ -288,7 +289,7 @@ func TestEnvFromConfigMapAndSecretWithInvalidKeys(t *testing.T) {
 	}
 
 	// Populate the pods's environment.
-	err := populateEnvironmentVariables(context.Background(), pod, rm, er)
+	err := populateEnvironmentVariables(context.Background(), pod, rm, nil, er)
 	assert.NoError(t, err)
 
 	// Make sure that the container's environment has two variables (corresponding to the single valid key in both the configmap and the secret).
@@ -358,7 +359,7 @@ func TestEnvOverridesEnvFrom(t *testing.T) {
 	}
 
 	// Populate the pods's environment.
-	err := populateEnvironmentVariables(context.Background(), pod, rm, er)
+	err := populateEnvironmentVariables(context.Background(), pod, rm, nil, er)
 	assert.NoError(t, err)
 
 	// Make sure that the container's environment contains all the expected keys and values.
@@ -422,7 +423,7 @@ func TestEnvFromInexistentConfigMaps(t *testing.T) {
 	}
 
 	// Populate the pods's environment.
-	err := populateEnvironmentVariables(context.Background(), pod, rm, er)
+	err := populateEnvironmentVariables(context.Background(), pod, rm, nil, er)
 	assert.Error(t, err)
 
 	// Make sure that two events have been recorded with the correct reason and message.
@@ -478,7 +479,7 @@ func TestEnvFromInexistentSecrets(t *testing.T) {
 	}
 
 	// Populate the pods's environment.
-	err := populateEnvironmentVariables(context.Background(), pod, rm, er)
+	err := populateEnvironmentVariables(context.Background(), pod, rm, nil, er)
 	assert.Error(t, err)
 
 	// Make sure that two events have been recorded with the correct reason and message.
@@ -490,3 +491,172 @@ func TestEnvFromInexistentSecrets(t *testing.T) {
 	assert.Contains(t, event2, ReasonMandatorySecretNotFound)
 	assert.Contains(t, event2, missingSecret2Name)
 }
+
+// capacityProvider is a provider which only implements the methods describing the addresses and capacity of the node.
+type capacityProvider struct {
+	addressProvider
+	capacity corev1.ResourceList
+}
+
+func (p *capacityProvider) Capacity(context.Context) corev1.ResourceList {
+	return p.capacity
+}
+
+// TestEnvFromDownwardAPI populates the environment of a container from fields and resources of the pod.
+// Then, it checks that the expected values have been populated, with unset limits defaulting to the node's capacity.
+func TestEnvFromDownwardAPI(t *testing.T) {
+	rm := testutil.FakeResourceManager()
+	er := testutil.FakeEventRecorder(defaultEventRecorderBufferSize)
+	p := &capacityProvider{
+		addressProvider: addressProvider{
+			addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeInternalIP, Address: "10.0.0.1"},
+			},
+		},
+		capacity: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("4Gi"),
+		},
+	}
+
+	fieldRef := func(name, path string) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name:      name,
+			ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: path}},
+		}
+	}
+	resourceFieldRef := func(name, res, divisor string) corev1.EnvVar {
+		fs := &corev1.ResourceFieldSelector{Resource: res}
+		if divisor != "" {
+			fs.Divisor = resource.MustParse(divisor)
+		}
+		return corev1.EnvVar{
+			Name:      name,
+			ValueFrom: &corev1.EnvVarSource{ResourceFieldRef: fs},
+		}
+	}
+
+	// Create a pod object having a single container, whose environment is to be populated using the downward API.
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      "pod-0",
+			UID:       "uid-0",
+			Labels:    map[string]string{"app": "foo", "tier": "web"},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:           "node-0",
+			ServiceAccountName: "default",
+			Containers: []corev1.Container{
+				{
+					Name: "container-0",
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("250m"),
+						},
+						Limits: corev1.ResourceList{
+							corev1.ResourceCPU: resource.MustParse("1500m"),
+						},
+					},
+					Env: []corev1.EnvVar{
+						fieldRef("POD_NAME", "metadata.name"),
+						fieldRef("POD_NAMESPACE", "metadata.namespace"),
+						fieldRef("POD_UID", "metadata.uid"),
+						fieldRef("POD_LABELS", "metadata.labels"),
+						fieldRef("POD_APP", "metadata.labels['app']"),
+						fieldRef("NODE_NAME", "spec.nodeName"),
+						fieldRef("SERVICE_ACCOUNT", "spec.serviceAccountName"),
+						fieldRef("HOST_IP", "status.hostIP"),
+						fieldRef("POD_IP", "status.podIP"),
+						resourceFieldRef("CPU_REQUEST", "requests.cpu", "1m"),
+						resourceFieldRef("CPU_LIMIT", "limits.cpu", ""),
+						resourceFieldRef("MEMORY_LIMIT", "limits.memory", "1Mi"),
+					},
+				},
+			},
+		},
+	}
+
+	// Populate the pods's environment.
+	err := populateEnvironmentVariables(context.Background(), pod, rm, p, er)
+	assert.NoError(t, err)
+
+	// Make sure that the container's environment contains all the expected keys and values.
+	assert.ElementsMatch(t, pod.Spec.Containers[0].Env, []corev1.EnvVar{
+		{Name: "POD_NAME", Value: "pod-0"},
+		{Name: "POD_NAMESPACE", Value: namespace},
+		{Name: "POD_UID", Value: "uid-0"},
+		{Name: "POD_LABELS", Value: "app=\"foo\"\ntier=\"web\""},
+		{Name: "POD_APP", Value: "foo"},
+		{Name: "NODE_NAME", Value: "node-0"},
+		{Name: "SERVICE_ACCOUNT", Value: "default"},
+		{Name: "HOST_IP", Value: "10.0.0.1"},
+		{Name: "CPU_REQUEST", Value: "250"},
+		{Name: "CPU_LIMIT", Value: "2"},
+		{Name: "MEMORY_LIMIT", Value: "4096"},
+	})
+
+	// Make sure that no event has been recorded, as the pod not having been assigned an IP address yet is expected.
+	assert.Len(t, er.Events, 0)
+}
+
+// TestEnvFromUnsupportedDownwardAPIFields populates the environment of a container from unsupported fields and resources of the pod.
+// Then, it checks that the expected events have been recorded.
+func TestEnvFromUnsupportedDownwardAPIFields(t *testing.T) {
+	rm := testutil.FakeResourceManager()
+
+	tests := []struct {
+		name   string
+		source *corev1.EnvVarSource
+		reason string
+	}{
+		{
+			name:   "unsupported field",
+			source: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "spec.hostname"}},
+			reason: ReasonUnsupportedFieldPath,
+		},
+		{
+			name:   "unsupported subscript",
+			source: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name['foo']"}},
+			reason: ReasonUnsupportedFieldPath,
+		},
+		{
+			name:   "unsupported resource",
+			source: &corev1.EnvVarSource{ResourceFieldRef: &corev1.ResourceFieldSelector{Resource: "limits.nvidia.com/gpu"}},
+			reason: ReasonUnsupportedResourceField,
+		},
+		{
+			name:   "unknown container",
+			source: &corev1.EnvVarSource{ResourceFieldRef: &corev1.ResourceFieldSelector{ContainerName: "missing", Resource: "limits.cpu"}},
+			reason: ReasonUnsupportedResourceField,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			er := testutil.FakeEventRecorder(defaultEventRecorderBufferSize)
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: namespace,
+					Name:      "pod-0",
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name: "container-0",
+							Env: []corev1.EnvVar{
+								{Name: keyFoo, ValueFrom: test.source},
+							},
+						},
+					},
+				},
+			}
+
+			err := populateEnvironmentVariables(context.Background(), pod, rm, &capacityProvider{}, er)
+			assert.Error(t, err)
+
+			assert.Len(t, er.Events, 1)
+			event := <-er.Events
+			assert.Contains(t, event, test.reason)
+		})
+	}
+}
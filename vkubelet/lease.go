@@ -0,0 +1,119 @@
+package vkubelet
+
+import (
+	"context"
+	"time"
+
+	"github.com/cpuguy83/strongerrors/status/ocstatus"
+	pkgerrors "github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	coordv1beta1 "k8s.io/api/coordination/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/virtual-kubelet/virtual-kubelet/log"
+)
+
+const (
+	// DefaultNodeLeaseDurationSeconds is the default duration of the lease used as the node's heartbeat.
+	// It is set to the same value used by the Kubelet.
+	// https://github.com/kubernetes/kubernetes/blob/v1.13.1/pkg/kubelet/apis/config/v1beta1/defaults.go
+	DefaultNodeLeaseDurationSeconds = 40
+	// leaseRenewIntervalFraction is the fraction of the lease duration after which the lease is renewed.
+	// It is set to the same value used by the Kubelet.
+	leaseRenewIntervalFraction = 0.25
+)
+
+// The leases are managed with the coordination.k8s.io/v1beta1 API only because it is the only version known to the
+// vendored client-go. Kubernetes serves that version from v1.12 to v1.21 and removed it in v1.22, so node leases
+// can't be used with later clusters until client-go is updated to use coordination.k8s.io/v1.
+
+// leaseSyncLoop periodically creates or renews the lease used as the heartbeat of the virtual node, until the specified context is cancelled.
+func (s *Server) leaseSyncLoop(ctx context.Context) {
+	interval := time.Duration(leaseRenewIntervalFraction * float64(time.Duration(s.nodeLeaseDurationSeconds)*time.Second))
+
+	t := time.NewTimer(0)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := s.renewLease(ctx); err != nil {
+				log.G(ctx).WithError(err).Error("Failed to renew node lease")
+			}
+			t.Reset(interval)
+		}
+	}
+}
+
+// renewLease creates the lease for the virtual node if it doesn't exist yet, or updates its renew time otherwise.
+func (s *Server) renewLease(ctx context.Context) error {
+	ctx, span := trace.StartSpan(ctx, "renewLease")
+	defer span.End()
+
+	leases := s.k8sClient.CoordinationV1beta1().Leases(corev1.NamespaceNodeLease)
+
+	lease, err := leases.Get(s.nodeName, metav1.GetOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			span.SetStatus(ocstatus.FromError(err))
+			return pkgerrors.Wrap(err, "error fetching node lease")
+		}
+		if _, err := leases.Create(s.newLease(ctx, nil)); err != nil {
+			span.SetStatus(ocstatus.FromError(err))
+			return pkgerrors.Wrap(err, "error creating node lease")
+		}
+		span.Annotate(nil, "Created node lease")
+		return nil
+	}
+
+	if _, err := leases.Update(s.newLease(ctx, lease)); err != nil {
+		s.recordUpdateConflict(ctx, "lease", err)
+		span.SetStatus(ocstatus.FromError(err))
+		return pkgerrors.Wrap(err, "error renewing node lease")
+	}
+	span.Annotate(nil, "Renewed node lease")
+	return nil
+}
+
+// newLease returns a lease for the virtual node based on the specified one, with its renew time set to the current time.
+// If base is nil, a new lease is created.
+func (s *Server) newLease(ctx context.Context, base *coordv1beta1.Lease) *coordv1beta1.Lease {
+	var lease *coordv1beta1.Lease
+	if base == nil {
+		lease = &coordv1beta1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      s.nodeName,
+				Namespace: corev1.NamespaceNodeLease,
+			},
+		}
+	} else {
+		lease = base.DeepCopy()
+	}
+
+	lease.Spec.HolderIdentity = &s.nodeName
+	lease.Spec.LeaseDurationSeconds = &s.nodeLeaseDurationSeconds
+	lease.Spec.RenewTime = &metav1.MicroTime{Time: time.Now()}
+
+	// Make the node own the lease, so that the lease is garbage collected when the node is deleted.
+	// This is done on a best-effort basis, as the node may not have been registered yet.
+	if len(lease.OwnerReferences) == 0 {
+		if node, err := s.k8sClient.CoreV1().Nodes().Get(s.nodeName, metav1.GetOptions{}); err != nil {
+			log.G(ctx).WithError(err).Warn("Failed to fetch node to set it as the owner of the node lease")
+		} else {
+			lease.OwnerReferences = []metav1.OwnerReference{
+				{
+					APIVersion: corev1.SchemeGroupVersion.String(),
+					Kind:       "Node",
+					Name:       node.Name,
+					UID:        node.UID,
+				},
+			}
+		}
+	}
+
+	return lease
+}
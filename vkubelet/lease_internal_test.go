@@ -0,0 +1,78 @@
+package vkubelet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestRenewLease verifies that the lease of the node is created, and then renewed, with the node as its owner.
+func TestRenewLease(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	s := New(Config{NodeName: "node", Client: client, Provider: &registeringProvider{}, NodeLeaseDurationSeconds: 40})
+	leases := client.CoordinationV1beta1().Leases(corev1.NamespaceNodeLease)
+	ctx := context.Background()
+
+	// The lease is created even though the node isn't registered yet.
+	require.NoError(t, s.renewLease(ctx))
+	lease, err := leases.Get("node", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, lease.Spec.HolderIdentity)
+	assert.Equal(t, "node", *lease.Spec.HolderIdentity)
+	require.NotNil(t, lease.Spec.LeaseDurationSeconds)
+	assert.Equal(t, int32(40), *lease.Spec.LeaseDurationSeconds)
+	require.NotNil(t, lease.Spec.RenewTime)
+	assert.Empty(t, lease.OwnerReferences)
+
+	_, err = client.CoreV1().Nodes().Create(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "uid-0"}})
+	require.NoError(t, err)
+	renewed := metav1.NewMicroTime(time.Now().Add(-time.Minute))
+	lease.Spec.RenewTime = &renewed
+	_, err = leases.Update(lease)
+	require.NoError(t, err)
+
+	require.NoError(t, s.renewLease(ctx))
+	lease, err = leases.Get("node", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, lease.Spec.RenewTime.After(renewed.Time), "the renew time must be updated")
+	require.Len(t, lease.OwnerReferences, 1)
+	assert.Equal(t, "Node", lease.OwnerReferences[0].Kind)
+	assert.Equal(t, "node", lease.OwnerReferences[0].Name)
+	assert.Equal(t, "uid-0", string(lease.OwnerReferences[0].UID))
+}
+
+// TestLeaseSyncLoop verifies that the lease of the node is created as soon as the loop starts.
+func TestLeaseSyncLoop(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "uid-0"}})
+	s := New(Config{NodeName: "node", Client: client, Provider: &registeringProvider{}, NodeLeaseDurationSeconds: 40})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.leaseSyncLoop(ctx)
+		close(done)
+	}()
+
+	leases := client.CoordinationV1beta1().Leases(corev1.NamespaceNodeLease)
+	var err error
+	for i := 0; i < 100; i++ {
+		if _, err = leases.Get("node", metav1.GetOptions{}); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.NoError(t, err)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the loop must stop once the context is cancelled")
+	}
+}
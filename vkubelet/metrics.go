@@ -0,0 +1,192 @@
+package vkubelet
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/virtual-kubelet/virtual-kubelet/log"
+	"github.com/virtual-kubelet/virtual-kubelet/providers/middleware"
+)
+
+const unitSeconds = "s"
+
+var (
+	// nodeKey is the name of the node a measurement is about, telling apart the nodes served by the same process.
+	nodeKey = middleware.NodeKey
+	// queueKey is the name of the work queue a measurement is about.
+	queueKey, _ = tag.NewKey("queue")
+	// operationKey is the name of the periodic synchronization a measurement is about.
+	operationKey, _ = tag.NewKey("operation")
+	// resourceKey is the kind of Kubernetes resource a measurement is about.
+	resourceKey, _ = tag.NewKey("resource")
+
+	workqueueDepth                   = stats.Int64("virtual-kubelet/workqueue_depth", "Current number of items waiting in the work queue", stats.UnitDimensionless)
+	workqueueAdds                    = stats.Int64("virtual-kubelet/workqueue_adds", "Number of items added to the work queue", stats.UnitDimensionless)
+	workqueueQueueDuration           = stats.Float64("virtual-kubelet/workqueue_queue_duration_seconds", "How long items stay in the work queue before being processed", unitSeconds)
+	workqueueWorkDuration            = stats.Float64("virtual-kubelet/workqueue_work_duration_seconds", "How long processing an item from the work queue takes", unitSeconds)
+	workqueueUnfinishedWork          = stats.Float64("virtual-kubelet/workqueue_unfinished_work_seconds", "Time spent processing the items of the work queue which are still being processed", unitSeconds)
+	workqueueLongestRunningProcessor = stats.Float64("virtual-kubelet/workqueue_longest_running_processor_seconds", "How long the longest running worker of the work queue has been processing its item", unitSeconds)
+	workqueueRetries                 = stats.Int64("virtual-kubelet/workqueue_retries", "Number of items requeued after failing to be processed", stats.UnitDimensionless)
+
+	// syncDuration measures the duration of the periodic synchronizations of the node and pod statuses.
+	syncDuration = stats.Float64("virtual-kubelet/sync_duration_seconds", "Duration of the periodic synchronizations with the provider", unitSeconds)
+	// apiUpdateConflicts counts the updates rejected by the Kubernetes API server because the resource was modified concurrently.
+	apiUpdateConflicts = stats.Int64("virtual-kubelet/api_update_conflicts", "Number of updates rejected by the Kubernetes API server because of a conflict", stats.UnitDimensionless)
+
+	// durationBuckets are the buckets of the distributions of durations, in seconds.
+	durationBuckets = view.Distribution(0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60)
+
+	// MetricsViews are the views of the metrics about the internals of the virtual-kubelet, such as its work queues and
+	// the calls to the provider, to be registered for them to be exported.
+	MetricsViews = append([]*view.View{
+		newView(workqueueDepth, view.LastValue(), nodeKey, queueKey),
+		newView(workqueueAdds, view.Count(), nodeKey, queueKey),
+		newView(workqueueQueueDuration, durationBuckets, nodeKey, queueKey),
+		newView(workqueueWorkDuration, durationBuckets, nodeKey, queueKey),
+		newView(workqueueUnfinishedWork, view.LastValue(), nodeKey, queueKey),
+		newView(workqueueLongestRunningProcessor, view.LastValue(), nodeKey, queueKey),
+		newView(workqueueRetries, view.Count(), nodeKey, queueKey),
+		newView(syncDuration, durationBuckets, nodeKey, operationKey),
+		newView(apiUpdateConflicts, view.Count(), nodeKey, resourceKey),
+	}, middleware.MetricsViews...)
+)
+
+// newView returns a view of the specified measure, named after it.
+func newView(m stats.Measure, aggregation *view.Aggregation, keys ...tag.Key) *view.View {
+	return &view.View{
+		Name:        m.Name(),
+		Description: m.Description(),
+		Measure:     m,
+		Aggregation: aggregation,
+		TagKeys:     keys,
+	}
+}
+
+// withTag returns a context holding the specified tag, which measurements recorded with it are attributed to.
+// Invalid tag values are logged, and the context is returned without the tag.
+func withTag(ctx context.Context, k tag.Key, v string) context.Context {
+	tagged, err := tag.New(ctx, tag.Upsert(k, v))
+	if err != nil {
+		log.G(ctx).WithError(err).WithField("key", k.Name()).Error("Error tagging metrics, recording them without the tag")
+		return ctx
+	}
+	return tagged
+}
+
+// recordSyncDuration records the duration of the specified periodic synchronization of the node, started at the
+// specified time.
+func (s *Server) recordSyncDuration(ctx context.Context, operation string, start time.Time) {
+	ctx = withTag(withTag(ctx, nodeKey, s.nodeName), operationKey, operation)
+	stats.Record(ctx, syncDuration.M(time.Since(start).Seconds()))
+}
+
+// recordUpdateConflict records the specified error returned when updating a Kubernetes resource of the node if it is
+// a conflict.
+func (s *Server) recordUpdateConflict(ctx context.Context, resource string, err error) {
+	if errors.IsConflict(err) {
+		ctx = withTag(withTag(ctx, nodeKey, s.nodeName), resourceKey, resource)
+		stats.Record(ctx, apiUpdateConflicts.M(1))
+	}
+}
+
+// queueName returns the name of the specified work queue of the specified node, from which the metrics of the queue
+// are tagged with the name of the node.
+func queueName(nodeName, queue string) string {
+	return nodeName + "/" + queue
+}
+
+var registerWorkqueueMetrics sync.Once
+
+// setWorkqueueMetricsProvider makes the work queues created from now on record their metrics with OpenCensus.
+func setWorkqueueMetricsProvider() {
+	registerWorkqueueMetrics.Do(func() {
+		workqueue.SetProvider(workqueueMetricsProvider{})
+	})
+}
+
+// workqueueMetricsProvider records the metrics of the work queues with OpenCensus.
+type workqueueMetricsProvider struct{}
+
+// workqueueMetric records the measurements of a metric of a work queue, converting them with the specified scale.
+type workqueueMetric struct {
+	ctx     context.Context
+	measure stats.Measure
+	scale   float64
+	// value is the current value of gauges.
+	value int64
+}
+
+func newWorkqueueMetric(name string, m stats.Measure, scale float64) *workqueueMetric {
+	ctx := context.Background()
+	// Node names can't contain slashes, unlike the names of queues.
+	if i := strings.Index(name, "/"); i >= 0 {
+		ctx = withTag(ctx, nodeKey, name[:i])
+		name = name[i+1:]
+	}
+	return &workqueueMetric{ctx: withTag(ctx, queueKey, name), measure: m, scale: scale}
+}
+
+func (m *workqueueMetric) record(v float64) {
+	switch measure := m.measure.(type) {
+	case *stats.Int64Measure:
+		stats.Record(m.ctx, measure.M(int64(v)))
+	case *stats.Float64Measure:
+		stats.Record(m.ctx, measure.M(v*m.scale))
+	}
+}
+
+func (m *workqueueMetric) Inc() {
+	m.record(float64(atomic.AddInt64(&m.value, 1)))
+}
+
+func (m *workqueueMetric) Dec() {
+	m.record(float64(atomic.AddInt64(&m.value, -1)))
+}
+
+func (m *workqueueMetric) Set(v float64) {
+	m.record(v)
+}
+
+func (m *workqueueMetric) Observe(v float64) {
+	m.record(v)
+}
+
+// Durations are reported by the work queues in microseconds.
+const microsecondsToSeconds = 1e-6
+
+func (workqueueMetricsProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return newWorkqueueMetric(name, workqueueDepth, 1)
+}
+
+func (workqueueMetricsProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return newWorkqueueMetric(name, workqueueAdds, 1)
+}
+
+func (workqueueMetricsProvider) NewLatencyMetric(name string) workqueue.SummaryMetric {
+	return newWorkqueueMetric(name, workqueueQueueDuration, microsecondsToSeconds)
+}
+
+func (workqueueMetricsProvider) NewWorkDurationMetric(name string) workqueue.SummaryMetric {
+	return newWorkqueueMetric(name, workqueueWorkDuration, microsecondsToSeconds)
+}
+
+func (workqueueMetricsProvider) NewUnfinishedWorkSecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return newWorkqueueMetric(name, workqueueUnfinishedWork, 1)
+}
+
+func (workqueueMetricsProvider) NewLongestRunningProcessorMicrosecondsMetric(name string) workqueue.SettableGaugeMetric {
+	return newWorkqueueMetric(name, workqueueLongestRunningProcessor, microsecondsToSeconds)
+}
+
+func (workqueueMetricsProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return newWorkqueueMetric(name, workqueueRetries, 1)
+}
@@ -0,0 +1,96 @@
+package vkubelet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// viewRows returns the rows of the specified view, indexed by the value of the specified tag.
+func viewRows(t *testing.T, name string, k tag.Key) map[string]view.AggregationData {
+	rows, err := view.RetrieveData(name)
+	require.NoError(t, err)
+	data := make(map[string]view.AggregationData)
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Key == k {
+				data[tag.Value] = row.Data
+			}
+		}
+	}
+	return data
+}
+
+// TestMetrics verifies that the metrics of the work queues are recorded.
+func TestMetrics(t *testing.T) {
+	require.NoError(t, view.Register(MetricsViews...))
+	defer view.Unregister(MetricsViews...)
+
+	setWorkqueueMetricsProvider()
+	q := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "test")
+	defer q.ShutDown()
+	q.Add("a")
+	q.Add("b")
+	item, _ := q.Get()
+	q.Done(item)
+
+	depth := viewRows(t, workqueueDepth.Name(), queueKey)["test"]
+	require.NotNil(t, depth)
+	assert.Equal(t, 1.0, depth.(*view.LastValueData).Value)
+	adds := viewRows(t, workqueueAdds.Name(), queueKey)["test"]
+	require.NotNil(t, adds)
+	assert.Equal(t, int64(2), adds.(*view.CountData).Value)
+
+	q.AddRateLimited("a")
+	retries := viewRows(t, workqueueRetries.Name(), queueKey)["test"]
+	require.NotNil(t, retries)
+	assert.Equal(t, int64(1), retries.(*view.CountData).Value)
+
+	// The queues of a node are tagged with its name.
+	nq := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), queueName("vk-0", "pods"))
+	defer nq.ShutDown()
+	nq.Add("a")
+	adds = viewRows(t, workqueueAdds.Name(), nodeKey)["vk-0"]
+	require.NotNil(t, adds)
+	assert.Equal(t, int64(1), adds.(*view.CountData).Value)
+	assert.NotNil(t, viewRows(t, workqueueAdds.Name(), queueKey)["pods"])
+}
+
+// TestWorkqueueMetricsNodes verifies that the work queues of different nodes are measured separately.
+func TestWorkqueueMetricsNodes(t *testing.T) {
+	require.NoError(t, view.Register(MetricsViews...))
+	defer view.Unregister(MetricsViews...)
+
+	setWorkqueueMetricsProvider()
+	for i, nodeName := range []string{"vk-0", "vk-1"} {
+		q := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), queueName(nodeName, "pods"))
+		defer q.ShutDown()
+		for j := 0; j <= i; j++ {
+			q.Add(j)
+		}
+	}
+
+	depths := viewRows(t, workqueueDepth.Name(), nodeKey)
+	require.Len(t, depths, 2)
+	assert.Equal(t, 1.0, depths["vk-0"].(*view.LastValueData).Value)
+	assert.Equal(t, 2.0, depths["vk-1"].(*view.LastValueData).Value)
+}
+
+// TestWithTagInvalid verifies that measurements with invalid tag values are recorded without the tag.
+func TestWithTagInvalid(t *testing.T) {
+	require.NoError(t, view.Register(MetricsViews...))
+	defer view.Unregister(MetricsViews...)
+
+	s := &Server{nodeName: "vk-\x00"}
+	s.recordSyncDuration(context.Background(), "lease", time.Now())
+	durations := viewRows(t, syncDuration.Name(), operationKey)["lease"]
+	require.NotNil(t, durations)
+	assert.Equal(t, int64(1), durations.(*view.DistributionData).Count)
+	assert.Nil(t, viewRows(t, syncDuration.Name(), nodeKey)["vk-\x00"])
+}
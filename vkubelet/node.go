@@ -2,15 +2,22 @@ package vkubelet
 
 import (
 	"context"
+	"encoding/json"
+	"reflect"
 	"strings"
+	"time"
 
 	"github.com/virtual-kubelet/virtual-kubelet/log"
 	"github.com/virtual-kubelet/virtual-kubelet/version"
 
+	"github.com/cpuguy83/strongerrors/status/ocstatus"
+	pkgerrors "github.com/pkg/errors"
 	"go.opencensus.io/trace"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
 )
 
 var (
@@ -19,27 +26,35 @@ var (
 	vkVersion = strings.Join([]string{"v1.13.1", "vk", version.Version}, "-")
 )
 
+const (
+	// nodeStatusReportFrequency is the maximum amount of time between two updates of the node status when node leases are enabled.
+	// Within this period, the node status is only updated when it changes.
+	// It is set to the same value used by the Kubelet.
+	nodeStatusReportFrequency = time.Minute
+)
+
 // registerNode registers this virtual node with the Kubernetes API.
 func (s *Server) registerNode(ctx context.Context) error {
 	ctx, span := trace.StartSpan(ctx, "registerNode")
 	defer span.End()
 
-	taints := make([]corev1.Taint, 0)
-
-	if s.taint != nil {
-		taints = append(taints, *s.taint)
+	s.configLock.Lock()
+	taints := append(make([]corev1.Taint, 0), s.taints...)
+	labels := make(map[string]string)
+	configLabels := make(map[string]string)
+	for k, v := range s.labels {
+		labels[k] = v
+		configLabels[k] = v
+	}
+	s.configLock.Unlock()
+	for k, v := range s.defaultNodeLabels() {
+		labels[k] = v
 	}
 
 	node := &corev1.Node{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: s.nodeName,
-			Labels: map[string]string{
-				"type":                   "virtual-kubelet",
-				"kubernetes.io/role":     "agent",
-				"beta.kubernetes.io/os":  strings.ToLower(s.provider.OperatingSystem()),
-				"kubernetes.io/hostname": s.nodeName,
-				"alpha.service-controller.kubernetes.io/exclude-balancer": "true",
-			},
+			Name:   s.nodeName,
+			Labels: labels,
 		},
 		Spec: corev1.NodeSpec{
 			Taints: taints,
@@ -57,11 +72,18 @@ func (s *Server) registerNode(ctx context.Context) error {
 			DaemonEndpoints: *s.provider.NodeDaemonEndpoints(ctx),
 		},
 	}
+	setManagedMetadata(node, taints, configLabels)
 	addNodeAttributes(span, node)
-	if _, err := s.k8sClient.CoreV1().Nodes().Create(node); err != nil && !errors.IsAlreadyExists(err) {
+	registered, err := s.k8sClient.CoreV1().Nodes().Create(node)
+	if errors.IsAlreadyExists(err) {
+		// The node was registered by a previous run, possibly with other taints and labels.
+		registered, err = s.reconcileNodeMetadata(ctx, taints, configLabels)
+	}
+	if err != nil {
 		span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
 		return err
 	}
+	s.setNodeUID(registered.UID)
 	span.Annotate(nil, "Registered node with k8s")
 
 	log.G(ctx).Info("Registered node")
@@ -69,11 +91,227 @@ func (s *Server) registerNode(ctx context.Context) error {
 	return nil
 }
 
+// reconcileNodeMetadata replaces the taints and labels set on the already registered node by a previous configuration,
+// as recorded in its managedMetadataAnnotation, with the specified ones, and restores its default labels.
+// It returns the reconciled node.
+func (s *Server) reconcileNodeMetadata(ctx context.Context, taints []corev1.Taint, labels map[string]string) (*corev1.Node, error) {
+	defaults := s.defaultNodeLabels()
+	var n *corev1.Node
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		var err error
+		n, err = s.k8sClient.CoreV1().Nodes().Get(s.nodeName, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		previous := managedMetadataOf(n)
+		changed := updateNodeMetadata(n, defaults, previous.Taints, taints, previous.Labels, labels)
+		if n.Labels == nil {
+			n.Labels = make(map[string]string)
+		}
+		for k, v := range defaults {
+			if n.Labels[k] != v {
+				n.Labels[k] = v
+				changed = true
+			}
+		}
+		if !setManagedMetadata(n, taints, labels) && !changed {
+			return nil
+		}
+		n, err = s.k8sClient.CoreV1().Nodes().Update(n)
+		s.recordUpdateConflict(ctx, "node", err)
+		return err
+	})
+	return n, pkgerrors.Wrap(err, "error reconciling taints and labels of existing node")
+}
+
+// setNodeUID records the UID of the registered node.
+func (s *Server) setNodeUID(uid types.UID) {
+	s.nodeUIDLock.Lock()
+	s.nodeUID = uid
+	s.nodeUIDLock.Unlock()
+}
+
+// getNodeUID returns the UID of the registered node, or an empty UID when it isn't registered yet.
+func (s *Server) getNodeUID() types.UID {
+	s.nodeUIDLock.Lock()
+	defer s.nodeUIDLock.Unlock()
+	return s.nodeUID
+}
+
+// managedMetadataAnnotation is the annotation recording the taints and labels set on the node by its configuration, so
+// that those removed from the configuration while the virtual-kubelet isn't running are removed from the node when it
+// starts again.
+const managedMetadataAnnotation = "virtual-kubelet.io/managed-metadata"
+
+// managedMetadata is the value of managedMetadataAnnotation.
+type managedMetadata struct {
+	Taints []corev1.Taint    `json:"taints,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// managedMetadataOf returns the taints and labels recorded in the managedMetadataAnnotation of the specified node.
+// Nothing is returned when the annotation is missing or invalid, in which case taints and labels removed from the
+// configuration are left on the node.
+func managedMetadataOf(n *corev1.Node) managedMetadata {
+	var md managedMetadata
+	if v, ok := n.Annotations[managedMetadataAnnotation]; ok {
+		if err := json.Unmarshal([]byte(v), &md); err != nil {
+			return managedMetadata{}
+		}
+	}
+	return md
+}
+
+// setManagedMetadata records the specified taints and labels in the managedMetadataAnnotation of the node, returning
+// whether it was changed.
+func setManagedMetadata(n *corev1.Node, taints []corev1.Taint, labels map[string]string) bool {
+	b, err := json.Marshal(managedMetadata{Taints: taints, Labels: labels})
+	if err != nil {
+		// Taints and labels can always be marshalled.
+		panic(err)
+	}
+	if n.Annotations[managedMetadataAnnotation] == string(b) {
+		return false
+	}
+	if n.Annotations == nil {
+		n.Annotations = make(map[string]string)
+	}
+	n.Annotations[managedMetadataAnnotation] = string(b)
+	return true
+}
+
+// defaultNodeLabels returns the labels which are always set on the node.
+func (s *Server) defaultNodeLabels() map[string]string {
+	return map[string]string{
+		"type":                   "virtual-kubelet",
+		"kubernetes.io/role":     "agent",
+		"beta.kubernetes.io/os":  strings.ToLower(s.provider.OperatingSystem()),
+		"kubernetes.io/hostname": s.nodeName,
+		"alpha.service-controller.kubernetes.io/exclude-balancer": "true",
+	}
+}
+
+// UpdateNodeMetadata replaces the taints and labels set on the node by its configuration.
+// The node is updated right away when the server is running, and is otherwise registered with them.
+// Taints and labels which were set on the node by other means, as well as the default labels, are preserved.
+func (s *Server) UpdateNodeMetadata(ctx context.Context, taints []corev1.Taint, labels map[string]string) error {
+	ctx, span := trace.StartSpan(ctx, "updateNodeMetadata")
+	defer span.End()
+
+	s.configLock.Lock()
+	defer s.configLock.Unlock()
+
+	if s.podController != nil {
+		defaults := s.defaultNodeLabels()
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			n, err := s.k8sClient.CoreV1().Nodes().Get(s.nodeName, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			changed := updateNodeMetadata(n, defaults, s.taints, taints, s.labels, labels)
+			if !setManagedMetadata(n, taints, labels) && !changed {
+				return nil
+			}
+			_, err = s.k8sClient.CoreV1().Nodes().Update(n)
+			s.recordUpdateConflict(ctx, "node", err)
+			return err
+		})
+		if err != nil {
+			span.SetStatus(ocstatus.FromError(err))
+			return pkgerrors.Wrap(err, "error updating node taints and labels")
+		}
+		log.G(ctx).Info("Updated node taints and labels")
+	}
+
+	s.taints = taints
+	s.labels = labels
+	return nil
+}
+
+// updateNodeMetadata replaces the old taints and labels of the specified node by the new ones, leaving the default labels
+// untouched. It returns whether the node was changed.
+func updateNodeMetadata(n *corev1.Node, defaults map[string]string, oldTaints, newTaints []corev1.Taint, oldLabels, newLabels map[string]string) bool {
+	// Taints are identified by their key and effect.
+	managed := func(t corev1.Taint) bool {
+		for _, taints := range [][]corev1.Taint{oldTaints, newTaints} {
+			for _, m := range taints {
+				if t.MatchTaint(&m) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	var taints []corev1.Taint
+	for _, t := range n.Spec.Taints {
+		if !managed(t) {
+			taints = append(taints, t)
+		}
+	}
+	taints = append(taints, newTaints...)
+
+	labels := make(map[string]string)
+	for k, v := range n.Labels {
+		_, isOld := oldLabels[k]
+		_, isDefault := defaults[k]
+		if !isOld || isDefault {
+			labels[k] = v
+		}
+	}
+	for k, v := range newLabels {
+		if _, isDefault := defaults[k]; !isDefault {
+			labels[k] = v
+		}
+	}
+
+	if sameTaints(taints, n.Spec.Taints) && len(labels) == len(n.Labels) && (len(labels) == 0 || reflect.DeepEqual(labels, n.Labels)) {
+		return false
+	}
+	n.Spec.Taints = taints
+	n.Labels = labels
+	return true
+}
+
+// sameTaints returns whether the specified lists hold the same taints, regardless of their order.
+func sameTaints(a, b []corev1.Taint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, t := range a {
+		found := false
+		for _, u := range b {
+			if reflect.DeepEqual(t, u) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
 // updateNode updates the node status within Kubernetes with updated NodeConditions.
+//
+// When node leases are enabled, the lease acts as the node's heartbeat.
+// Hence, the node status is only updated when it has changed, or when it hasn't been reported for nodeStatusReportFrequency.
 func (s *Server) updateNode(ctx context.Context) {
 	ctx, span := trace.StartSpan(ctx, "updateNode")
 	defer span.End()
 
+	conditions := s.provider.NodeConditions(ctx)
+	capacity := s.provider.Capacity(ctx)
+	addresses := s.provider.NodeAddresses(ctx)
+
+	if s.nodeLeaseDurationSeconds > 0 &&
+		s.lastNodeStatus != nil &&
+		time.Since(s.lastNodeStatusReport) < nodeStatusReportFrequency &&
+		nodeStatusEqual(s.lastNodeStatus, conditions, capacity, addresses) {
+		span.Annotate(nil, "Node status unchanged, skipping update")
+		return
+	}
+
 	opts := metav1.GetOptions{}
 	n, err := s.k8sClient.CoreV1().Nodes().Get(s.nodeName, opts)
 	if err != nil && !errors.IsNotFound(err) {
@@ -83,6 +321,10 @@ func (s *Server) updateNode(ctx context.Context) {
 	}
 	addNodeAttributes(span, n)
 	span.Annotate(nil, "Fetched node details from k8s")
+	if n != nil {
+		// The node may have been deleted and registered again by someone else.
+		s.setNodeUID(n.UID)
+	}
 
 	if errors.IsNotFound(err) {
 		if err = s.registerNode(ctx); err != nil {
@@ -95,20 +337,48 @@ func (s *Server) updateNode(ctx context.Context) {
 	}
 
 	n.ResourceVersion = "" // Blank out resource version to prevent object has been modified error
-	n.Status.Conditions = s.provider.NodeConditions(ctx)
+	n.Status.Conditions = conditions
 
-	capacity := s.provider.Capacity(ctx)
 	n.Status.Capacity = capacity
 	n.Status.Allocatable = capacity
 
-	n.Status.Addresses = s.provider.NodeAddresses(ctx)
+	n.Status.Addresses = addresses
 
 	n, err = s.k8sClient.CoreV1().Nodes().UpdateStatus(n)
 	if err != nil {
+		s.recordUpdateConflict(ctx, "node", err)
 		log.G(ctx).WithError(err).Error("Failed to update node")
 		span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
 		return
 	}
+
+	s.lastNodeStatus = n.Status.DeepCopy()
+	s.lastNodeStatusReport = time.Now()
+}
+
+// nodeStatusEqual checks whether the specified conditions, capacity and addresses match the ones in the specified node status.
+// Heartbeat and transition times of the conditions are ignored, as they are expected to change on every call to the provider.
+func nodeStatusEqual(status *corev1.NodeStatus, conditions []corev1.NodeCondition, capacity corev1.ResourceList, addresses []corev1.NodeAddress) bool {
+	if len(status.Conditions) != len(conditions) || len(status.Capacity) != len(capacity) || len(status.Addresses) != len(addresses) {
+		return false
+	}
+	for i, c := range conditions {
+		o := status.Conditions[i]
+		if o.Type != c.Type || o.Status != c.Status || o.Reason != c.Reason || o.Message != c.Message {
+			return false
+		}
+	}
+	for name, q := range capacity {
+		if o, ok := status.Capacity[name]; !ok || o.Cmp(q) != 0 {
+			return false
+		}
+	}
+	for i, a := range addresses {
+		if status.Addresses[i] != a {
+			return false
+		}
+	}
+	return true
 }
 
 type taintsStringer []corev1.Taint
@@ -0,0 +1,134 @@
+package vkubelet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestNodeStatusEqual verifies that changes to the node's conditions, capacity or addresses are detected, while heartbeat times are ignored.
+func TestNodeStatusEqual(t *testing.T) {
+	conditions := func(status corev1.ConditionStatus) []corev1.NodeCondition {
+		return []corev1.NodeCondition{
+			{
+				Type:              corev1.NodeReady,
+				Status:            status,
+				LastHeartbeatTime: metav1.NewTime(time.Now()),
+			},
+		}
+	}
+	capacity := func(pods string) corev1.ResourceList {
+		return corev1.ResourceList{
+			corev1.ResourcePods: resource.MustParse(pods),
+		}
+	}
+	addresses := []corev1.NodeAddress{
+		{
+			Type:    corev1.NodeInternalIP,
+			Address: "10.0.0.1",
+		},
+	}
+
+	status := &corev1.NodeStatus{
+		Conditions: conditions(corev1.ConditionTrue),
+		Capacity:   capacity("20"),
+		Addresses:  addresses,
+	}
+
+	assert.True(t, nodeStatusEqual(status, conditions(corev1.ConditionTrue), capacity("20"), addresses))
+	assert.False(t, nodeStatusEqual(status, conditions(corev1.ConditionFalse), capacity("20"), addresses))
+	assert.False(t, nodeStatusEqual(status, conditions(corev1.ConditionTrue), capacity("10"), addresses))
+	assert.False(t, nodeStatusEqual(status, conditions(corev1.ConditionTrue), capacity("20"), nil))
+}
+
+// TestUpdateNodeMetadata verifies that the configured taints and labels of the node are replaced, while the ones set by
+// other means and the default labels are preserved.
+func TestUpdateNodeMetadata(t *testing.T) {
+	defaults := map[string]string{"type": "virtual-kubelet"}
+	providerTaint := corev1.Taint{Key: "virtual-kubelet.io/provider", Value: "mock", Effect: corev1.TaintEffectNoSchedule}
+	otherTaint := corev1.Taint{Key: "node.kubernetes.io/unreachable", Effect: corev1.TaintEffectNoExecute}
+	oldLabels := map[string]string{"zone": "a", "tier": "batch"}
+	n := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{"type": "virtual-kubelet", "zone": "a", "tier": "batch", "other": "x"},
+		},
+		Spec: corev1.NodeSpec{
+			Taints: []corev1.Taint{providerTaint, otherTaint},
+		},
+	}
+
+	assert.False(t, updateNodeMetadata(n.DeepCopy(), defaults, []corev1.Taint{providerTaint}, []corev1.Taint{providerTaint}, oldLabels, oldLabels))
+
+	newTaint := providerTaint
+	newTaint.Value = "other"
+	newLabels := map[string]string{"zone": "b", "type": "ignored"}
+	assert.True(t, updateNodeMetadata(n, defaults, []corev1.Taint{providerTaint}, []corev1.Taint{newTaint}, oldLabels, newLabels))
+	assert.Equal(t, []corev1.Taint{otherTaint, newTaint}, n.Spec.Taints)
+	assert.Equal(t, map[string]string{"type": "virtual-kubelet", "zone": "b", "other": "x"}, n.Labels)
+}
+
+// TestManagedMetadata verifies that the taints and labels recorded on the node by a previous run are found again, so
+// that they can be replaced when the node is registered again with another configuration.
+func TestManagedMetadata(t *testing.T) {
+	oldTaint := corev1.Taint{Key: "example.com/dedicated", Value: "batch", Effect: corev1.TaintEffectNoSchedule}
+	n := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{"type": "virtual-kubelet", "tier": "batch"},
+		},
+		Spec: corev1.NodeSpec{
+			Taints: []corev1.Taint{oldTaint},
+		},
+	}
+	assert.Equal(t, managedMetadata{}, managedMetadataOf(n))
+	assert.True(t, setManagedMetadata(n, []corev1.Taint{oldTaint}, map[string]string{"tier": "batch"}))
+	assert.False(t, setManagedMetadata(n, []corev1.Taint{oldTaint}, map[string]string{"tier": "batch"}))
+
+	previous := managedMetadataOf(n)
+	assert.Equal(t, []corev1.Taint{oldTaint}, previous.Taints)
+	assert.Equal(t, map[string]string{"tier": "batch"}, previous.Labels)
+	assert.True(t, updateNodeMetadata(n, map[string]string{"type": "virtual-kubelet"}, previous.Taints, nil, previous.Labels, nil))
+	assert.Empty(t, n.Spec.Taints)
+	assert.Equal(t, map[string]string{"type": "virtual-kubelet"}, n.Labels)
+
+	n.Annotations[managedMetadataAnnotation] = "invalid"
+	assert.Equal(t, managedMetadata{}, managedMetadataOf(n))
+}
+
+// registeringProvider is a provider which implements all the methods describing the node, for it to be registered.
+type registeringProvider struct {
+	capacityProvider
+}
+
+func (p *registeringProvider) OperatingSystem() string {
+	return "Linux"
+}
+
+func (p *registeringProvider) NodeConditions(context.Context) []corev1.NodeCondition {
+	return nil
+}
+
+func (p *registeringProvider) NodeDaemonEndpoints(context.Context) *corev1.NodeDaemonEndpoints {
+	return &corev1.NodeDaemonEndpoints{}
+}
+
+// TestRegisterNodeUID verifies that the events about the node refer to the UID of the node once it is registered,
+// including when it was registered by a previous run.
+func TestRegisterNodeUID(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node", UID: "uid-0"}})
+	s := New(Config{NodeName: "node", Client: client, Provider: &registeringProvider{}})
+	pc := &PodController{server: s}
+	assert.Empty(t, pc.nodeReference().UID)
+
+	require.NoError(t, s.registerNode(context.Background()))
+	ref := pc.nodeReference()
+	assert.Equal(t, "Node", ref.Kind)
+	assert.Equal(t, "node", ref.Name)
+	assert.Equal(t, "uid-0", string(ref.UID))
+}
@@ -0,0 +1,171 @@
+package vkubelet
+
+import (
+	"context"
+
+	"github.com/cpuguy83/strongerrors/status/ocstatus"
+	pkgerrors "github.com/pkg/errors"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"go.opencensus.io/trace"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/virtual-kubelet/virtual-kubelet/log"
+)
+
+const (
+	// ReasonOrphanedPodFound is the reason used in events emitted when a pod exists in the provider but not in Kubernetes.
+	ReasonOrphanedPodFound = "OrphanedPodFound"
+	// ReasonOrphanedPodDeleted is the reason used in events emitted when an orphaned pod is deleted from the provider.
+	ReasonOrphanedPodDeleted = "OrphanedPodDeleted"
+)
+
+var (
+	// orphanedPodsFound counts the pods found in the provider which don't exist in Kubernetes.
+	orphanedPodsFound = stats.Int64("virtual-kubelet/orphaned_pods_found", "Number of pods found in the provider which don't exist in Kubernetes", stats.UnitDimensionless)
+	// orphanedPodsDeleted counts the orphaned pods deleted from the provider.
+	orphanedPodsDeleted = stats.Int64("virtual-kubelet/orphaned_pods_deleted", "Number of orphaned pods deleted from the provider", stats.UnitDimensionless)
+
+	// OrphanedPodsViews are the views of the orphaned pods metrics, to be registered for them to be exported.
+	OrphanedPodsViews = []*view.View{
+		{
+			Name:        orphanedPodsFound.Name(),
+			Description: orphanedPodsFound.Description(),
+			Measure:     orphanedPodsFound,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{nodeKey},
+		},
+		{
+			Name:        orphanedPodsDeleted.Name(),
+			Description: orphanedPodsDeleted.Description(),
+			Measure:     orphanedPodsDeleted,
+			Aggregation: view.Count(),
+			TagKeys:     []tag.Key{nodeKey},
+		},
+	}
+)
+
+// reconcileOrphanedPods checks whether the provider knows about any pods which Kubernetes doesn't know about, and queues
+// them for deletion, unless in dry-run mode.
+// Orphaned pods are only counted and reported the first time they are found, until they go away.
+func (pc *PodController) reconcileOrphanedPods(ctx context.Context) {
+	ctx, span := trace.StartSpan(ctx, "reconcileOrphanedPods")
+	defer span.End()
+
+	// Grab the list of pods known to the provider.
+	pps, err := pc.server.provider.GetPods(ctx)
+	if err != nil {
+		err := pkgerrors.Wrap(err, "failed to fetch the list of pods from the provider")
+		span.SetStatus(ocstatus.FromError(err))
+		log.G(ctx).Error(err)
+		return
+	}
+
+	var found int64
+	orphans := make(map[types.UID]struct{})
+	for _, pp := range pps {
+		if _, err := pc.podsLister.Pods(pp.Namespace).Get(pp.Name); err != nil {
+			if !errors.IsNotFound(err) {
+				// For some reason we couldn't fetch the pod from the lister, so we skip it until the next reconciliation.
+				log.G(ctx).WithError(err).Errorf("failed to fetch pod %q from the lister", loggablePodName(pp))
+				if _, ok := pc.reportedOrphans[pp.UID]; ok {
+					orphans[pp.UID] = struct{}{}
+				}
+				continue
+			}
+
+			// The current pod does not exist in Kubernetes, so it is orphaned.
+			orphans[pp.UID] = struct{}{}
+			_, reported := pc.reportedOrphans[pp.UID]
+			if !reported {
+				found++
+				stats.Record(withTag(ctx, nodeKey, pc.server.nodeName), orphanedPodsFound.M(1))
+				pc.recorder.Eventf(pc.nodeReference(), corev1.EventTypeWarning, ReasonOrphanedPodFound, "Pod %q exists in the provider but not in Kubernetes", loggablePodName(pp))
+			}
+			if pc.server.orphanedPodsDryRun {
+				if !reported {
+					log.G(ctx).Warnf("found orphaned pod %q in provider, not deleting it in dry-run mode", loggablePodName(pp))
+				}
+				continue
+			}
+			if !reported {
+				log.G(ctx).Warnf("found orphaned pod %q in provider, deleting it", loggablePodName(pp))
+			}
+
+			key, err := cache.MetaNamespaceKeyFunc(pp)
+			if err != nil {
+				log.G(ctx).Error(err)
+				continue
+			}
+			// Pods whose deletion already failed are retried by the work queue after their backoff.
+			if pc.orphanedPodsQueue.NumRequeues(key) == 0 {
+				pc.orphanedPodsQueue.Add(key)
+			}
+		}
+	}
+	// The orphaned pods which weren't found again are forgotten, and reported again if they ever come back.
+	pc.reportedOrphans = orphans
+	span.AddAttributes(trace.Int64Attribute("nOrphanedPods", found))
+}
+
+// runOrphanedPodWorker is a long-running function that will continually call the processNextOrphanedPod function in order to read and process an item on the orphaned pods work queue.
+func (pc *PodController) runOrphanedPodWorker(ctx context.Context, workerId string, stop <-chan struct{}) {
+	for !isStopped(stop) && pc.processNextOrphanedPod(ctx, workerId) {
+	}
+}
+
+// processNextOrphanedPod will read a single work item off the orphaned pods work queue and attempt to process it, by calling the orphanedPodHandler.
+func (pc *PodController) processNextOrphanedPod(ctx context.Context, workerId string) bool {
+	return handleQueueItem(ctx, pc.orphanedPodsQueue, "processNextOrphanedPod", workerId, pc.orphanedPodHandler)
+}
+
+// orphanedPodHandler deletes the pod identified by the specified key from the provider, unless it has since been created in Kubernetes.
+func (pc *PodController) orphanedPodHandler(ctx context.Context, key string) error {
+	ctx, span := trace.StartSpan(ctx, "orphanedPodHandler")
+	defer span.End()
+
+	// Add the current key as an attribute to the current span.
+	span.AddAttributes(trace.StringAttribute("key", key))
+
+	// Convert the namespace/name string into a distinct namespace and name.
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		// Log the error as a warning, but do not requeue the key as it is invalid.
+		log.G(ctx).Warn(pkgerrors.Wrapf(err, "invalid resource key: %q", key))
+		return nil
+	}
+
+	// The pod may have been created in Kubernetes since it was found, in which case it is no longer orphaned.
+	if _, err := pc.podsLister.Pods(namespace).Get(name); err == nil {
+		return nil
+	} else if !errors.IsNotFound(err) {
+		err := pkgerrors.Wrapf(err, "failed to fetch pod with key %q from lister", key)
+		span.SetStatus(ocstatus.FromError(err))
+		return err
+	}
+
+	if err := pc.server.deletePod(ctx, namespace, name); err != nil {
+		err := pkgerrors.Wrapf(err, "failed to delete orphaned pod %q in the provider", key)
+		span.SetStatus(ocstatus.FromError(err))
+		return err
+	}
+
+	stats.Record(withTag(ctx, nodeKey, pc.server.nodeName), orphanedPodsDeleted.M(1))
+	pc.recorder.Eventf(pc.nodeReference(), corev1.EventTypeNormal, ReasonOrphanedPodDeleted, "Deleted orphaned pod %q from the provider", key)
+	log.G(ctx).Infof("deleted orphaned pod %q in provider", key)
+	return nil
+}
+
+// nodeReference returns a reference to the node, which events about pods which don't exist in Kubernetes are attached to.
+// Its UID is left empty when the node isn't registered yet.
+func (pc *PodController) nodeReference() *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		Kind: "Node",
+		Name: pc.server.nodeName,
+		UID:  pc.server.getNodeUID(),
+	}
+}
@@ -0,0 +1,74 @@
+package vkubelet
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+)
+
+// podsProvider is a provider which only implements listing the pods it runs.
+type podsProvider struct {
+	providers.Provider
+	pods []*corev1.Pod
+}
+
+func (p *podsProvider) GetPods(context.Context) ([]*corev1.Pod, error) {
+	return p.pods, nil
+}
+
+// TestReconcileOrphanedPods verifies that only the pods which don't exist in Kubernetes are reported, once until they
+// go away, and queued for deletion unless in dry-run mode.
+func TestReconcileOrphanedPods(t *testing.T) {
+	known := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "known", UID: "known"}}
+	orphaned := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "orphaned", UID: "orphaned"}}
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	assert.NoError(t, indexer.Add(known))
+
+	for _, dryRun := range []bool{true, false} {
+		recorder := record.NewFakeRecorder(10)
+		provider := &podsProvider{pods: []*corev1.Pod{known, orphaned}}
+		pc := &PodController{
+			server: New(Config{
+				NodeName:           "node",
+				Provider:           provider,
+				OrphanedPodsDryRun: dryRun,
+			}),
+			podsLister:        corev1listers.NewPodLister(indexer),
+			recorder:          recorder,
+			orphanedPodsQueue: workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		}
+
+		pc.reconcileOrphanedPods(context.Background())
+		pc.reconcileOrphanedPods(context.Background())
+		assert.Len(t, recorder.Events, 1)
+		assert.Contains(t, <-recorder.Events, ReasonOrphanedPodFound)
+
+		if dryRun {
+			assert.Equal(t, 0, pc.orphanedPodsQueue.Len())
+		} else {
+			assert.Equal(t, 1, pc.orphanedPodsQueue.Len())
+			key, _ := pc.orphanedPodsQueue.Get()
+			assert.Equal(t, "default/orphaned", key)
+			pc.orphanedPodsQueue.Done(key)
+		}
+
+		// Orphaned pods which went away are forgotten, and reported again when they come back.
+		provider.pods = []*corev1.Pod{known}
+		pc.reconcileOrphanedPods(context.Background())
+		assert.Len(t, recorder.Events, 0)
+		provider.pods = []*corev1.Pod{known, orphaned}
+		pc.reconcileOrphanedPods(context.Background())
+		assert.Len(t, recorder.Events, 1)
+		pc.orphanedPodsQueue.ShutDown()
+	}
+}
@@ -3,9 +3,11 @@ package vkubelet
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"sync"
 	"time"
 
+	"github.com/cpuguy83/strongerrors"
 	"github.com/cpuguy83/strongerrors/status/ocstatus"
 	pkgerrors "github.com/pkg/errors"
 	"go.opencensus.io/trace"
@@ -17,6 +19,11 @@ import (
 	"github.com/virtual-kubelet/virtual-kubelet/log"
 )
 
+const (
+	// ReasonProviderUpdateFailed is the reason used in events emitted when the provider fails to update a pod.
+	ReasonProviderUpdateFailed = "ProviderUpdateFailed"
+)
+
 func addPodAttributes(span *trace.Span, pod *corev1.Pod) {
 	span.AddAttributes(
 		trace.StringAttribute("uid", string(pod.GetUID())),
@@ -28,27 +35,36 @@ func addPodAttributes(span *trace.Span, pod *corev1.Pod) {
 }
 
 func (s *Server) createOrUpdatePod(ctx context.Context, pod *corev1.Pod, recorder record.EventRecorder) error {
+	ctx, span := trace.StartSpan(ctx, "createOrUpdatePod")
+	defer span.End()
+	addPodAttributes(span, pod)
+
 	// Check if the pod is already known by the provider.
 	// NOTE: Some providers return a non-nil error in their GetPod implementation when the pod is not found while some other don't.
 	// Hence, we ignore the error and just act upon the pod if it is non-nil (meaning that the provider still knows about the pod).
-	if pp, _ := s.provider.GetPod(ctx, pod.Namespace, pod.Name); pp != nil {
-		// The pod has already been created in the provider.
-		// Hence, we return since pod updates are not yet supported.
-		log.G(ctx).Warnf("skipping update of pod %s as pod updates are not supported", pp.Name)
-		return nil
-	}
+	pp, _ := s.provider.GetPod(ctx, pod.Namespace, pod.Name)
 
-	ctx, span := trace.StartSpan(ctx, "createOrUpdatePod")
-	defer span.End()
-	addPodAttributes(span, pod)
+	// Pods which have not been created yet must be admitted first, so that the node isn't over-committed.
+	if pp == nil {
+		if result := s.admitPod(ctx, pod); !result.Admit {
+			span.SetStatus(trace.Status{Code: trace.StatusCodeResourceExhausted, Message: result.Message})
+			return s.rejectPod(ctx, pod, result, recorder)
+		}
+	}
 
-	if err := populateEnvironmentVariables(ctx, pod, s.resourceManager, recorder); err != nil {
+	if err := populateEnvironmentVariables(ctx, pod, s.resourceManager, s.provider, recorder); err != nil {
 		span.SetStatus(trace.Status{Code: trace.StatusCodeInvalidArgument, Message: err.Error()})
 		return err
 	}
 
 	logger := log.G(ctx).WithField("pod", pod.GetName()).WithField("namespace", pod.GetNamespace())
 
+	if pp != nil {
+		// The pod has already been created in the provider.
+		// Hence, we propagate any changes to its mutable fields.
+		return s.updatePod(ctx, pp, pod, recorder)
+	}
+
 	if origErr := s.provider.CreatePod(ctx, pod); origErr != nil {
 		podPhase := corev1.PodPending
 		if pod.Spec.RestartPolicy == corev1.RestartPolicyNever {
@@ -62,6 +78,7 @@ func (s *Server) createOrUpdatePod(ctx context.Context, pod *corev1.Pod, recorde
 
 		_, err := s.k8sClient.CoreV1().Pods(pod.Namespace).UpdateStatus(pod)
 		if err != nil {
+			s.recordUpdateConflict(ctx, "pod", err)
 			logger.WithError(err).Warn("Failed to update pod status")
 		} else {
 			span.Annotate(nil, "Updated k8s pod status")
@@ -71,12 +88,127 @@ func (s *Server) createOrUpdatePod(ctx context.Context, pod *corev1.Pod, recorde
 		return origErr
 	}
 	span.Annotate(nil, "Created pod in provider")
+	s.setPushedPod(pod)
 
 	logger.Info("Pod created")
 
 	return nil
 }
 
+// updatePod propagates changes to the mutable fields of the specified pod to the provider.
+// "pp" is the pod as known by the provider, and "pod" is the pod as known by Kubernetes.
+//
+// The pod is compared with the pod as last pushed to the provider, since providers may not return all of its mutable
+// fields from GetPod, such as its labels. It is only compared with the pod as known by the provider when it was
+// created in the provider by a previous run.
+func (s *Server) updatePod(ctx context.Context, pp, pod *corev1.Pod, recorder record.EventRecorder) error {
+	ctx, span := trace.StartSpan(ctx, "updatePod")
+	defer span.End()
+	addPodAttributes(span, pod)
+
+	logger := log.G(ctx).WithField("pod", pod.GetName()).WithField("namespace", pod.GetNamespace())
+
+	last := s.pushedPod(pod.Namespace, pod.Name)
+	if last == nil {
+		last = pp
+	}
+	if podsEffectivelyEqual(last, pod) {
+		s.setPushedPod(pod)
+		log.Trace(logger, "Pod is up to date in the provider, skipping update")
+		return nil
+	}
+
+	if err := s.provider.UpdatePod(ctx, pod); err != nil {
+		recorder.Eventf(pod, corev1.EventTypeWarning, ReasonProviderUpdateFailed, "failed to update pod in the provider: %v", err)
+		span.SetStatus(ocstatus.FromError(err))
+		if strongerrors.IsNotImplemented(err) {
+			// There is no point in retrying as the provider will keep rejecting the update.
+			s.setPushedPod(pod)
+			logger.WithError(err).Warn("Pod updates are not supported by the provider")
+			return nil
+		}
+		return pkgerrors.Wrap(err, "error updating pod in the provider")
+	}
+	span.Annotate(nil, "Updated pod in provider")
+	s.setPushedPod(pod)
+
+	logger.Info("Pod updated")
+
+	return nil
+}
+
+// pushedPod returns the specified pod as last created or updated in the provider, or nil if it wasn't by this server.
+func (s *Server) pushedPod(namespace, name string) *corev1.Pod {
+	s.pushedPodsLock.Lock()
+	defer s.pushedPodsLock.Unlock()
+	return s.pushedPods[namespace+"/"+name]
+}
+
+// setPushedPod records the specified pod as created or updated in the provider.
+func (s *Server) setPushedPod(pod *corev1.Pod) {
+	s.pushedPodsLock.Lock()
+	defer s.pushedPodsLock.Unlock()
+	s.pushedPods[pod.Namespace+"/"+pod.Name] = pod.DeepCopy()
+}
+
+// forgetPushedPod forgets the specified pod once it is deleted.
+func (s *Server) forgetPushedPod(namespace, name string) {
+	s.pushedPodsLock.Lock()
+	defer s.pushedPodsLock.Unlock()
+	delete(s.pushedPods, namespace+"/"+name)
+}
+
+// podsEffectivelyEqual compares the fields of the specified pods which may be changed on a running pod.
+// These are the container images, ".spec.activeDeadlineSeconds", ".spec.tolerations" and the pod's labels and annotations.
+// This mirrors the checks performed by "ValidatePodUpdate" in the Kubernetes API server.
+// https://github.com/kubernetes/kubernetes/blob/v1.13.1/pkg/apis/core/validation/validation.go
+func podsEffectivelyEqual(p1, p2 *corev1.Pod) bool {
+	return stringMapsEqual(p1.Labels, p2.Labels) &&
+		stringMapsEqual(p1.Annotations, p2.Annotations) &&
+		containerImagesEqual(p1.Spec.InitContainers, p2.Spec.InitContainers) &&
+		containerImagesEqual(p1.Spec.Containers, p2.Spec.Containers) &&
+		reflect.DeepEqual(p1.Spec.ActiveDeadlineSeconds, p2.Spec.ActiveDeadlineSeconds) &&
+		tolerationsEqual(p1.Spec.Tolerations, p2.Spec.Tolerations)
+}
+
+// stringMapsEqual compares two maps, considering nil and empty maps to be equal.
+func stringMapsEqual(m1, m2 map[string]string) bool {
+	if len(m1) != len(m2) {
+		return false
+	}
+	for k, v1 := range m1 {
+		if v2, ok := m2[k]; !ok || v1 != v2 {
+			return false
+		}
+	}
+	return true
+}
+
+// containerImagesEqual checks whether the containers with the same name in both slices use the same image.
+func containerImagesEqual(c1, c2 []corev1.Container) bool {
+	if len(c1) != len(c2) {
+		return false
+	}
+	images := make(map[string]string, len(c1))
+	for _, c := range c1 {
+		images[c.Name] = c.Image
+	}
+	for _, c := range c2 {
+		if image, ok := images[c.Name]; !ok || image != c.Image {
+			return false
+		}
+	}
+	return true
+}
+
+// tolerationsEqual compares two slices of tolerations, considering nil and empty slices to be equal.
+func tolerationsEqual(t1, t2 []corev1.Toleration) bool {
+	if len(t1) == 0 && len(t2) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(t1, t2)
+}
+
 func (s *Server) deletePod(ctx context.Context, namespace, name string) error {
 	// Grab the pod as known by the provider.
 	// NOTE: Some providers return a non-nil error in their GetPod implementation when the pod is not found while some other don't.
@@ -84,6 +216,8 @@ func (s *Server) deletePod(ctx context.Context, namespace, name string) error {
 	pod, _ := s.provider.GetPod(ctx, namespace, name)
 	if pod == nil {
 		// The provider is not aware of the pod, but we must still delete the Kubernetes API resource.
+		s.forgetPushedPod(namespace, name)
+		s.finishTerminations(namespace, name)
 		return s.forceDeletePodResource(ctx, namespace, name)
 	}
 
@@ -91,22 +225,29 @@ func (s *Server) deletePod(ctx context.Context, namespace, name string) error {
 	defer span.End()
 	addPodAttributes(span, pod)
 
-	var delErr error
-	if delErr = s.provider.DeletePod(ctx, pod); delErr != nil && errors.IsNotFound(delErr) {
-		span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: delErr.Error()})
-		return delErr
+	// A pod which the provider no longer knows about has already been deleted from it.
+	if err := s.provider.DeletePod(ctx, pod); err != nil && !errors.IsNotFound(err) && !strongerrors.IsNotFound(err) {
+		span.SetStatus(ocstatus.FromError(err))
+		return pkgerrors.Wrap(err, "error deleting pod in the provider")
 	}
 	span.Annotate(nil, "Deleted pod from provider")
+	s.forgetPushedPod(namespace, name)
+	s.finishTerminations(namespace, name)
 
-	logger := log.G(ctx).WithField("pod", pod.GetName()).WithField("namespace", pod.GetNamespace())
-	if !errors.IsNotFound(delErr) {
-		if err := s.forceDeletePodResource(ctx, namespace, name); err != nil {
-			span.SetStatus(ocstatus.FromError(err))
-			return err
-		}
-		span.Annotate(nil, "Deleted pod from k8s")
-		logger.Info("Pod deleted")
+	if s.restartManager != nil {
+		s.restartManager.forget(pod.UID)
+	}
+	if s.prober != nil {
+		s.prober.forget(pod.UID)
+	}
+
+	if err := s.forceDeletePodResource(ctx, namespace, name); err != nil {
+		span.SetStatus(ocstatus.FromError(err))
+		return err
 	}
+	span.Annotate(nil, "Deleted pod from k8s")
+
+	log.G(ctx).WithField("pod", pod.GetName()).WithField("namespace", pod.GetNamespace()).Info("Pod deleted")
 
 	return nil
 }
@@ -132,7 +273,7 @@ func (s *Server) forceDeletePodResource(ctx context.Context, namespace, name str
 }
 
 // updatePodStatuses syncs the providers pod status with the kubernetes pod status.
-func (s *Server) updatePodStatuses(ctx context.Context) {
+func (s *Server) updatePodStatuses(ctx context.Context, recorder record.EventRecorder) {
 	ctx, span := trace.StartSpan(ctx, "updatePodStatuses")
 	defer span.End()
 
@@ -140,7 +281,9 @@ func (s *Server) updatePodStatuses(ctx context.Context) {
 	pods := s.resourceManager.GetPods()
 	span.AddAttributes(trace.Int64Attribute("nPods", int64(len(pods))))
 
+	s.configLock.Lock()
 	sema := make(chan struct{}, s.podSyncWorkers)
+	s.configLock.Unlock()
 	var wg sync.WaitGroup
 	wg.Add(len(pods))
 
@@ -156,7 +299,7 @@ func (s *Server) updatePodStatuses(ctx context.Context) {
 			}
 			defer func() { <-sema }()
 
-			if err := s.updatePodStatus(ctx, pod); err != nil {
+			if err := s.updatePodStatus(ctx, pod, recorder); err != nil {
 				logger := log.G(ctx).WithField("pod", pod.GetName()).WithField("namespace", pod.GetNamespace()).WithField("status", pod.Status.Phase).WithField("reason", pod.Status.Reason)
 				logger.Error(err)
 			}
@@ -167,7 +310,7 @@ func (s *Server) updatePodStatuses(ctx context.Context) {
 	wg.Wait()
 }
 
-func (s *Server) updatePodStatus(ctx context.Context, pod *corev1.Pod) error {
+func (s *Server) updatePodStatus(ctx context.Context, pod *corev1.Pod, recorder record.EventRecorder) error {
 	ctx, span := trace.StartSpan(ctx, "updatePodStatus")
 	defer span.End()
 	addPodAttributes(span, pod)
@@ -180,8 +323,29 @@ func (s *Server) updatePodStatus(ctx context.Context, pod *corev1.Pod) error {
 
 	status, err := s.provider.GetPodStatus(ctx, pod.Namespace, pod.Name)
 	if err != nil {
-		span.SetStatus(ocstatus.FromError(err))
-		return pkgerrors.Wrap(err, "error retreiving pod status")
+		// Pods lost by providers which delegate restarts are restarted below, rather than being reported as errors.
+		if !s.restartsDelegated || !(errors.IsNotFound(err) || strongerrors.IsNotFound(err)) {
+			span.SetStatus(ocstatus.FromError(err))
+			return pkgerrors.Wrap(err, "error retreiving pod status")
+		}
+		status = nil
+	}
+
+	// Containers failing their liveness probe are reported as terminated, and the readiness of the others is set from their readiness probe.
+	var unhealthy bool
+	if s.prober != nil && status != nil {
+		status, unhealthy = s.prober.apply(pod, status)
+	}
+
+	// Pods whose provider delegates restarts are restarted when their containers exit or when they are lost, as allowed by
+	// their restart policy. Pods which may still be being created are not considered lost, and terminating pods are not restarted.
+	// Pods with containers failing their liveness probe are restarted whatever the provider.
+	restart := s.restartsDelegated && (status != nil || podLost(pod)) || unhealthy
+	if restart && pod.DeletionTimestamp == nil {
+		if status, err = s.enforceRestartPolicy(ctx, pod, status, recorder); err != nil {
+			span.SetStatus(ocstatus.FromError(err))
+			return err
+		}
 	}
 
 	// Update the pod's status
@@ -190,7 +354,7 @@ func (s *Server) updatePodStatus(ctx context.Context, pod *corev1.Pod) error {
 	} else {
 		// Only change the status when the pod was already up
 		// Only doing so when the pod was successfully running makes sure we don't run into race conditions during pod creation.
-		if pod.Status.Phase == corev1.PodRunning || pod.ObjectMeta.CreationTimestamp.Add(time.Minute).Before(time.Now()) {
+		if podLost(pod) {
 			// Set the pod to failed, this makes sure if the underlying container implementation is gone that a new pod will be created.
 			pod.Status.Phase = corev1.PodFailed
 			pod.Status.Reason = "NotFound"
@@ -210,6 +374,7 @@ func (s *Server) updatePodStatus(ctx context.Context, pod *corev1.Pod) error {
 	}
 
 	if _, err := s.k8sClient.CoreV1().Pods(pod.Namespace).UpdateStatus(pod); err != nil {
+		s.recordUpdateConflict(ctx, "pod", err)
 		span.SetStatus(ocstatus.FromError(err))
 		return pkgerrors.Wrap(err, "error while updating pod status in kubernetes")
 	}
@@ -220,3 +385,9 @@ func (s *Server) updatePodStatus(ctx context.Context, pod *corev1.Pod) error {
 	}, "updated pod status in kubernetes")
 	return nil
 }
+
+// podLost returns whether the specified pod, whose status isn't known to the provider, must have been lost by the provider.
+// This is the case when the pod was already running, or was created long enough ago for the provider to know about it.
+func podLost(pod *corev1.Pod) bool {
+	return pod.Status.Phase == corev1.PodRunning || pod.ObjectMeta.CreationTimestamp.Add(time.Minute).Before(time.Now())
+}
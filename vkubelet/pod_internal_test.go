@@ -0,0 +1,135 @@
+package vkubelet
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+	testutil "github.com/virtual-kubelet/virtual-kubelet/test/util"
+)
+
+// TestPodsEffectivelyEqual verifies that only changes to the mutable fields of a pod are considered when comparing pods.
+func TestPodsEffectivelyEqual(t *testing.T) {
+	var (
+		deadline = int64(30)
+	)
+
+	tests := []struct {
+		name   string
+		mutate func(pod *corev1.Pod)
+		equal  bool
+	}{
+		{
+			name:   "no changes",
+			mutate: func(pod *corev1.Pod) {},
+			equal:  true,
+		},
+		{
+			name: "empty annotations",
+			mutate: func(pod *corev1.Pod) {
+				pod.Annotations = map[string]string{}
+			},
+			equal: true,
+		},
+		{
+			name: "changed label",
+			mutate: func(pod *corev1.Pod) {
+				pod.Labels = map[string]string{"app": "bar"}
+			},
+			equal: false,
+		},
+		{
+			name: "changed annotation",
+			mutate: func(pod *corev1.Pod) {
+				pod.Annotations = map[string]string{"foo": "bar"}
+			},
+			equal: false,
+		},
+		{
+			name: "changed image",
+			mutate: func(pod *corev1.Pod) {
+				pod.Spec.Containers[0].Image = "image-1"
+			},
+			equal: false,
+		},
+		{
+			name: "changed active deadline",
+			mutate: func(pod *corev1.Pod) {
+				pod.Spec.ActiveDeadlineSeconds = &deadline
+			},
+			equal: false,
+		},
+		{
+			name: "added toleration",
+			mutate: func(pod *corev1.Pod) {
+				pod.Spec.Tolerations = append(pod.Spec.Tolerations, corev1.Toleration{Key: "foo", Operator: corev1.TolerationOpExists})
+			},
+			equal: false,
+		},
+		{
+			name: "changed immutable field",
+			mutate: func(pod *corev1.Pod) {
+				pod.Spec.Containers[0].Command = []string{"sleep", "infinity"}
+			},
+			equal: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p1 := testutil.FakePodWithSingleContainer(namespace, "pod-0", "image-0")
+			p1.Labels = map[string]string{"app": "foo"}
+			p2 := p1.DeepCopy()
+			tc.mutate(p2)
+			assert.Equal(t, tc.equal, podsEffectivelyEqual(p1, p2))
+		})
+	}
+}
+
+// updateCountingProvider counts the updates of pods.
+type updateCountingProvider struct {
+	providers.Provider
+	updates int32
+}
+
+func (p *updateCountingProvider) UpdatePod(context.Context, *corev1.Pod) error {
+	atomic.AddInt32(&p.updates, 1)
+	return nil
+}
+
+// TestUpdatePod verifies that pods are compared with the pod last pushed to the provider, rather than with the pod
+// returned by the provider, which may lack some of the mutable fields.
+func TestUpdatePod(t *testing.T) {
+	p := &updateCountingProvider{}
+	s := New(Config{Provider: p, ResourceManager: testutil.FakeResourceManager()})
+	recorder := record.NewFakeRecorder(10)
+	ctx := context.Background()
+
+	pod := testutil.FakePodWithSingleContainer(namespace, "pod-0", "image-0")
+	pod.Labels = map[string]string{"app": "foo"}
+	// The provider doesn't return the labels of the pod.
+	pp := pod.DeepCopy()
+	pp.Labels = nil
+
+	// The pod created by a previous run is compared with the pod returned by the provider.
+	require.NoError(t, s.updatePod(ctx, pp, pod, recorder))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&p.updates))
+	for i := 0; i < 2; i++ {
+		require.NoError(t, s.updatePod(ctx, pp, pod, recorder))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&p.updates))
+
+	pod.Spec.Containers[0].Image = "image-1"
+	require.NoError(t, s.updatePod(ctx, pp, pod, recorder))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&p.updates))
+
+	// Pods deleted from the provider are forgotten.
+	s.forgetPushedPod(pod.Namespace, pod.Name)
+	assert.Nil(t, s.pushedPod(pod.Namespace, pod.Name))
+}
@@ -27,6 +27,7 @@ import (
 	"go.opencensus.io/trace"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -37,6 +38,7 @@ import (
 	"k8s.io/client-go/util/workqueue"
 
 	"github.com/virtual-kubelet/virtual-kubelet/log"
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
 )
 
 const (
@@ -57,8 +59,26 @@ type PodController struct {
 	// This is used to queue work to be processed instead of performing it as soon as a change happens.
 	// This means we can ensure we only process a fixed amount of resources at a time, and makes it easy to ensure we are never processing the same item simultaneously in two different workers.
 	workqueue workqueue.RateLimitingInterface
+	// podStatusQueue is a rate limited work queue holding the keys of pods whose status has been reported as changed by the provider.
+	// It is only used when the provider implements providers.PodNotifier.
+	podStatusQueue workqueue.RateLimitingInterface
+	// orphanedPodsQueue is a rate limited work queue holding the keys of pods which exist in the provider but not in Kubernetes.
+	// Failing deletions of these pods are retried with an exponential backoff.
+	orphanedPodsQueue workqueue.RateLimitingInterface
 	// recorder is an event recorder for recording Event resources to the Kubernetes API.
 	recorder record.EventRecorder
+	// reportedOrphans holds the UIDs of the orphaned pods found by the last reconciliation, which are only reported once.
+	// It is only used by reconcileOrphanedPods, which is never run concurrently.
+	reportedOrphans map[types.UID]struct{}
+
+	// workersLock guards workers, workersCtx and workerStops.
+	workersLock sync.Mutex
+	// workers is the number of workers processing each work queue, or zero until it is set by Run or setWorkers.
+	workers int
+	// workersCtx is the context workers are started with, which is set once the controller is running.
+	workersCtx context.Context
+	// workerStops holds a function stopping each running worker, indexed by worker ID.
+	workerStops []context.CancelFunc
 }
 
 // NewPodController returns a new instance of PodController.
@@ -69,13 +89,19 @@ func NewPodController(server *Server) *PodController {
 	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: server.k8sClient.CoreV1().Events("")})
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: fmt.Sprintf("%s/pod-controller", server.nodeName)})
 
+	// Record the metrics of the work queues created below.
+	setWorkqueueMetricsProvider()
+
 	// Create an instance of PodController having a work queue that uses the rate limiter created above.
 	pc := &PodController{
-		server:       server,
-		podsInformer: server.podInformer,
-		podsLister:   server.podInformer.Lister(),
-		workqueue:    workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "pods"),
-		recorder:     recorder,
+		server:         server,
+		podsInformer:   server.podInformer,
+		podsLister:     server.podInformer.Lister(),
+		workqueue:      workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), queueName(server.nodeName, "pods")),
+		podStatusQueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), queueName(server.nodeName, "podStatusUpdates")),
+		recorder:       recorder,
+
+		orphanedPodsQueue: workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), queueName(server.nodeName, "orphanedPods")),
 	}
 
 	// Set up event handlers for when Pod resources change.
@@ -123,25 +149,36 @@ func NewPodController(server *Server) *PodController {
 // It will block until stopCh is closed, at which point it will shutdown the work queue and wait for workers to finish processing their current work items.
 func (pc *PodController) Run(ctx context.Context, threadiness int) error {
 	defer pc.workqueue.ShutDown()
+	defer pc.podStatusQueue.ShutDown()
+	defer pc.orphanedPodsQueue.ShutDown()
 
 	// Wait for the caches to be synced before starting workers.
 	if ok := cache.WaitForCacheSync(ctx.Done(), pc.podsInformer.Informer().HasSynced); !ok {
 		return pkgerrors.New("failed to wait for caches to sync")
 	}
 
-	// Perform a reconciliation step that deletes any dangling pods from the provider.
-	// This happens only when the virtual-kubelet is starting, and operates on a "best-effort" basis.
-	// If by any reason the provider fails to delete a dangling pod, it will stay in the provider and deletion won't be retried.
-	pc.deleteDanglingPods(ctx, threadiness)
+	// Look for orphaned pods in the provider, which don't exist in Kubernetes, so that they get deleted.
+	// This happens when the virtual-kubelet is starting, and then periodically if a reconciliation interval is configured.
+	// Orphaned pods which fail to be deleted are retried with an exponential backoff.
+	if pc.server.orphanedPodsReconcileInterval > 0 {
+		go wait.Until(func() {
+			pc.reconcileOrphanedPods(ctx)
+		}, pc.server.orphanedPodsReconcileInterval, ctx.Done())
+	} else {
+		pc.reconcileOrphanedPods(ctx)
+	}
+
+	pc.notifyPodStatusUpdates(ctx)
 
 	// Launch "threadiness" workers to process Pod resources.
 	log.G(ctx).Info("starting workers")
-	for id := 0; id < threadiness; id++ {
-		go wait.Until(func() {
-			// Use the worker's "index" as its ID so we can use it for tracing.
-			pc.runWorker(ctx, strconv.Itoa(id))
-		}, time.Second, ctx.Done())
+	pc.workersLock.Lock()
+	pc.workersCtx = ctx
+	if pc.workers == 0 {
+		pc.workers = threadiness
 	}
+	pc.startWorkers()
+	pc.workersLock.Unlock()
 
 	log.G(ctx).Info("started workers")
 	<-ctx.Done()
@@ -150,34 +187,102 @@ func (pc *PodController) Run(ctx context.Context, threadiness int) error {
 	return nil
 }
 
+// setWorkers changes the number of workers processing each work queue, starting or stopping workers if the controller
+// is running. Stopped workers finish processing their current work item first.
+// When it is called before Run, the number of workers passed to Run is ignored.
+func (pc *PodController) setWorkers(threadiness int) {
+	pc.workersLock.Lock()
+	defer pc.workersLock.Unlock()
+
+	pc.workers = threadiness
+	if pc.workersCtx != nil {
+		pc.startWorkers()
+	}
+}
+
+// startWorkers starts or stops workers so that pc.workers workers process each work queue.
+// It must be called with workersLock held.
+func (pc *PodController) startWorkers() {
+	for id := len(pc.workerStops); id < pc.workers; id++ {
+		// Use the worker's "index" as its ID so we can use it for tracing.
+		workerID := strconv.Itoa(id)
+		// Work items are processed with the context of the controller, so that stopping a worker doesn't cancel its current item.
+		ctx := pc.workersCtx
+		workerCtx, cancel := context.WithCancel(ctx)
+		stop := workerCtx.Done()
+		go wait.Until(func() {
+			pc.runWorker(ctx, workerID, stop)
+		}, time.Second, stop)
+		go wait.Until(func() {
+			pc.runPodStatusWorker(ctx, workerID, stop)
+		}, time.Second, stop)
+		go wait.Until(func() {
+			pc.runOrphanedPodWorker(ctx, workerID, stop)
+		}, time.Second, stop)
+		pc.workerStops = append(pc.workerStops, cancel)
+	}
+	for len(pc.workerStops) > pc.workers {
+		last := len(pc.workerStops) - 1
+		pc.workerStops[last]()
+		pc.workerStops = pc.workerStops[:last]
+	}
+}
+
+// isStopped returns whether the specified stop channel is closed.
+func isStopped(stop <-chan struct{}) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
 // runWorker is a long-running function that will continually call the processNextWorkItem function in order to read and process an item on the work queue.
-func (pc *PodController) runWorker(ctx context.Context, workerId string) {
-	for pc.processNextWorkItem(ctx, workerId) {
+func (pc *PodController) runWorker(ctx context.Context, workerId string, stop <-chan struct{}) {
+	for !isStopped(stop) && pc.processNextWorkItem(ctx, workerId) {
 	}
 }
 
 // processNextWorkItem will read a single work item off the work queue and attempt to process it,by calling the syncHandler.
 func (pc *PodController) processNextWorkItem(ctx context.Context, workerId string) bool {
-	obj, shutdown := pc.workqueue.Get()
+	return handleQueueItem(ctx, pc.workqueue, "processNextWorkItem", workerId, pc.syncHandler)
+}
+
+// runPodStatusWorker is a long-running function that will continually call the processNextPodStatusUpdate function in order to read and process an item on the pod status work queue.
+func (pc *PodController) runPodStatusWorker(ctx context.Context, workerId string, stop <-chan struct{}) {
+	for !isStopped(stop) && pc.processNextPodStatusUpdate(ctx, workerId) {
+	}
+}
+
+// processNextPodStatusUpdate will read a single work item off the pod status work queue and attempt to process it, by calling the podStatusHandler.
+func (pc *PodController) processNextPodStatusUpdate(ctx context.Context, workerId string) bool {
+	return handleQueueItem(ctx, pc.podStatusQueue, "processNextPodStatusUpdate", workerId, pc.podStatusHandler)
+}
+
+// handleQueueItem reads a single work item off the specified work queue and attempts to process it by calling the specified handler.
+// It returns false when the work queue has been shut down.
+func handleQueueItem(ctx context.Context, q workqueue.RateLimitingInterface, spanName, workerId string, handler func(ctx context.Context, key string) error) bool {
+	obj, shutdown := q.Get()
 
 	if shutdown {
 		return false
 	}
 
 	// We create a span only after popping from the queue so that we can get an adequate picture of how long it took to process the item.
-	ctx, span := trace.StartSpan(ctx, "processNextWorkItem")
+	ctx, span := trace.StartSpan(ctx, spanName)
 	defer span.End()
 
 	// Add the ID of the current worker as an attribute to the current span.
 	span.AddAttributes(trace.StringAttribute("workerId", workerId))
 
-	// We wrap this block in a func so we can defer pc.workqueue.Done.
+	// We wrap this block in a func so we can defer q.Done.
 	err := func(obj interface{}) error {
 		// We call Done here so the work queue knows we have finished processing this item.
 		// We also must remember to call Forget if we do not want this work item being re-queued.
 		// For example, we do not call Forget if a transient error occurs.
 		// Instead, the item is put back on the work queue and attempted again after a back-off period.
-		defer pc.workqueue.Done(obj)
+		defer q.Done(obj)
 		var key string
 		var ok bool
 		// We expect strings to come off the work queue.
@@ -185,26 +290,26 @@ func (pc *PodController) processNextWorkItem(ctx context.Context, workerId strin
 		// We do this as the delayed nature of the work queue means the items in the informer cache may actually be more up to date that when the item was initially put onto the workqueue.
 		if key, ok = obj.(string); !ok {
 			// As the item in the work queue is actually invalid, we call Forget here else we'd go into a loop of attempting to process a work item that is invalid.
-			pc.workqueue.Forget(obj)
+			q.Forget(obj)
 			log.G(ctx).Warnf("expected string in work queue but got %#v", obj)
 			return nil
 		}
 		// Add the current key as an attribute to the current span.
 		span.AddAttributes(trace.StringAttribute("key", key))
-		// Run the syncHandler, passing it the namespace/name string of the Pod resource to be synced.
-		if err := pc.syncHandler(ctx, key); err != nil {
-			if pc.workqueue.NumRequeues(key) < maxRetries {
+		// Run the handler, passing it the namespace/name string of the Pod resource to be synced.
+		if err := handler(ctx, key); err != nil {
+			if q.NumRequeues(key) < maxRetries {
 				// Put the item back on the work queue to handle any transient errors.
 				log.G(ctx).Warnf("requeuing %q due to failed sync: %v", key, err)
-				pc.workqueue.AddRateLimited(key)
+				q.AddRateLimited(key)
 				return nil
 			}
 			// We've exceeded the maximum retries, so we must forget the key.
-			pc.workqueue.Forget(key)
+			q.Forget(key)
 			return pkgerrors.Wrapf(err, "forgetting %q due to maximum retries reached", key)
 		}
 		// Finally, if no error occurs we Forget this item so it does not get queued again until another change happens.
-		pc.workqueue.Forget(obj)
+		q.Forget(obj)
 		return nil
 	}(obj)
 
@@ -266,13 +371,22 @@ func (pc *PodController) syncPodInProvider(ctx context.Context, pod *corev1.Pod)
 	addPodAttributes(span, pod)
 
 	// Check whether the pod has been marked for deletion.
-	// If it does, guarantee it is deleted in the provider and Kubernetes.
+	// If it does, guarantee it is gracefully stopped and then deleted in the provider and Kubernetes.
 	if pod.DeletionTimestamp != nil {
-		if err := pc.server.deletePod(ctx, pod.Namespace, pod.Name); err != nil {
+		after, err := pc.server.terminatePod(ctx, pod, pc.recorder)
+		if err != nil {
 			err := pkgerrors.Wrapf(err, "failed to delete pod %q in the provider", loggablePodName(pod))
 			span.SetStatus(ocstatus.FromError(err))
 			return err
 		}
+		if after > 0 {
+			// The pod is still terminating, so we check on it again later.
+			if key, err := cache.MetaNamespaceKeyFunc(pod); err != nil {
+				log.G(ctx).Error(err)
+			} else {
+				pc.workqueue.AddAfter(key, after)
+			}
+		}
 		return nil
 	}
 
@@ -291,73 +405,72 @@ func (pc *PodController) syncPodInProvider(ctx context.Context, pod *corev1.Pod)
 	return nil
 }
 
-// deleteDanglingPods checks whether the provider knows about any pods which Kubernetes doesn't know about, and deletes them.
-func (pc *PodController) deleteDanglingPods(ctx context.Context, threadiness int) {
-	ctx, span := trace.StartSpan(ctx, "deleteDanglingPods")
+// notifyPodStatusUpdates subscribes to the pod status change notifications of the provider, if it is able to send them.
+// Only pods whose status has changed are then synchronized, instead of polling the status of every pod.
+func (pc *PodController) notifyPodStatusUpdates(ctx context.Context) {
+	if _, ok := providers.Unwrap(pc.server.provider).(providers.PodNotifier); ok {
+		pc.server.provider.(providers.PodNotifier).NotifyPods(ctx, func(pod *corev1.Pod) {
+			pc.enqueuePodStatusUpdate(ctx, pod)
+		})
+	}
+}
+
+// enqueuePodStatusUpdate adds the key of the specified pod to the pod status work queue.
+// It is called by providers implementing providers.PodNotifier whenever the status of a pod changes.
+func (pc *PodController) enqueuePodStatusUpdate(ctx context.Context, pod *corev1.Pod) {
+	if key, err := cache.MetaNamespaceKeyFunc(pod); err != nil {
+		log.G(ctx).WithError(err).WithField("method", "enqueuePodStatusUpdate").Error("Error getting pod meta namespace key")
+	} else {
+		pc.podStatusQueue.AddRateLimited(key)
+	}
+}
+
+// podStatusHandler synchronizes the status of the pod identified by the specified key from the provider back to Kubernetes.
+func (pc *PodController) podStatusHandler(ctx context.Context, key string) error {
+	ctx, span := trace.StartSpan(ctx, "podStatusHandler")
 	defer span.End()
 
-	// Grab the list of pods known to the provider.
-	pps, err := pc.server.provider.GetPods(ctx)
+	// Add the current key as an attribute to the current span.
+	span.AddAttributes(trace.StringAttribute("key", key))
+
+	// Convert the namespace/name string into a distinct namespace and name.
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
 	if err != nil {
-		err := pkgerrors.Wrap(err, "failed to fetch the list of pods from the provider")
-		span.SetStatus(ocstatus.FromError(err))
-		log.G(ctx).Error(err)
-		return
+		// Log the error as a warning, but do not requeue the key as it is invalid.
+		log.G(ctx).Warn(pkgerrors.Wrapf(err, "invalid resource key: %q", key))
+		return nil
 	}
 
-	// Create a slice to hold the pods we will be deleting from the provider.
-	ptd := make([]*corev1.Pod, 0)
-
-	// Iterate over the pods known to the provider, marking for deletion those that don't exist in Kubernetes.
-	// Take on this opportunity to populate the list of key that correspond to pods known to the provider.
-	for _, pp := range pps {
-		if _, err := pc.podsLister.Pods(pp.Namespace).Get(pp.Name); err != nil {
-			if errors.IsNotFound(err) {
-				// The current pod does not exist in Kubernetes, so we mark it for deletion.
-				ptd = append(ptd, pp)
-				continue
-			}
-			// For some reason we couldn't fetch the pod from the lister, so we propagate the error.
-			err := pkgerrors.Wrap(err, "failed to fetch pod from the lister")
-			span.SetStatus(ocstatus.FromError(err))
-			log.G(ctx).Error(err)
-			return
+	// Get the Pod resource with this namespace/name.
+	pod, err := pc.podsLister.Pods(namespace).Get(name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			// The pod has been deleted from Kubernetes, so there is no status to update.
+			return nil
 		}
+		err := pkgerrors.Wrapf(err, "failed to fetch pod with key %q from lister", key)
+		span.SetStatus(ocstatus.FromError(err))
+		return err
 	}
 
-	// We delete each pod in its own goroutine, allowing a maximum of "threadiness" concurrent deletions.
-	semaphore := make(chan struct{}, threadiness)
-	var wg sync.WaitGroup
-	wg.Add(len(ptd))
-
-	// Iterate over the slice of pods to be deleted and delete them in the provider.
-	for _, pod := range ptd {
-		go func(ctx context.Context, pod *corev1.Pod) {
-			defer wg.Done()
-
-			ctx, span := trace.StartSpan(ctx, "deleteDanglingPod")
-			defer span.End()
-
-			semaphore <- struct{}{}
-			defer func() {
-				<-semaphore
-			}()
-
-			// Add the pod's attributes to the current span.
-			addPodAttributes(span, pod)
-			// Actually delete the pod.
-			if err := pc.server.deletePod(ctx, pod.Namespace, pod.Name); err != nil {
-				span.SetStatus(ocstatus.FromError(err))
-				log.G(ctx).Errorf("failed to delete pod %q in provider", loggablePodName(pod))
-			} else {
-				log.G(ctx).Infof("deleted leaked pod %q in provider", loggablePodName(pod))
-			}
-		}(ctx, pod)
+	// The status of terminating pods is reported while they are being synced, which must happen as soon as their containers stop.
+	if pod.DeletionTimestamp != nil {
+		pc.workqueue.Add(key)
+		return nil
+	}
+
+	// Make a copy of the pod so that we don't mutate the informer's cache.
+	if err := pc.server.updatePodStatus(ctx, pod.DeepCopy(), pc.recorder); err != nil {
+		err := pkgerrors.Wrapf(err, "failed to update status of pod %q", loggablePodName(pod))
+		span.SetStatus(ocstatus.FromError(err))
+		return err
 	}
 
-	// Wait for all pods to be deleted.
-	wg.Wait()
-	return
+	// As the provider only notifies us about status changes, pods waiting to be restarted are checked on again when their back-off expires.
+	if after := pc.server.restartBackOffRemaining(pod); after > 0 {
+		pc.podStatusQueue.AddAfter(key, after)
+	}
+	return nil
 }
 
 // loggablePodName returns the "namespace/name" key for the specified pod.
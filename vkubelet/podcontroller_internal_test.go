@@ -0,0 +1,111 @@
+package vkubelet
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+)
+
+// TestSetWorkers verifies that workers are started and stopped when their number changes while the controller is running.
+func TestSetWorkers(t *testing.T) {
+	pc := &PodController{
+		workqueue:         workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		podStatusQueue:    workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		orphanedPodsQueue: workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+	}
+	defer pc.workqueue.ShutDown()
+	defer pc.podStatusQueue.ShutDown()
+	defer pc.orphanedPodsQueue.ShutDown()
+
+	// Workers aren't started until the controller runs.
+	pc.setWorkers(3)
+	assert.Len(t, pc.workerStops, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pc.workersLock.Lock()
+	pc.workersCtx = ctx
+	pc.startWorkers()
+	pc.workersLock.Unlock()
+	assert.Len(t, pc.workerStops, 3)
+
+	pc.setWorkers(1)
+	assert.Len(t, pc.workerStops, 1)
+	pc.setWorkers(5)
+	assert.Len(t, pc.workerStops, 5)
+}
+
+// notifyingProvider notifies the status changes of its pods, which all have the same status.
+type notifyingProvider struct {
+	providers.Provider
+	status *corev1.PodStatus
+	notify func(*corev1.Pod)
+}
+
+func (p *notifyingProvider) GetPodStatus(context.Context, string, string) (*corev1.PodStatus, error) {
+	return p.status, nil
+}
+
+func (p *notifyingProvider) NotifyPods(_ context.Context, f func(*corev1.Pod)) {
+	p.notify = f
+}
+
+// TestPodStatusNotifications verifies that the status of the pods notified by the provider is updated in Kubernetes.
+func TestPodStatusNotifications(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod-0"},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	}
+	client := fake.NewSimpleClientset(pod)
+	p := &notifyingProvider{status: &corev1.PodStatus{Phase: corev1.PodRunning}}
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	pc := &PodController{
+		server:         New(Config{NodeName: "node", Client: client, Provider: p}),
+		podsLister:     corev1listers.NewPodLister(indexer),
+		workqueue:      workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		podStatusQueue: workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		recorder:       record.NewFakeRecorder(10),
+	}
+	defer pc.workqueue.ShutDown()
+	defer pc.podStatusQueue.ShutDown()
+	ctx := context.Background()
+
+	// The notifications are received through the middlewares decorating the provider.
+	pc.notifyPodStatusUpdates(ctx)
+	require.NotNil(t, p.notify)
+
+	require.NoError(t, indexer.Add(pod))
+	p.notify(pod)
+	require.True(t, pc.processNextPodStatusUpdate(ctx, "test"))
+	updated, err := client.CoreV1().Pods("default").Get("pod-0", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, corev1.PodRunning, updated.Status.Phase)
+
+	// The pods deleted from Kubernetes have no status to update.
+	require.NoError(t, indexer.Delete(pod))
+	client.ClearActions()
+	p.notify(pod)
+	require.True(t, pc.processNextPodStatusUpdate(ctx, "test"))
+	assert.Empty(t, client.Actions())
+
+	// The terminating pods are synchronized instead.
+	terminating := pod.DeepCopy()
+	now := metav1.Now()
+	terminating.DeletionTimestamp = &now
+	require.NoError(t, indexer.Add(terminating))
+	p.notify(terminating)
+	require.True(t, pc.processNextPodStatusUpdate(ctx, "test"))
+	assert.Empty(t, client.Actions())
+	assert.Equal(t, 1, pc.workqueue.Len())
+}
@@ -0,0 +1,458 @@
+package vkubelet
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opencensus.io/trace"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/virtual-kubelet/virtual-kubelet/log"
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+)
+
+const (
+	// ReasonUnhealthy is the reason used in events emitted when a probe of a container fails, as used by the Kubelet.
+	ReasonUnhealthy = "Unhealthy"
+
+	// probeInterval is the interval at which containers are checked for probes which are due.
+	probeInterval = time.Second
+	// maxProbeOutput is the maximum number of bytes of the output of a probe which is reported when it fails.
+	maxProbeOutput = 1024
+)
+
+// probeType is the type of a probe, which determines what its result is used for.
+type probeType string
+
+const (
+	readinessProbe probeType = "Readiness"
+	livenessProbe  probeType = "Liveness"
+)
+
+// probeKey identifies a probe of a container.
+type probeKey struct {
+	uid       types.UID
+	container string
+	probeType probeType
+}
+
+// probeResult holds the state of a probe of a container.
+type probeResult struct {
+	// startedAt is the time at which the probed container started, at second precision, as stored by the API server.
+	// The result is reset when the container restarts.
+	startedAt time.Time
+	// lastProbe is the time at which the container was last probed.
+	lastProbe time.Time
+	// inFlight is whether the container is being probed.
+	inFlight bool
+	// successes and failures are the numbers of consecutive successful and failed probes.
+	successes int32
+	failures  int32
+	// healthy is whether the container is ready, for readiness probes, or alive, for liveness probes.
+	healthy bool
+}
+
+// prober runs the readiness and liveness probes of the running containers of pods.
+// HTTP and TCP probes which don't specify a host are only run once the pod has an IP.
+// Probe results are applied to the statuses reported by the provider: readiness probes determine whether containers are
+// ready, and containers failing their liveness probe are reported as terminated so that they get restarted.
+type prober struct {
+	provider providers.Provider
+	client   *http.Client
+
+	mu      sync.Mutex
+	results map[probeKey]*probeResult
+}
+
+// newProber returns a prober which has not probed any container yet.
+func newProber(p providers.Provider) *prober {
+	return &prober{
+		provider: p,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:             http.ProxyFromEnvironment,
+				DisableKeepAlives: true,
+				// Like the Kubelet, HTTPS probes don't verify the certificates of containers, which are rarely signed
+				// for their pod IP.
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+			},
+		},
+		results: make(map[probeKey]*probeResult),
+	}
+}
+
+// run probes the containers of the pods returned by the specified function until the context is cancelled.
+// The specified function is called with pods whose readiness or liveness changes.
+func (p *prober) run(ctx context.Context, pods func() []*corev1.Pod, recorder record.EventRecorder, changed func(*corev1.Pod)) {
+	t := time.NewTicker(probeInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			for _, pod := range pods() {
+				p.probePod(ctx, pod, recorder, changed)
+			}
+		}
+	}
+}
+
+// probePod starts the probes of the running containers of the specified pod which are due.
+func (p *prober) probePod(ctx context.Context, pod *corev1.Pod, recorder record.EventRecorder, changed func(*corev1.Pod)) {
+	if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+		return
+	}
+	for idx := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[idx]
+		startedAt, ok := containerStartedAt(pod.Status.ContainerStatuses, c.Name)
+		if !ok {
+			continue
+		}
+		if c.ReadinessProbe != nil && (pod.Status.PodIP != "" || !needsPodIP(c.ReadinessProbe)) {
+			p.maybeProbe(ctx, pod, c, readinessProbe, c.ReadinessProbe, startedAt, recorder, changed)
+		}
+		if c.LivenessProbe != nil && (pod.Status.PodIP != "" || !needsPodIP(c.LivenessProbe)) {
+			p.maybeProbe(ctx, pod, c, livenessProbe, c.LivenessProbe, startedAt, recorder, changed)
+		}
+	}
+}
+
+// maybeProbe runs the specified probe of a container in the background if it is due, and records its result.
+func (p *prober) maybeProbe(ctx context.Context, pod *corev1.Pod, c *corev1.Container, pt probeType, probe *corev1.Probe, startedAt time.Time, recorder record.EventRecorder, changed func(*corev1.Pod)) {
+	key := probeKey{uid: pod.UID, container: c.Name, probeType: pt}
+	now := time.Now()
+
+	p.mu.Lock()
+	r, ok := p.results[key]
+	if !ok || !sameStart(r.startedAt, startedAt) {
+		// Containers are considered alive, but not ready, until probed otherwise.
+		r = &probeResult{startedAt: startedAt.Truncate(time.Second), healthy: pt == livenessProbe}
+		p.results[key] = r
+	}
+	due := !r.inFlight &&
+		!now.Before(startedAt.Add(seconds(probe.InitialDelaySeconds, 0))) &&
+		!now.Before(r.lastProbe.Add(seconds(probe.PeriodSeconds, 10)))
+	if due {
+		r.inFlight = true
+		r.lastProbe = now
+	}
+	p.mu.Unlock()
+
+	if !due {
+		return
+	}
+
+	go func() {
+		ctx, span := trace.StartSpan(ctx, "probeContainer")
+		defer span.End()
+		addPodAttributes(span, pod)
+		span.AddAttributes(trace.StringAttribute("container", c.Name), trace.StringAttribute("probe", string(pt)))
+
+		output, err := p.probe(ctx, pod, c, probe)
+
+		p.mu.Lock()
+		r.inFlight = false
+		wasHealthy := r.healthy
+		if err == nil {
+			r.failures = 0
+			r.successes++
+			if r.successes >= threshold(probe.SuccessThreshold, 1) {
+				r.healthy = true
+			}
+		} else {
+			r.successes = 0
+			r.failures++
+			if r.failures >= threshold(probe.FailureThreshold, 3) {
+				r.healthy = false
+			}
+		}
+		healthy := r.healthy
+		current := p.results[key] == r
+		p.mu.Unlock()
+
+		if err != nil {
+			message := fmt.Sprintf("%s probe failed: %v", pt, err)
+			if output != "" {
+				message = fmt.Sprintf("%s probe failed: %s", pt, output)
+			}
+			recorder.Event(pod, corev1.EventTypeWarning, ReasonUnhealthy, message)
+			log.G(ctx).WithField("pod", pod.GetName()).WithField("namespace", pod.GetNamespace()).WithField("container", c.Name).Debug(message)
+		}
+		if current && healthy != wasHealthy {
+			changed(pod)
+		}
+	}()
+}
+
+// probe runs the specified probe of a container once, returning an error when it fails along with its output, if any.
+// https://github.com/kubernetes/kubernetes/blob/v1.13.1/pkg/kubelet/prober/prober.go#L140-L200
+func (p *prober) probe(ctx context.Context, pod *corev1.Pod, c *corev1.Container, probe *corev1.Probe) (string, error) {
+	timeout := seconds(probe.TimeoutSeconds, 1)
+
+	switch {
+	case probe.Exec != nil:
+		var out probeOutput
+		err := p.provider.ExecInContainer(fmt.Sprintf("%s-%s", pod.Namespace, pod.Name), pod.UID, c.Name, probe.Exec.Command, nil, &out, &out, false, nil, timeout)
+		return out.String(), err
+	case probe.HTTPGet != nil:
+		return p.probeHTTP(ctx, pod, c, probe.HTTPGet, timeout)
+	case probe.TCPSocket != nil:
+		port, err := resolvePort(probe.TCPSocket.Port, c)
+		if err != nil {
+			return "", err
+		}
+		host := probe.TCPSocket.Host
+		if host == "" {
+			host = pod.Status.PodIP
+		}
+		conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(port)), timeout)
+		if err != nil {
+			return "", err
+		}
+		return "", conn.Close()
+	}
+	return "", fmt.Errorf("probe of container %q has no handler", c.Name)
+}
+
+// probeHTTP performs an HTTP GET request against a container, which succeeds when the response status is 2xx or 3xx.
+func (p *prober) probeHTTP(ctx context.Context, pod *corev1.Pod, c *corev1.Container, action *corev1.HTTPGetAction, timeout time.Duration) (string, error) {
+	port, err := resolvePort(action.Port, c)
+	if err != nil {
+		return "", err
+	}
+	host := action.Host
+	if host == "" {
+		host = pod.Status.PodIP
+	}
+	scheme := strings.ToLower(string(action.Scheme))
+	if scheme == "" {
+		scheme = "http"
+	}
+	u := &url.URL{Scheme: scheme, Host: net.JoinHostPort(host, strconv.Itoa(port))}
+	if u, err = u.Parse(action.Path); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	for _, h := range action.HTTPHeaders {
+		if strings.EqualFold(h.Name, "Host") {
+			req.Host = h.Value
+			continue
+		}
+		req.Header.Add(h.Name, h.Value)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	res, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	var out probeOutput
+	out.readFrom(res.Body)
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusBadRequest {
+		return out.String(), fmt.Errorf("HTTP probe failed with statuscode: %d", res.StatusCode)
+	}
+	return out.String(), nil
+}
+
+// apply returns a copy of the specified status reported by the provider, with the readiness of containers and of the pod
+// set from the results of their readiness probes, and containers which failed their liveness probe reported as terminated.
+// It also returns whether any container failed its liveness probe.
+func (p *prober) apply(pod *corev1.Pod, status *corev1.PodStatus) (*corev1.PodStatus, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	status = status.DeepCopy()
+	var unhealthy bool
+	for i := range status.ContainerStatuses {
+		cs := &status.ContainerStatuses[i]
+		if cs.State.Running == nil {
+			continue
+		}
+		startedAt := cs.State.Running.StartedAt.Time
+		if r, ok := p.results[probeKey{uid: pod.UID, container: cs.Name, probeType: livenessProbe}]; ok && sameStart(r.startedAt, startedAt) && !r.healthy {
+			unhealthy = true
+			cs.Ready = false
+			cs.State = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				ExitCode:    137,
+				Reason:      "Error",
+				Message:     "Container failed its liveness probe",
+				StartedAt:   cs.State.Running.StartedAt,
+				FinishedAt:  metav1.Now(),
+				ContainerID: cs.ContainerID,
+			}}
+			continue
+		}
+		if podContainerHasReadinessProbe(pod, cs.Name) {
+			r, ok := p.results[probeKey{uid: pod.UID, container: cs.Name, probeType: readinessProbe}]
+			cs.Ready = ok && sameStart(r.startedAt, startedAt) && r.healthy
+		}
+	}
+
+	ready := len(status.ContainerStatuses) > 0
+	for _, cs := range status.ContainerStatuses {
+		ready = ready && cs.Ready
+	}
+	setPodCondition(status, corev1.ContainersReady, ready)
+	setPodCondition(status, corev1.PodReady, ready)
+	return status, unhealthy
+}
+
+// forget drops the probe results of the containers of the specified pod.
+func (p *prober) forget(uid types.UID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key := range p.results {
+		if key.uid == uid {
+			delete(p.results, key)
+		}
+	}
+}
+
+// probeOutput is a buffer which keeps at most maxProbeOutput bytes of the output of a probe.
+// It may be written to concurrently, as is the case of the stdout and stderr of exec probes.
+type probeOutput struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (o *probeOutput) Write(b []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if remaining := maxProbeOutput - o.buf.Len(); remaining < len(b) {
+		o.buf.Write(b[:remaining])
+	} else {
+		o.buf.Write(b)
+	}
+	return len(b), nil
+}
+
+func (o *probeOutput) Close() error {
+	return nil
+}
+
+func (o *probeOutput) String() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.buf.String()
+}
+
+// readFrom reads at most maxProbeOutput bytes from the specified reader into the buffer.
+func (o *probeOutput) readFrom(r io.Reader) {
+	io.Copy(o, io.LimitReader(r, maxProbeOutput))
+}
+
+// containerStartedAt returns the time at which the named container started, if it is running.
+func containerStartedAt(statuses []corev1.ContainerStatus, name string) (time.Time, bool) {
+	for _, cs := range statuses {
+		if cs.Name == name && cs.State.Running != nil {
+			return cs.State.Running.StartedAt.Time, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// sameStart returns whether the specified start times of a container are the same at second precision, which is the
+// precision of the times stored by the API server, whereas the statuses reported by providers may be more precise.
+func sameStart(a, b time.Time) bool {
+	return a.Truncate(time.Second).Equal(b.Truncate(time.Second))
+}
+
+// needsPodIP returns whether the specified probe connects to the IP of the pod, as HTTP and TCP probes do by default.
+func needsPodIP(probe *corev1.Probe) bool {
+	switch {
+	case probe.HTTPGet != nil:
+		return probe.HTTPGet.Host == ""
+	case probe.TCPSocket != nil:
+		return probe.TCPSocket.Host == ""
+	}
+	return false
+}
+
+// podContainerHasReadinessProbe returns whether the named container of the pod has a readiness probe.
+func podContainerHasReadinessProbe(pod *corev1.Pod, name string) bool {
+	for _, c := range pod.Spec.Containers {
+		if c.Name == name {
+			return c.ReadinessProbe != nil
+		}
+	}
+	return false
+}
+
+// setPodCondition sets the status of the specified condition of the pod, adding it if needed.
+func setPodCondition(status *corev1.PodStatus, conditionType corev1.PodConditionType, value bool) {
+	s := corev1.ConditionFalse
+	if value {
+		s = corev1.ConditionTrue
+	}
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == conditionType {
+			if status.Conditions[i].Status != s {
+				status.Conditions[i].Status = s
+				status.Conditions[i].LastTransitionTime = metav1.Now()
+			}
+			return
+		}
+	}
+	status.Conditions = append(status.Conditions, corev1.PodCondition{
+		Type:               conditionType,
+		Status:             s,
+		LastTransitionTime: metav1.Now(),
+	})
+}
+
+// resolvePort returns the number of the specified port of a container, which may be referenced by name.
+func resolvePort(port intstr.IntOrString, c *corev1.Container) (int, error) {
+	if port.Type == intstr.Int {
+		return port.IntValue(), nil
+	}
+	for _, p := range c.Ports {
+		if p.Name == port.StrVal {
+			return int(p.ContainerPort), nil
+		}
+	}
+	if n, err := strconv.Atoi(port.StrVal); err == nil {
+		return n, nil
+	}
+	return 0, fmt.Errorf("port %q not found in container %q", port.StrVal, c.Name)
+}
+
+// seconds returns the specified number of seconds as a duration, or the default number of seconds when it is not set.
+func seconds(n, defaultSeconds int32) time.Duration {
+	if n <= 0 {
+		n = defaultSeconds
+	}
+	return time.Duration(n) * time.Second
+}
+
+// threshold returns the specified probe threshold, or the default threshold when it is not set.
+func threshold(n, defaultThreshold int32) int32 {
+	if n <= 0 {
+		return defaultThreshold
+	}
+	return n
+}
@@ -0,0 +1,215 @@
+package vkubelet
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/remotecommand"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+)
+
+// execProvider is a provider whose exec commands always succeed.
+type execProvider struct {
+	providers.Provider
+}
+
+func (p *execProvider) ExecInContainer(name string, uid types.UID, container string, cmd []string, in io.Reader, out, err io.WriteCloser, tty bool, resize <-chan remotecommand.TerminalSize, timeout time.Duration) error {
+	_, e := out.Write([]byte("ok"))
+	return e
+}
+
+// TestProbe verifies that HTTP probes succeed on 2xx and 3xx responses only, and that TCP probes succeed when a
+// connection can be established, including to named ports.
+func TestProbe(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" || r.Header.Get("X-Probe") != "true" {
+			http.Error(w, "not healthy", http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	host, portStr, err := net.SplitHostPort(u.Host)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(portStr)
+	require.NoError(t, err)
+
+	pod := &corev1.Pod{Status: corev1.PodStatus{PodIP: host}}
+	c := &corev1.Container{
+		Name:  "container-0",
+		Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: int32(port)}},
+	}
+	p := newProber(nil)
+
+	probe := &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path:        "/healthz",
+				Port:        intstr.FromString("http"),
+				HTTPHeaders: []corev1.HTTPHeader{{Name: "X-Probe", Value: "true"}},
+			},
+		},
+	}
+	_, err = p.probe(context.Background(), pod, c, probe)
+	assert.NoError(t, err)
+
+	probe.HTTPGet.Path = "/"
+	output, err := p.probe(context.Background(), pod, c, probe)
+	assert.Error(t, err)
+	assert.Equal(t, "not healthy\n", output)
+
+	probe = &corev1.Probe{
+		Handler: corev1.Handler{
+			TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(port)},
+		},
+	}
+	_, err = p.probe(context.Background(), pod, c, probe)
+	assert.NoError(t, err)
+
+	probe.TCPSocket.Port = intstr.FromString("missing")
+	_, err = p.probe(context.Background(), pod, c, probe)
+	assert.Error(t, err)
+}
+
+// TestProbeHTTPS verifies that HTTPS probes succeed against containers serving certificates which can't be verified.
+func TestProbeHTTPS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	host, portStr, err := net.SplitHostPort(u.Host)
+	require.NoError(t, err)
+	port, err := strconv.Atoi(portStr)
+	require.NoError(t, err)
+
+	pod := &corev1.Pod{Status: corev1.PodStatus{PodIP: host}}
+	probe := &corev1.Probe{
+		Handler: corev1.Handler{
+			HTTPGet: &corev1.HTTPGetAction{
+				Path:   "/healthz",
+				Port:   intstr.FromInt(port),
+				Scheme: corev1.URISchemeHTTPS,
+			},
+		},
+	}
+	_, err = newProber(nil).probe(context.Background(), pod, &corev1.Container{Name: "container-0"}, probe)
+	assert.NoError(t, err)
+}
+
+// TestProberApply verifies that the readiness of containers and of the pod is set from readiness probes, and that
+// containers failing their liveness probe are reported as terminated.
+func TestProberApply(t *testing.T) {
+	startedAt := metav1.NewTime(time.Now().Truncate(time.Second))
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{UID: "pod-0"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{Name: "probed", ReadinessProbe: &corev1.Probe{}, LivenessProbe: &corev1.Probe{}},
+				{Name: "unprobed"},
+			},
+		},
+	}
+	status := &corev1.PodStatus{
+		Phase: corev1.PodRunning,
+		ContainerStatuses: []corev1.ContainerStatus{
+			{Name: "probed", Ready: true, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: startedAt}}},
+			{Name: "unprobed", Ready: true, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: startedAt}}},
+		},
+	}
+	p := newProber(nil)
+
+	// The container is not ready until its readiness probe succeeds.
+	applied, unhealthy := p.apply(pod, status)
+	assert.False(t, unhealthy)
+	assert.False(t, applied.ContainerStatuses[0].Ready)
+	assert.True(t, applied.ContainerStatuses[1].Ready)
+	assert.Equal(t, corev1.PodCondition{Type: corev1.PodReady, Status: corev1.ConditionFalse}, withoutTransitionTime(applied.Conditions[1]))
+
+	p.results[probeKey{uid: pod.UID, container: "probed", probeType: readinessProbe}] = &probeResult{startedAt: startedAt.Time, healthy: true}
+	applied, unhealthy = p.apply(pod, status)
+	assert.False(t, unhealthy)
+	assert.True(t, applied.ContainerStatuses[0].Ready)
+	assert.Equal(t, corev1.PodCondition{Type: corev1.ContainersReady, Status: corev1.ConditionTrue}, withoutTransitionTime(applied.Conditions[0]))
+
+	p.results[probeKey{uid: pod.UID, container: "probed", probeType: livenessProbe}] = &probeResult{startedAt: startedAt.Time, healthy: false}
+	applied, unhealthy = p.apply(pod, status)
+	assert.True(t, unhealthy)
+	assert.Equal(t, int32(137), applied.ContainerStatuses[0].State.Terminated.ExitCode)
+	assert.True(t, needsRestart(corev1.RestartPolicyAlways, applied))
+
+	// Results are not applied to containers which have restarted since they were probed.
+	status.ContainerStatuses[0].State.Running.StartedAt = metav1.NewTime(startedAt.Add(time.Minute))
+	applied, unhealthy = p.apply(pod, status)
+	assert.False(t, unhealthy)
+	assert.False(t, applied.ContainerStatuses[0].Ready)
+
+	p.forget(pod.UID)
+	assert.Empty(t, p.results)
+}
+
+// TestProberExec verifies that exec probes are run on pods without an IP, and that their results are applied to the
+// statuses reported by the provider, whose start times are more precise than those stored by the API server.
+func TestProberExec(t *testing.T) {
+	reported := metav1.NewTime(time.Now().Add(-time.Minute))
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{UID: "pod-0"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name: "probed",
+				ReadinessProbe: &corev1.Probe{
+					Handler: corev1.Handler{Exec: &corev1.ExecAction{Command: []string{"true"}}},
+				},
+			}},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "probed",
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(reported.Truncate(time.Second))}},
+			}},
+		},
+	}
+	p := newProber(&execProvider{})
+
+	changed := make(chan struct{}, 1)
+	p.probePod(context.Background(), pod, record.NewFakeRecorder(10), func(*corev1.Pod) {
+		changed <- struct{}{}
+	})
+	select {
+	case <-changed:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the readiness of the container did not change")
+	}
+
+	status := &corev1.PodStatus{
+		Phase: corev1.PodRunning,
+		ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  "probed",
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: reported}},
+		}},
+	}
+	applied, unhealthy := p.apply(pod, status)
+	assert.False(t, unhealthy)
+	assert.True(t, applied.ContainerStatuses[0].Ready)
+}
+
+// withoutTransitionTime returns the specified condition without its last transition time, so that it can be compared.
+func withoutTransitionTime(c corev1.PodCondition) corev1.PodCondition {
+	c.LastTransitionTime = metav1.Time{}
+	return c
+}
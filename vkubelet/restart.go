@@ -0,0 +1,295 @@
+package vkubelet
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cpuguy83/strongerrors"
+	pkgerrors "github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
+
+	"github.com/virtual-kubelet/virtual-kubelet/log"
+)
+
+const (
+	// ReasonBackOff is the reason used in events emitted when the restart of a pod is delayed by its back-off.
+	ReasonBackOff = "BackOff"
+	// ReasonRestarting is the reason used in events emitted when a pod is created again in the provider.
+	ReasonRestarting = "Restarting"
+
+	// reasonCrashLoopBackOff is the reason of the waiting state of containers whose pod is waiting to be restarted.
+	reasonCrashLoopBackOff = "CrashLoopBackOff"
+	// reasonContainerCreating is the reason of the waiting state of containers whose pod has just been restarted.
+	reasonContainerCreating = "ContainerCreating"
+
+	// initialRestartBackOff and maxRestartBackOff bound the delay between restarts of a pod, as done by the Kubelet.
+	// The back-off is reset once a pod hasn't been restarted for twice the maximum delay.
+	initialRestartBackOff = 10 * time.Second
+	maxRestartBackOff     = 300 * time.Second
+)
+
+// restartManager keeps track of the restarts of pods performed by the virtual-kubelet, which recreates them in the provider.
+// This happens when the provider delegates restarts to the virtual-kubelet, or when containers fail their liveness probe.
+// The restart history is kept in memory only, so the restart counts and back-offs of pods start over when the
+// virtual-kubelet restarts.
+type restartManager struct {
+	backoff *flowcontrol.Backoff
+
+	mu   sync.Mutex
+	pods map[types.UID]*podRestarts
+}
+
+// podRestarts holds the restart history of a pod.
+type podRestarts struct {
+	// count is the number of times the pod, and hence each of its containers, has been restarted.
+	count int32
+	// lastRestart is the time at which the pod was last restarted.
+	lastRestart time.Time
+	// lastTermination holds the state of each container of the pod before it was last restarted, by container name.
+	lastTermination map[string]corev1.ContainerState
+	// backOffReported is whether the back-off delaying the next restart of the pod has been reported with an event.
+	backOffReported bool
+}
+
+// newRestartManager returns a restart manager with no restart history.
+func newRestartManager() *restartManager {
+	return &restartManager{
+		backoff: flowcontrol.NewBackOff(initialRestartBackOff, maxRestartBackOff),
+		pods:    make(map[types.UID]*podRestarts),
+	}
+}
+
+// get returns the restart history of the specified pod, or nil if it has never been restarted.
+func (m *restartManager) get(uid types.UID) *podRestarts {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.pods[uid]
+}
+
+// recordRestart records that the specified pod is being restarted, after its containers ended in the specified status.
+// A nil status means the pod was lost by the provider.
+func (m *restartManager) recordRestart(pod *corev1.Pod, status *corev1.PodStatus, now time.Time) {
+	m.backoff.Next(string(pod.UID), now)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.pods[pod.UID]
+	if !ok {
+		r = &podRestarts{}
+		m.pods[pod.UID] = r
+	}
+	r.count++
+	r.lastRestart = now
+	r.backOffReported = false
+	r.lastTermination = make(map[string]corev1.ContainerState, len(pod.Spec.Containers))
+	for _, c := range pod.Spec.Containers {
+		r.lastTermination[c.Name] = terminatedState(c.Name, status, now)
+	}
+}
+
+// backOffRemaining returns how long the restart of the specified pod is still delayed for, if at all.
+func (m *restartManager) backOffRemaining(pod *corev1.Pod, now time.Time) time.Duration {
+	r := m.get(pod.UID)
+	if r == nil || !m.backoff.IsInBackOffSinceUpdate(string(pod.UID), now) {
+		return 0
+	}
+	return r.lastRestart.Add(m.backoff.Get(string(pod.UID))).Sub(now)
+}
+
+// reportBackOff returns whether the back-off delaying the next restart of the specified pod must be reported, which
+// is only the case the first time it is called since the pod was last restarted.
+func (m *restartManager) reportBackOff(uid types.UID) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r := m.pods[uid]
+	if r == nil || r.backOffReported {
+		return false
+	}
+	r.backOffReported = true
+	return true
+}
+
+// forget drops the restart history of the specified pod.
+func (m *restartManager) forget(uid types.UID) {
+	m.backoff.DeleteEntry(string(uid))
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.pods, uid)
+}
+
+// withRestarts returns a copy of the specified status reported by the provider, with the restarts of the pod added to the
+// restart count of each container, and their last termination state set from the restart history of the pod.
+func (m *restartManager) withRestarts(pod *corev1.Pod, status *corev1.PodStatus) *corev1.PodStatus {
+	r := m.get(pod.UID)
+	if r == nil {
+		return status
+	}
+	status = status.DeepCopy()
+	for i := range status.ContainerStatuses {
+		cs := &status.ContainerStatuses[i]
+		cs.RestartCount += r.count
+		if cs.LastTerminationState == (corev1.ContainerState{}) {
+			cs.LastTerminationState = r.lastTermination[cs.Name]
+		}
+	}
+	return status
+}
+
+// waitingStatus returns the status of the specified pod while its containers wait for it to be restarted.
+func (m *restartManager) waitingStatus(pod *corev1.Pod, reason, message string) *corev1.PodStatus {
+	r := m.get(pod.UID)
+
+	status := pod.Status.DeepCopy()
+	status.Phase = corev1.PodRunning
+	status.Reason = ""
+	status.Message = ""
+	for i := range status.Conditions {
+		if status.Conditions[i].Type == corev1.PodReady || status.Conditions[i].Type == corev1.ContainersReady {
+			status.Conditions[i].Status = corev1.ConditionFalse
+		}
+	}
+	status.ContainerStatuses = make([]corev1.ContainerStatus, 0, len(pod.Spec.Containers))
+	for _, c := range pod.Spec.Containers {
+		status.ContainerStatuses = append(status.ContainerStatuses, corev1.ContainerStatus{
+			Name:                 c.Name,
+			Image:                c.Image,
+			State:                corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason, Message: message}},
+			LastTerminationState: r.lastTermination[c.Name],
+			RestartCount:         r.count,
+		})
+	}
+	return status
+}
+
+// terminatedState returns the terminated state of the named container in the specified status.
+// Containers which are still running are considered to be killed by the restart of their pod, and containers of pods
+// lost by the provider are considered to have been deleted.
+func terminatedState(name string, status *corev1.PodStatus, now time.Time) corev1.ContainerState {
+	if status != nil {
+		for _, cs := range status.ContainerStatuses {
+			if cs.Name != name {
+				continue
+			}
+			if cs.State.Terminated != nil {
+				return corev1.ContainerState{Terminated: cs.State.Terminated.DeepCopy()}
+			}
+			t := &corev1.ContainerStateTerminated{
+				ExitCode:    137,
+				Reason:      "Killed",
+				Message:     "Container was killed to restart its pod",
+				FinishedAt:  metav1.NewTime(now),
+				ContainerID: cs.ContainerID,
+			}
+			if cs.State.Running != nil {
+				t.StartedAt = cs.State.Running.StartedAt
+			}
+			return corev1.ContainerState{Terminated: t}
+		}
+	}
+	return corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+		ExitCode:   -137,
+		Reason:     "NotFound",
+		Message:    "Container was not found and was likely deleted",
+		FinishedAt: metav1.NewTime(now),
+	}}
+}
+
+// needsRestart returns whether a pod whose containers are in the specified status must be restarted according to the
+// specified restart policy. A nil status means the pod was lost by the provider.
+func needsRestart(policy corev1.RestartPolicy, status *corev1.PodStatus) bool {
+	if policy == corev1.RestartPolicyNever {
+		return false
+	}
+	if status == nil {
+		return true
+	}
+	switch status.Phase {
+	case corev1.PodSucceeded:
+		return policy == corev1.RestartPolicyAlways
+	case corev1.PodFailed:
+		return true
+	}
+	for _, cs := range status.ContainerStatuses {
+		if t := cs.State.Terminated; t != nil && (policy == corev1.RestartPolicyAlways || t.ExitCode != 0) {
+			return true
+		}
+	}
+	return false
+}
+
+// enforceRestartPolicy restarts the specified pod in the provider when the status reported by the provider requires it
+// according to the pod's restart policy, and returns the status to report for the pod.
+// As the provider can't restart containers, the whole pod is deleted and created again, at most once per back-off period.
+func (s *Server) enforceRestartPolicy(ctx context.Context, pod *corev1.Pod, status *corev1.PodStatus, recorder record.EventRecorder) (*corev1.PodStatus, error) {
+	ctx, span := trace.StartSpan(ctx, "enforceRestartPolicy")
+	defer span.End()
+	addPodAttributes(span, pod)
+
+	m := s.restartManager
+	m.backoff.GC()
+
+	if !needsRestart(pod.Spec.RestartPolicy, status) {
+		if status == nil {
+			return nil, nil
+		}
+		return m.withRestarts(pod, status), nil
+	}
+
+	now := time.Now()
+	if remaining := m.backOffRemaining(pod, now); remaining > 0 {
+		message := fmt.Sprintf("Back-off %v restarting failed pod %s", m.backoff.Get(string(pod.UID)), loggablePodName(pod))
+		// The status of the pod is synchronized every few seconds, but the back-off is only reported once.
+		if m.reportBackOff(pod.UID) {
+			recorder.Event(pod, corev1.EventTypeWarning, ReasonBackOff, message)
+		}
+		span.Annotate(nil, "Pod restart is backing off")
+		return m.waitingStatus(pod, reasonCrashLoopBackOff, message), nil
+	}
+
+	logger := log.G(ctx).WithField("pod", pod.GetName()).WithField("namespace", pod.GetNamespace())
+
+	m.recordRestart(pod, status, now)
+
+	// NOTE: Some providers return a non-nil error in their GetPod implementation when the pod is not found while some other don't.
+	// Hence, we ignore the error and just act upon the pod if it is non-nil (meaning that the provider still knows about the pod).
+	if pp, _ := s.provider.GetPod(ctx, pod.Namespace, pod.Name); pp != nil {
+		if err := s.provider.DeletePod(ctx, pp); err != nil && !errors.IsNotFound(err) && !strongerrors.IsNotFound(err) {
+			return nil, pkgerrors.Wrap(err, "error deleting pod in the provider to restart it")
+		}
+	}
+
+	// The environment of the containers is only populated in the pod created in the provider, not in Kubernetes.
+	pod = pod.DeepCopy()
+	if err := populateEnvironmentVariables(ctx, pod, s.resourceManager, s.provider, recorder); err != nil {
+		return nil, err
+	}
+	if err := s.provider.CreatePod(ctx, pod); err != nil {
+		return nil, pkgerrors.Wrap(err, "error creating pod in the provider to restart it")
+	}
+	span.Annotate(nil, "Restarted pod in provider")
+	s.setPushedPod(pod)
+
+	recorder.Eventf(pod, corev1.EventTypeNormal, ReasonRestarting, "Restarted pod according to its %s restart policy", pod.Spec.RestartPolicy)
+	logger.WithField("restartCount", m.get(pod.UID).count).Info("Pod restarted")
+
+	return m.waitingStatus(pod, reasonContainerCreating, ""), nil
+}
+
+// restartBackOffRemaining returns how long the restart of the specified pod is still delayed for, if at all.
+func (s *Server) restartBackOffRemaining(pod *corev1.Pod) time.Duration {
+	if s.restartManager == nil {
+		return 0
+	}
+	return s.restartManager.backOffRemaining(pod, time.Now())
+}
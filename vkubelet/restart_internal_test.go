@@ -0,0 +1,124 @@
+package vkubelet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+	testutil "github.com/virtual-kubelet/virtual-kubelet/test/util"
+)
+
+// restartlessProvider is a provider which runs a single pod, and delegates its restarts to the virtual-kubelet.
+type restartlessProvider struct {
+	providers.Provider
+	pod     *corev1.Pod
+	created int
+	deleted int
+}
+
+func (p *restartlessProvider) DelegateRestarts() bool {
+	return true
+}
+
+func (p *restartlessProvider) GetPod(context.Context, string, string) (*corev1.Pod, error) {
+	return p.pod, nil
+}
+
+func (p *restartlessProvider) CreatePod(_ context.Context, pod *corev1.Pod) error {
+	p.created++
+	p.pod = pod
+	return nil
+}
+
+func (p *restartlessProvider) DeletePod(context.Context, *corev1.Pod) error {
+	p.deleted++
+	p.pod = nil
+	return nil
+}
+
+// containerStatus returns a pod status with a single container in the specified state.
+func containerStatus(state corev1.ContainerState) *corev1.PodStatus {
+	return &corev1.PodStatus{
+		Phase:             corev1.PodRunning,
+		ContainerStatuses: []corev1.ContainerStatus{{Name: "container-0", State: state}},
+	}
+}
+
+// TestNeedsRestart verifies that pods are restarted as allowed by their restart policy.
+func TestNeedsRestart(t *testing.T) {
+	succeeded := containerStatus(corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}})
+	failed := containerStatus(corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1}})
+	running := containerStatus(corev1.ContainerState{Running: &corev1.ContainerStateRunning{}})
+
+	assert.True(t, needsRestart(corev1.RestartPolicyAlways, succeeded))
+	assert.True(t, needsRestart(corev1.RestartPolicyAlways, failed))
+	assert.True(t, needsRestart(corev1.RestartPolicyAlways, nil))
+	assert.False(t, needsRestart(corev1.RestartPolicyAlways, running))
+
+	assert.False(t, needsRestart(corev1.RestartPolicyOnFailure, succeeded))
+	assert.True(t, needsRestart(corev1.RestartPolicyOnFailure, failed))
+	assert.True(t, needsRestart(corev1.RestartPolicyOnFailure, nil))
+	assert.True(t, needsRestart(corev1.RestartPolicyOnFailure, &corev1.PodStatus{Phase: corev1.PodFailed}))
+
+	assert.False(t, needsRestart(corev1.RestartPolicyNever, failed))
+	assert.False(t, needsRestart(corev1.RestartPolicyNever, nil))
+}
+
+// TestEnforceRestartPolicy verifies that a failed pod is restarted once per back-off period, and that the restarts are
+// reported in the status of its containers.
+func TestEnforceRestartPolicy(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod-0", UID: "pod-0"},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyOnFailure,
+			Containers:    []corev1.Container{{Name: "container-0", Image: "busybox"}},
+		},
+	}
+	p := &restartlessProvider{pod: pod}
+	s := New(Config{Provider: p, ResourceManager: testutil.FakeResourceManager()})
+	require.NotNil(t, s.restartManager)
+	recorder := record.NewFakeRecorder(10)
+
+	failed := containerStatus(corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"}})
+	status, err := s.enforceRestartPolicy(context.Background(), pod, failed, recorder)
+	require.NoError(t, err)
+	assert.Equal(t, 1, p.deleted)
+	assert.Equal(t, 1, p.created)
+	assert.Contains(t, <-recorder.Events, ReasonRestarting)
+	require.Len(t, status.ContainerStatuses, 1)
+	cs := status.ContainerStatuses[0]
+	assert.Equal(t, int32(1), cs.RestartCount)
+	assert.Equal(t, reasonContainerCreating, cs.State.Waiting.Reason)
+	assert.Equal(t, "Error", cs.LastTerminationState.Terminated.Reason)
+
+	// The pod fails again right away, so its restart is delayed.
+	status, err = s.enforceRestartPolicy(context.Background(), pod, failed, recorder)
+	require.NoError(t, err)
+	assert.Equal(t, 1, p.created)
+	assert.Contains(t, <-recorder.Events, ReasonBackOff)
+	assert.Equal(t, reasonCrashLoopBackOff, status.ContainerStatuses[0].State.Waiting.Reason)
+	assert.True(t, s.restartBackOffRemaining(pod) > 0)
+
+	// The back-off is only reported once.
+	_, err = s.enforceRestartPolicy(context.Background(), pod, failed, recorder)
+	require.NoError(t, err)
+	assert.Len(t, recorder.Events, 0)
+
+	// The restart count is reported along with the status of running containers.
+	running := containerStatus(corev1.ContainerState{Running: &corev1.ContainerStateRunning{}})
+	status, err = s.enforceRestartPolicy(context.Background(), pod, running, recorder)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), status.ContainerStatuses[0].RestartCount)
+	assert.NotNil(t, status.ContainerStatuses[0].State.Running)
+
+	s.restartManager.forget(pod.UID)
+	assert.Equal(t, time.Duration(0), s.restartBackOffRemaining(pod))
+	assert.Nil(t, s.restartManager.get(pod.UID))
+}
@@ -0,0 +1,155 @@
+package vkubelet
+
+import (
+	"context"
+	"time"
+
+	"github.com/cpuguy83/strongerrors/status/ocstatus"
+	pkgerrors "github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/virtual-kubelet/virtual-kubelet/log"
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+)
+
+const (
+	// ReasonKilling is the reason used in events emitted when the containers of a pod are being stopped.
+	ReasonKilling = "Killing"
+
+	// defaultTerminationGracePeriod is the grace period of pods which specify none, as defaulted by the API server.
+	defaultTerminationGracePeriod = 30 * time.Second
+	// terminationCheckInterval is the interval at which the status of a terminating pod is checked.
+	terminationCheckInterval = 2 * time.Second
+)
+
+// terminatePod gracefully stops the specified pod, which has been marked for deletion, before deleting it.
+// Providers which don't implement providers.PodTerminator have the pod deleted right away.
+// Otherwise, the pod is deleted once all its containers are reported as terminated or its grace period expires, and the
+// returned duration is the time after which the pod must be checked again while it is terminating.
+func (s *Server) terminatePod(ctx context.Context, pod *corev1.Pod, recorder record.EventRecorder) (time.Duration, error) {
+	ctx, span := trace.StartSpan(ctx, "terminatePod")
+	defer span.End()
+	addPodAttributes(span, pod)
+
+	if _, ok := providers.Unwrap(s.provider).(providers.PodTerminator); !ok {
+		return 0, s.deletePod(ctx, pod.Namespace, pod.Name)
+	}
+	pt := s.provider.(providers.PodTerminator)
+
+	// NOTE: Some providers return a non-nil error in their GetPod implementation when the pod is not found while some other don't.
+	// Hence, we ignore the error and just act upon the pod if it is non-nil (meaning that the provider still knows about the pod).
+	pp, _ := s.provider.GetPod(ctx, pod.Namespace, pod.Name)
+	if pp == nil {
+		s.finishTermination(pod)
+		return 0, s.forceDeletePodResource(ctx, pod.Namespace, pod.Name)
+	}
+
+	logger := log.G(ctx).WithField("pod", pod.GetName()).WithField("namespace", pod.GetNamespace())
+
+	if s.startTermination(pod) {
+		gracePeriod := podGracePeriod(pod)
+		recorder.Eventf(pod, corev1.EventTypeNormal, ReasonKilling, "Stopping pod with a grace period of %v", gracePeriod)
+		if err := pt.StopPod(ctx, pp, gracePeriod); err != nil {
+			s.finishTermination(pod)
+			span.SetStatus(ocstatus.FromError(err))
+			return 0, pkgerrors.Wrap(err, "error stopping pod in the provider")
+		}
+		span.Annotate(nil, "Stopping pod in provider")
+		logger.WithField("gracePeriod", gracePeriod).Info("Stopping pod")
+	}
+
+	// Report the termination progress of the containers, as known by the provider.
+	status, err := s.provider.GetPodStatus(ctx, pod.Namespace, pod.Name)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get status of terminating pod")
+	} else if status != nil {
+		pod = pod.DeepCopy()
+		pod.ResourceVersion = "" // Blank out resource version to prevent object has been modified error
+		pod.Status = *status
+		if _, err := s.k8sClient.CoreV1().Pods(pod.Namespace).UpdateStatus(pod); err != nil {
+			s.recordUpdateConflict(ctx, "pod", err)
+			logger.WithError(err).Warn("Failed to update status of terminating pod")
+		}
+	}
+
+	remaining := time.Until(pod.DeletionTimestamp.Time)
+	if (status == nil || !podStopped(status)) && remaining > 0 {
+		if remaining > terminationCheckInterval {
+			return terminationCheckInterval, nil
+		}
+		return remaining, nil
+	}
+	if remaining <= 0 {
+		logger.Warn("Grace period of pod expired before its containers were stopped")
+	}
+
+	// The termination of the pod is only finished once it is deleted from the provider, so that it isn't stopped again
+	// when deleting it fails.
+	return 0, s.deletePod(ctx, pod.Namespace, pod.Name)
+}
+
+// startTermination records that the specified pod is being stopped in the provider.
+// It returns false if the pod was already being stopped.
+func (s *Server) startTermination(pod *corev1.Pod) bool {
+	s.terminatingPodsLock.Lock()
+	defer s.terminatingPodsLock.Unlock()
+
+	if _, ok := s.terminatingPods[pod.UID]; ok {
+		return false
+	}
+	s.terminatingPods[pod.UID] = pod.Namespace + "/" + pod.Name
+	return true
+}
+
+// finishTermination forgets that the specified pod is being stopped in the provider.
+func (s *Server) finishTermination(pod *corev1.Pod) {
+	s.terminatingPodsLock.Lock()
+	defer s.terminatingPodsLock.Unlock()
+
+	delete(s.terminatingPods, pod.UID)
+}
+
+// finishTerminations forgets that the pods with the specified namespace and name are being stopped in the provider,
+// once they are deleted from it. Only the namespace and name of the pods which were force deleted from Kubernetes
+// while terminating are known by then.
+func (s *Server) finishTerminations(namespace, name string) {
+	s.terminatingPodsLock.Lock()
+	defer s.terminatingPodsLock.Unlock()
+
+	key := namespace + "/" + name
+	for uid, k := range s.terminatingPods {
+		if k == key {
+			delete(s.terminatingPods, uid)
+		}
+	}
+}
+
+// podGracePeriod returns the period the containers of the specified pod are given to stop once it is marked for deletion.
+// This is the grace period of the deletion, which defaults to the grace period specified by the pod.
+func podGracePeriod(pod *corev1.Pod) time.Duration {
+	switch {
+	case pod.DeletionGracePeriodSeconds != nil:
+		return time.Duration(*pod.DeletionGracePeriodSeconds) * time.Second
+	case pod.Spec.TerminationGracePeriodSeconds != nil:
+		return time.Duration(*pod.Spec.TerminationGracePeriodSeconds) * time.Second
+	}
+	return defaultTerminationGracePeriod
+}
+
+// podStopped returns whether the specified status reports that all the containers of a pod have exited.
+func podStopped(status *corev1.PodStatus) bool {
+	if status.Phase == corev1.PodSucceeded || status.Phase == corev1.PodFailed {
+		return true
+	}
+	if len(status.ContainerStatuses) == 0 {
+		return false
+	}
+	for _, cs := range status.ContainerStatuses {
+		if cs.State.Terminated == nil {
+			return false
+		}
+	}
+	return true
+}
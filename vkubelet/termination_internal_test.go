@@ -0,0 +1,159 @@
+package vkubelet
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/virtual-kubelet/virtual-kubelet/providers"
+)
+
+// TestPodGracePeriod verifies that the grace period of the deletion takes precedence over the one of the pod.
+func TestPodGracePeriod(t *testing.T) {
+	pod := &corev1.Pod{}
+	assert.Equal(t, defaultTerminationGracePeriod, podGracePeriod(pod))
+
+	specGrace := int64(60)
+	pod.Spec.TerminationGracePeriodSeconds = &specGrace
+	assert.Equal(t, time.Minute, podGracePeriod(pod))
+
+	deletionGrace := int64(5)
+	pod.DeletionGracePeriodSeconds = &deletionGrace
+	assert.Equal(t, 5*time.Second, podGracePeriod(pod))
+}
+
+// TestPodStopped verifies that a pod is considered stopped once all its containers are terminated.
+func TestPodStopped(t *testing.T) {
+	status := &corev1.PodStatus{Phase: corev1.PodRunning}
+	assert.False(t, podStopped(status))
+
+	status.ContainerStatuses = []corev1.ContainerStatus{
+		{Name: "container-0", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}},
+		{Name: "container-1", State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+	}
+	assert.False(t, podStopped(status))
+
+	status.ContainerStatuses[1].State = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 143}}
+	assert.True(t, podStopped(status))
+
+	assert.True(t, podStopped(&corev1.PodStatus{Phase: corev1.PodSucceeded}))
+}
+
+// TestTerminationTracking verifies that a pod is only stopped once until its termination is finished.
+func TestTerminationTracking(t *testing.T) {
+	s := New(Config{Provider: &capacityProvider{}})
+	pod := &corev1.Pod{}
+	pod.UID = "pod-0"
+
+	assert.True(t, s.startTermination(pod))
+	assert.False(t, s.startTermination(pod))
+	s.finishTermination(pod)
+	assert.True(t, s.startTermination(pod))
+}
+
+// terminatingProvider stops pods gracefully, reporting their containers as running until they are marked as stopped.
+type terminatingProvider struct {
+	providers.Provider
+	pods      map[string]*corev1.Pod
+	stopped   map[string]bool
+	stopCalls int
+	deleteErr error
+}
+
+func newTerminatingProvider(pods ...*corev1.Pod) *terminatingProvider {
+	p := &terminatingProvider{pods: make(map[string]*corev1.Pod), stopped: make(map[string]bool)}
+	for _, pod := range pods {
+		p.pods[pod.Name] = pod
+	}
+	return p
+}
+
+func (p *terminatingProvider) GetPod(ctx context.Context, namespace, name string) (*corev1.Pod, error) {
+	return p.pods[name], nil
+}
+
+func (p *terminatingProvider) GetPodStatus(ctx context.Context, namespace, name string) (*corev1.PodStatus, error) {
+	state := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	if p.stopped[name] {
+		state = corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 143}}
+	}
+	return &corev1.PodStatus{
+		Phase:             corev1.PodRunning,
+		ContainerStatuses: []corev1.ContainerStatus{{Name: "container-0", State: state}},
+	}, nil
+}
+
+func (p *terminatingProvider) StopPod(ctx context.Context, pod *corev1.Pod, gracePeriod time.Duration) error {
+	p.stopCalls++
+	return nil
+}
+
+func (p *terminatingProvider) DeletePod(ctx context.Context, pod *corev1.Pod) error {
+	if p.deleteErr != nil {
+		return p.deleteErr
+	}
+	delete(p.pods, pod.Name)
+	return nil
+}
+
+func terminatingPod(name string) *corev1.Pod {
+	deletion := metav1.NewTime(time.Now().Add(time.Minute))
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         "default",
+			Name:              name,
+			UID:               "uid-" + types.UID(name),
+			DeletionTimestamp: &deletion,
+		},
+	}
+}
+
+// TestTerminatePod verifies that a pod is stopped once, until it is deleted from the provider, including when deleting
+// it fails, and that its termination is forgotten once it is deleted, including when it is force deleted.
+func TestTerminatePod(t *testing.T) {
+	pod := terminatingPod("pod-0")
+	p := newTerminatingProvider(pod)
+	s := New(Config{Provider: p, Client: fake.NewSimpleClientset(pod)})
+	recorder := record.NewFakeRecorder(10)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		after, err := s.terminatePod(ctx, pod, recorder)
+		require.NoError(t, err)
+		assert.Equal(t, terminationCheckInterval, after)
+	}
+	assert.Equal(t, 1, p.stopCalls)
+	assert.Len(t, recorder.Events, 1)
+
+	// The pod isn't stopped again when deleting it fails.
+	p.stopped[pod.Name] = true
+	p.deleteErr = errors.New("failed")
+	_, err := s.terminatePod(ctx, pod, recorder)
+	assert.Error(t, err)
+	p.deleteErr = nil
+	after, err := s.terminatePod(ctx, pod, recorder)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), after)
+	assert.Equal(t, 1, p.stopCalls)
+	assert.Len(t, recorder.Events, 1)
+	assert.Empty(t, p.pods)
+	assert.Empty(t, s.terminatingPods)
+
+	// The termination of a pod force deleted from Kubernetes is forgotten once it is deleted from the provider.
+	pod = terminatingPod("pod-1")
+	p.pods[pod.Name] = pod
+	_, err = s.terminatePod(ctx, pod, recorder)
+	require.NoError(t, err)
+	assert.Len(t, s.terminatingPods, 1)
+	require.NoError(t, s.deletePod(ctx, pod.Namespace, pod.Name))
+	assert.Empty(t, s.terminatingPods)
+}
@@ -2,15 +2,19 @@ package vkubelet
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"go.opencensus.io/trace"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	corev1informers "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/virtual-kubelet/virtual-kubelet/manager"
 	"github.com/virtual-kubelet/virtual-kubelet/providers"
+	"github.com/virtual-kubelet/virtual-kubelet/providers/middleware"
 )
 
 const (
@@ -21,24 +25,89 @@ const (
 type Server struct {
 	nodeName        string
 	namespace       string
-	k8sClient       *kubernetes.Clientset
-	taint           *corev1.Taint
+	k8sClient       kubernetes.Interface
 	provider        providers.Provider
 	resourceManager *manager.ResourceManager
-	podSyncWorkers  int
 	podInformer     corev1informers.PodInformer
+	// configLock guards the settings below, which can be changed while the server is running.
+	configLock sync.Mutex
+	// taints and labels are the taints and labels the node is registered with, in addition to its default labels.
+	taints []corev1.Taint
+	labels map[string]string
+	// podSyncWorkers is the number of workers processing each work queue of the pod controller.
+	podSyncWorkers int
+	// podController is the pod controller of the running server, or nil when the server isn't running.
+	podController *PodController
+	// admitHandlers are the handlers which pods must be admitted by before being created in the provider.
+	admitHandlers []providers.PodAdmitHandler
+	// terminatingPods holds the UIDs of the pods which are being gracefully stopped in the provider, along with their
+	// namespace and name.
+	terminatingPods     map[types.UID]string
+	terminatingPodsLock sync.Mutex
+	// pushedPods holds the pods as last created or updated in the provider, by namespace and name, for changes to their
+	// mutable fields to be detected even though providers may not return all of them from GetPod.
+	pushedPods     map[string]*corev1.Pod
+	pushedPodsLock sync.Mutex
+	// nodeUID is the UID of the registered node, which events about the node refer to.
+	// It is empty until the node is registered.
+	nodeUID     types.UID
+	nodeUIDLock sync.Mutex
+	// orphanedPodsReconcileInterval is the interval at which pods which only exist in the provider are looked for.
+	orphanedPodsReconcileInterval time.Duration
+	// orphanedPodsDryRun is whether orphaned pods are only reported instead of being deleted.
+	orphanedPodsDryRun bool
+	// restartsDelegated is whether the provider delegates the enforcement of the restart policy of pods.
+	restartsDelegated bool
+	// restartManager restarts pods when the provider delegates restarts or when their containers are probed.
+	// It is nil otherwise.
+	restartManager *restartManager
+	// prober runs the probes of containers when enabled. It is nil otherwise.
+	prober *prober
+
+	// nodeLeaseDurationSeconds is the duration of the lease used as the node's heartbeat.
+	// Node leases are disabled when it is zero.
+	nodeLeaseDurationSeconds int32
+	// lastNodeStatus is the node status last reported to Kubernetes.
+	lastNodeStatus *corev1.NodeStatus
+	// lastNodeStatusReport is the time at which the node status was last reported to Kubernetes.
+	lastNodeStatusReport time.Time
 }
 
 // Config is used to configure a new server.
 type Config struct {
-	Client          *kubernetes.Clientset
+	Client          kubernetes.Interface
 	Namespace       string
 	NodeName        string
 	Provider        providers.Provider
 	ResourceManager *manager.ResourceManager
-	Taint           *corev1.Taint
-	PodSyncWorkers  int
-	PodInformer     corev1informers.PodInformer
+	// Taint is a taint of the node, which is added to Taints when set.
+	Taint *corev1.Taint
+	// Taints and Labels are set on the node in addition to its default labels, which take precedence.
+	// They can be changed while the server is running with UpdateNodeMetadata.
+	Taints []corev1.Taint
+	Labels map[string]string
+	// PodSyncWorkers can be changed while the server is running with SetPodSyncWorkers.
+	PodSyncWorkers int
+	PodInformer    corev1informers.PodInformer
+	// NodeLeaseDurationSeconds enables heartbeats using a coordination.k8s.io/v1beta1 Lease when greater than zero,
+	// which only clusters up to Kubernetes v1.21 serve.
+	// The lease is renewed every quarter of its duration.
+	NodeLeaseDurationSeconds int32
+	// PodAdmitHandlers are additional handlers which pods must be admitted by before being created in the provider.
+	// Pods are checked against the capacity of the provider first, unless DisableCapacityAdmission is set, and then by
+	// the provider itself when it implements providers.PodAdmitHandler.
+	PodAdmitHandlers []providers.PodAdmitHandler
+	// DisableCapacityAdmission stops pods whose resource requests don't fit in the capacity of the provider from being
+	// rejected, for providers whose capacity isn't a hard limit.
+	DisableCapacityAdmission bool
+	// OrphanedPodsReconcileInterval is the interval at which pods which exist in the provider but not in Kubernetes are
+	// looked for and deleted. They are only looked for at startup when it is zero.
+	OrphanedPodsReconcileInterval time.Duration
+	// OrphanedPodsDryRun makes orphaned pods only be reported, through logs, events and metrics, instead of being deleted.
+	OrphanedPodsDryRun bool
+	// ProbeContainers enables running the readiness and liveness probes of containers from the virtual-kubelet, for
+	// providers whose backend doesn't run them. Pods must be reachable on their IP from the virtual-kubelet.
+	ProbeContainers bool
 }
 
 // New creates a new virtual-kubelet server.
@@ -47,15 +116,57 @@ type Config struct {
 // This creates but does not start the server.
 // You must call `Run` on the returned object to start the server.
 func New(cfg Config) *Server {
+	provider := middleware.Metrics(cfg.NodeName)(cfg.Provider)
+
+	// The optional interfaces are looked up on the innermost provider, and called through the middlewares.
+	var admitHandlers []providers.PodAdmitHandler
+	if !cfg.DisableCapacityAdmission {
+		admitHandlers = append(admitHandlers, &capacityAdmitHandler{provider: provider})
+	}
+	if _, ok := providers.Unwrap(provider).(providers.PodAdmitHandler); ok {
+		admitHandlers = append(admitHandlers, provider.(providers.PodAdmitHandler))
+	}
+	admitHandlers = append(admitHandlers, cfg.PodAdmitHandlers...)
+
+	_, ok := providers.Unwrap(provider).(providers.RestartDelegator)
+	restartsDelegated := ok && provider.(providers.RestartDelegator).DelegateRestarts()
+
+	var (
+		rm *restartManager
+		pr *prober
+	)
+	if restartsDelegated || cfg.ProbeContainers {
+		rm = newRestartManager()
+	}
+	if cfg.ProbeContainers {
+		pr = newProber(provider)
+	}
+
+	taints := append([]corev1.Taint(nil), cfg.Taints...)
+	if cfg.Taint != nil {
+		taints = append(taints, *cfg.Taint)
+	}
+
 	return &Server{
 		namespace:       cfg.Namespace,
 		nodeName:        cfg.NodeName,
-		taint:           cfg.Taint,
+		taints:          taints,
+		labels:          cfg.Labels,
 		k8sClient:       cfg.Client,
 		resourceManager: cfg.ResourceManager,
-		provider:        cfg.Provider,
+		provider:        provider,
 		podSyncWorkers:  cfg.PodSyncWorkers,
 		podInformer:     cfg.PodInformer,
+		admitHandlers:   admitHandlers,
+		terminatingPods: make(map[types.UID]string),
+		pushedPods:      make(map[string]*corev1.Pod),
+
+		nodeLeaseDurationSeconds:      cfg.NodeLeaseDurationSeconds,
+		orphanedPodsReconcileInterval: cfg.OrphanedPodsReconcileInterval,
+		orphanedPodsDryRun:            cfg.OrphanedPodsDryRun,
+		restartsDelegated:             restartsDelegated,
+		restartManager:                rm,
+		prober:                        pr,
 	}
 }
 
@@ -69,13 +180,50 @@ func (s *Server) Run(ctx context.Context) error {
 		return err
 	}
 
-	go s.providerSyncLoop(ctx)
+	if s.nodeLeaseDurationSeconds > 0 {
+		go s.leaseSyncLoop(ctx)
+	}
+
+	// Providers which notify us about pod status changes don't need to have the status of every pod polled.
+	_, isPodNotifier := providers.Unwrap(s.provider).(providers.PodNotifier)
+	pc := NewPodController(s)
+	s.configLock.Lock()
+	s.podController = pc
+	podSyncWorkers := s.podSyncWorkers
+	s.configLock.Unlock()
+	defer func() {
+		s.configLock.Lock()
+		s.podController = nil
+		s.configLock.Unlock()
+	}()
+
+	go s.providerSyncLoop(ctx, !isPodNotifier, pc.recorder)
+
+	// The status of pods whose readiness or liveness changes is updated right away.
+	if s.prober != nil {
+		go s.prober.run(ctx, s.resourceManager.GetPods, pc.recorder, func(pod *corev1.Pod) {
+			pc.enqueuePodStatusUpdate(ctx, pod)
+		})
+	}
+
+	return pc.Run(ctx, podSyncWorkers)
+}
 
-	return NewPodController(s).Run(ctx, s.podSyncWorkers)
+// SetPodSyncWorkers changes the number of pod synchronization workers, starting or stopping workers right away when the
+// server is running. Stopped workers finish processing their current pod first.
+func (s *Server) SetPodSyncWorkers(podSyncWorkers int) {
+	s.configLock.Lock()
+	defer s.configLock.Unlock()
+
+	s.podSyncWorkers = podSyncWorkers
+	if s.podController != nil {
+		s.podController.setWorkers(podSyncWorkers)
+	}
 }
 
 // providerSyncLoop syncronizes pod states from the provider back to kubernetes
-func (s *Server) providerSyncLoop(ctx context.Context) {
+// Pod statuses are only polled when syncPodStatuses is true, otherwise only the node status is synchronized.
+func (s *Server) providerSyncLoop(ctx context.Context, syncPodStatuses bool, recorder record.EventRecorder) {
 	const sleepTime = 5 * time.Second
 
 	t := time.NewTimer(sleepTime)
@@ -89,8 +237,14 @@ func (s *Server) providerSyncLoop(ctx context.Context) {
 			t.Stop()
 
 			ctx, span := trace.StartSpan(ctx, "syncActualState")
+			start := time.Now()
 			s.updateNode(ctx)
-			s.updatePodStatuses(ctx)
+			s.recordSyncDuration(ctx, "updateNode", start)
+			if syncPodStatuses {
+				start = time.Now()
+				s.updatePodStatuses(ctx, recorder)
+				s.recordSyncDuration(ctx, "updatePodStatuses", start)
+			}
 			span.End()
 
 			// restart the timer
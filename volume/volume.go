@@ -0,0 +1,189 @@
+// Package volume renders the contents of pod volumes backed by Kubernetes resources, so that providers can materialize
+// them in whatever way their backend supports.
+package volume
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/virtual-kubelet/virtual-kubelet/fieldpath"
+	"github.com/virtual-kubelet/virtual-kubelet/manager"
+)
+
+// File is a single file of a rendered volume.
+type File struct {
+	// Path is the path of the file, relative to the root of the volume.
+	Path string
+	// Data is the contents of the file.
+	Data []byte
+	// Mode is the permission bits of the file.
+	Mode os.FileMode
+}
+
+// Supported returns whether the specified volume can be rendered.
+// These are the secret, configMap, downwardAPI and projected volumes.
+func Supported(vol *corev1.Volume) bool {
+	return vol.Secret != nil || vol.ConfigMap != nil || vol.DownwardAPI != nil || vol.Projected != nil
+}
+
+// Render returns the files of the specified secret, configMap, downwardAPI or projected volume of the pod, sorted by path.
+// The items, file modes and optional references of the volume are honoured in the same way as by the Kubelet.
+// Limits referenced by downwardAPI items default to the passed in allocatable resources of the node when unset.
+func Render(rm *manager.ResourceManager, pod *corev1.Pod, vol *corev1.Volume, allocatable corev1.ResourceList) ([]File, error) {
+	var (
+		files []File
+		err   error
+	)
+	switch {
+	case vol.Secret != nil:
+		src := vol.Secret
+		files, err = renderSecret(rm, pod, src.SecretName, src.Items, src.Optional, fileMode(src.DefaultMode, corev1.SecretVolumeSourceDefaultMode))
+	case vol.ConfigMap != nil:
+		src := vol.ConfigMap
+		files, err = renderConfigMap(rm, pod, src.Name, src.Items, src.Optional, fileMode(src.DefaultMode, corev1.ConfigMapVolumeSourceDefaultMode))
+	case vol.DownwardAPI != nil:
+		src := vol.DownwardAPI
+		files, err = renderDownwardAPI(pod, src.Items, allocatable, fileMode(src.DefaultMode, corev1.DownwardAPIVolumeSourceDefaultMode))
+	case vol.Projected != nil:
+		files, err = renderProjected(rm, pod, vol.Projected, allocatable)
+	default:
+		return nil, fmt.Errorf("volume %q of pod %s is of an unsupported type", vol.Name, pod.Name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to render volume %q of pod %s: %v", vol.Name, pod.Name, err)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files, nil
+}
+
+// renderSecret returns the files of a secret volume or projection.
+// A missing secret results in no files when the reference is optional.
+func renderSecret(rm *manager.ResourceManager, pod *corev1.Pod, name string, items []corev1.KeyToPath, optional *bool, mode os.FileMode) ([]File, error) {
+	s, err := rm.GetSecret(name, pod.Namespace)
+	if err != nil {
+		if errors.IsNotFound(err) && optional != nil && *optional {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read secret %q: %v", name, err)
+	}
+	return renderKeys("secret", name, s.Data, items, optional, mode)
+}
+
+// renderConfigMap returns the files of a configmap volume or projection, from both its string and binary data.
+// A missing configmap results in no files when the reference is optional.
+func renderConfigMap(rm *manager.ResourceManager, pod *corev1.Pod, name string, items []corev1.KeyToPath, optional *bool, mode os.FileMode) ([]File, error) {
+	m, err := rm.GetConfigMap(name, pod.Namespace)
+	if err != nil {
+		if errors.IsNotFound(err) && optional != nil && *optional {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read configmap %q: %v", name, err)
+	}
+
+	data := make(map[string][]byte, len(m.Data)+len(m.BinaryData))
+	for key, val := range m.Data {
+		data[key] = []byte(val)
+	}
+	for key, val := range m.BinaryData {
+		data[key] = val
+	}
+	return renderKeys("configmap", name, data, items, optional, mode)
+}
+
+// renderKeys returns the files for the keys of a secret or configmap.
+// When items are specified, only the listed keys are rendered, to the listed paths and with the listed modes.
+// A listed key which does not exist results in an error, unless the reference is optional.
+// https://github.com/kubernetes/kubernetes/blob/v1.13.1/pkg/volume/secret/secret.go#L255-L286
+func renderKeys(kind, name string, data map[string][]byte, items []corev1.KeyToPath, optional *bool, mode os.FileMode) ([]File, error) {
+	if len(items) == 0 {
+		files := make([]File, 0, len(data))
+		for key, val := range data {
+			files = append(files, File{Path: key, Data: val, Mode: mode})
+		}
+		return files, nil
+	}
+
+	files := make([]File, 0, len(items))
+	for _, item := range items {
+		val, ok := data[item.Key]
+		if !ok {
+			if optional != nil && *optional {
+				continue
+			}
+			return nil, fmt.Errorf("%s %q does not contain the %q key", kind, name, item.Key)
+		}
+		files = append(files, File{Path: item.Path, Data: val, Mode: fileMode(item.Mode, int32(mode))})
+	}
+	return files, nil
+}
+
+// renderDownwardAPI returns the files of a downwardAPI volume or projection.
+// https://github.com/kubernetes/kubernetes/blob/v1.13.1/pkg/volume/downwardapi/downwardapi.go#L235-L267
+func renderDownwardAPI(pod *corev1.Pod, items []corev1.DownwardAPIVolumeFile, allocatable corev1.ResourceList, mode os.FileMode) ([]File, error) {
+	files := make([]File, 0, len(items))
+	for _, item := range items {
+		var (
+			val string
+			err error
+		)
+		switch {
+		case item.FieldRef != nil:
+			val, err = fieldpath.ExtractFieldPathAsString(pod, item.FieldRef.FieldPath)
+		case item.ResourceFieldRef != nil:
+			val, err = fieldpath.ContainerResourceValue(item.ResourceFieldRef, pod, nil, allocatable)
+		default:
+			err = fmt.Errorf("item %q has no field or resource reference", item.Path)
+		}
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{Path: item.Path, Data: []byte(val), Mode: fileMode(item.Mode, int32(mode))})
+	}
+	return files, nil
+}
+
+// renderProjected returns the files of a projected volume, in which every source uses the volume's default mode.
+// https://github.com/kubernetes/kubernetes/blob/v1.13.1/pkg/volume/projected/projected.go#L250-L350
+func renderProjected(rm *manager.ResourceManager, pod *corev1.Pod, src *corev1.ProjectedVolumeSource, allocatable corev1.ResourceList) ([]File, error) {
+	mode := fileMode(src.DefaultMode, corev1.ProjectedVolumeSourceDefaultMode)
+
+	var files []File
+	for _, source := range src.Sources {
+		var (
+			sourceFiles []File
+			err         error
+		)
+		switch {
+		case source.Secret != nil:
+			s := source.Secret
+			sourceFiles, err = renderSecret(rm, pod, s.Name, s.Items, s.Optional, mode)
+		case source.ConfigMap != nil:
+			m := source.ConfigMap
+			sourceFiles, err = renderConfigMap(rm, pod, m.Name, m.Items, m.Optional, mode)
+		case source.DownwardAPI != nil:
+			sourceFiles, err = renderDownwardAPI(pod, source.DownwardAPI.Items, allocatable, mode)
+		case source.ServiceAccountToken != nil:
+			err = fmt.Errorf("service account token projections are not supported")
+		}
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, sourceFiles...)
+	}
+	return files, nil
+}
+
+// fileMode returns the specified mode, or the default mode when it is not set.
+func fileMode(mode *int32, defaultMode int32) os.FileMode {
+	if mode != nil {
+		return os.FileMode(*mode)
+	}
+	return os.FileMode(defaultMode)
+}
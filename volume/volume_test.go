@@ -0,0 +1,225 @@
+package volume_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	testutil "github.com/virtual-kubelet/virtual-kubelet/test/util"
+	"github.com/virtual-kubelet/virtual-kubelet/volume"
+)
+
+const (
+	// namespace is the namespace to which mock resources used in the tests belong.
+	namespace = "foo"
+)
+
+var (
+	// bTrue represents the "true" value.
+	// Used so we can take its address when a pointer to a bool is required.
+	bTrue = true
+	// mode0400 represents the 0400 file mode.
+	// Used so we can take its address when a pointer to an int32 is required.
+	mode0400 = int32(0400)
+)
+
+// fakePod returns a pod with the specified volume, and a single container with a memory limit.
+func fakePod(vol corev1.Volume) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      "pod-0",
+			Labels:    map[string]string{"app": "foo"},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{
+				{
+					Name: "container-0",
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{
+							corev1.ResourceMemory: resource.MustParse("64Mi"),
+						},
+					},
+				},
+			},
+			Volumes: []corev1.Volume{vol},
+		},
+	}
+}
+
+// TestRenderSecret verifies that all the keys of a secret are rendered with the default mode, unless items are specified.
+func TestRenderSecret(t *testing.T) {
+	rm := testutil.FakeResourceManager(testutil.FakeSecret(namespace, "secret-0", map[string]string{
+		"username": "admin",
+		"password": "secret",
+	}))
+
+	vol := corev1.Volume{
+		Name: "secret",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: "secret-0"},
+		},
+	}
+	files, err := volume.Render(rm, fakePod(vol), &vol, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []volume.File{
+		{Path: "password", Data: []byte("secret"), Mode: 0644},
+		{Path: "username", Data: []byte("admin"), Mode: 0644},
+	}, files)
+
+	vol.Secret.DefaultMode = &mode0400
+	vol.Secret.Items = []corev1.KeyToPath{
+		{Key: "password", Path: "auth/password"},
+	}
+	files, err = volume.Render(rm, fakePod(vol), &vol, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []volume.File{
+		{Path: "auth/password", Data: []byte("secret"), Mode: 0400},
+	}, files)
+
+	vol.Secret.Items = []corev1.KeyToPath{
+		{Key: "missing", Path: "missing"},
+	}
+	_, err = volume.Render(rm, fakePod(vol), &vol, nil)
+	assert.Error(t, err)
+}
+
+// TestRenderConfigMap verifies that both the string and binary data of a configmap are rendered.
+func TestRenderConfigMap(t *testing.T) {
+	m := testutil.FakeConfigMap(namespace, "configmap-0", map[string]string{
+		"config.yaml": "foo: bar",
+	})
+	m.BinaryData = map[string][]byte{
+		"data.bin": {0x00, 0x01},
+	}
+	rm := testutil.FakeResourceManager(m)
+
+	vol := corev1.Volume{
+		Name: "config",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "configmap-0"},
+				Items: []corev1.KeyToPath{
+					{Key: "config.yaml", Path: "config.yaml", Mode: &mode0400},
+					{Key: "data.bin", Path: "data.bin"},
+				},
+			},
+		},
+	}
+	files, err := volume.Render(rm, fakePod(vol), &vol, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []volume.File{
+		{Path: "config.yaml", Data: []byte("foo: bar"), Mode: 0400},
+		{Path: "data.bin", Data: []byte{0x00, 0x01}, Mode: 0644},
+	}, files)
+}
+
+// TestRenderOptional verifies that missing optional secrets, configmaps and keys are skipped, while mandatory ones are not.
+func TestRenderOptional(t *testing.T) {
+	rm := testutil.FakeResourceManager(testutil.FakeConfigMap(namespace, "configmap-0", map[string]string{
+		"foo": "bar",
+	}))
+
+	vol := corev1.Volume{
+		Name: "optional",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					{
+						Secret: &corev1.SecretProjection{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "missing"},
+							Optional:             &bTrue,
+						},
+					},
+					{
+						ConfigMap: &corev1.ConfigMapProjection{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "configmap-0"},
+							Items: []corev1.KeyToPath{
+								{Key: "foo", Path: "foo"},
+								{Key: "missing", Path: "missing"},
+							},
+							Optional: &bTrue,
+						},
+					},
+				},
+			},
+		},
+	}
+	files, err := volume.Render(rm, fakePod(vol), &vol, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []volume.File{
+		{Path: "foo", Data: []byte("bar"), Mode: 0644},
+	}, files)
+
+	vol.Projected.Sources[0].Secret.Optional = nil
+	_, err = volume.Render(rm, fakePod(vol), &vol, nil)
+	assert.Error(t, err)
+}
+
+// TestRenderDownwardAPI verifies that fields and resources of the pod are rendered, both in downwardAPI and projected volumes.
+func TestRenderDownwardAPI(t *testing.T) {
+	rm := testutil.FakeResourceManager()
+
+	items := []corev1.DownwardAPIVolumeFile{
+		{
+			Path:     "labels",
+			FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.labels"},
+		},
+		{
+			Path:     "name",
+			FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+			Mode:     &mode0400,
+		},
+		{
+			Path: "memory",
+			ResourceFieldRef: &corev1.ResourceFieldSelector{
+				ContainerName: "container-0",
+				Resource:      "limits.memory",
+				Divisor:       resource.MustParse("1Mi"),
+			},
+		},
+		{
+			Path: "cpu",
+			ResourceFieldRef: &corev1.ResourceFieldSelector{
+				ContainerName: "container-0",
+				Resource:      "limits.cpu",
+			},
+		},
+	}
+	allocatable := corev1.ResourceList{
+		corev1.ResourceCPU: resource.MustParse("4"),
+	}
+	expected := []volume.File{
+		{Path: "cpu", Data: []byte("4"), Mode: 0644},
+		{Path: "labels", Data: []byte(`app="foo"`), Mode: 0644},
+		{Path: "memory", Data: []byte("64"), Mode: 0644},
+		{Path: "name", Data: []byte("pod-0"), Mode: 0400},
+	}
+
+	vol := corev1.Volume{
+		Name: "downward",
+		VolumeSource: corev1.VolumeSource{
+			DownwardAPI: &corev1.DownwardAPIVolumeSource{Items: items},
+		},
+	}
+	files, err := volume.Render(rm, fakePod(vol), &vol, allocatable)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, files)
+
+	vol = corev1.Volume{
+		Name: "projected",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: []corev1.VolumeProjection{
+					{DownwardAPI: &corev1.DownwardAPIProjection{Items: items}},
+				},
+			},
+		},
+	}
+	files, err = volume.Render(rm, fakePod(vol), &vol, allocatable)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, files)
+}